@@ -0,0 +1,96 @@
+package log_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mulan-ext/log"
+	"go.uber.org/zap"
+)
+
+// benchmarkLogger 以 cfg 创建 logger 并发写入 b.N 条日志，控制台输出重定向到 /dev/null
+func benchmarkLogger(b *testing.B, cfg *log.Config) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = stdout
+		_ = devNull.Close()
+	})
+	cfg.NoGlobal = true
+	logger, err := log.NewWithConfig(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer logger.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			logger.Info("request handled", zap.String("method", "GET"), zap.String("path", "/api/v1/users"), zap.Int("status", 200), zap.Int("n", i))
+			i++
+		}
+	})
+	b.StopTimer()
+}
+
+func BenchmarkConsole(b *testing.B) {
+	for _, format := range []string{"console", "json"} {
+		b.Run(format, func(b *testing.B) {
+			benchmarkLogger(b, &log.Config{Format: format, ConsoleColor: "never"})
+		})
+	}
+}
+
+func BenchmarkFile(b *testing.B) {
+	for _, tt := range []struct{ name, params string }{
+		{"plain", ""},
+		{"buffered", "?buffer=256k"},
+		{"sharded", "?buffer=256k&shards=4"},
+		{"redacted", "?redact-values=secrets"},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			logFile := filepath.Join(b.TempDir(), "app.log")
+			benchmarkLogger(b, &log.Config{ConsoleLevel: "fatal", Adaptors: []string{"file://" + logFile + tt.params}})
+		})
+	}
+}
+
+func BenchmarkHTTP(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	for _, tt := range []struct{ name, params string }{
+		{"channel", "?overflow=block"},
+		{"ring", "?queue=ring&overflow=block"},
+		{"gzip", "?overflow=block&compress=gzip"},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			benchmarkLogger(b, &log.Config{ConsoleLevel: "fatal", Adaptors: []string{srv.URL + "/logs" + tt.params}})
+		})
+	}
+}
+
+// BenchmarkFanout 多个适配器共用编码器时每条日志只编码一次
+func BenchmarkFanout(b *testing.B) {
+	dir := b.TempDir()
+	benchmarkLogger(b, &log.Config{ConsoleLevel: "fatal", Adaptors: []string{
+		"file://" + filepath.Join(dir, "a.log") + "?buffer=256k",
+		"file://" + filepath.Join(dir, "b.log") + "?buffer=256k",
+		"file://" + filepath.Join(dir, "c.log") + "?buffer=256k",
+	}})
+}
+
+func BenchmarkProduction(b *testing.B) {
+	logFile := filepath.Join(b.TempDir(), "app.log")
+	cfg := log.ProductionConfig("file://" + logFile)
+	cfg.ConsoleLevel = "fatal"
+	benchmarkLogger(b, cfg)
+}
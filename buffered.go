@@ -0,0 +1,145 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultBufferedEntries 请求日志缓冲的默认最大条数
+const defaultBufferedEntries = 1000
+
+// BufferedOptions Buffered 的选项
+type BufferedOptions struct {
+	// Threshold 请求耗时达到该值时写出缓冲的日志，0 表示只在出错时写出
+	Threshold time.Duration
+	// MaxEntries 缓冲的最大条数，超出时丢弃最早的日志，默认 1000
+	MaxEntries int
+}
+
+// RequestBuffer 请求级日志缓冲，见 Buffered
+type RequestBuffer struct {
+	opts  BufferedOptions
+	start time.Time
+
+	mu      sync.Mutex
+	entries []flightEntry
+	dropped int
+	done    bool // 已写出或已结束，之后的日志直接写出
+}
+
+// Buffered 返回 context 中的 logger 改为缓冲日志的 context：之后通过 FromContext 取得的 logger
+// 写出的日志暂存在内存中，请求结束时调用 RequestBuffer.Finish，出错或耗时超过阈值才写出，否则丢弃。
+// 请求中出现 error 及以上级别的日志时立即写出已缓冲的日志，之后的日志不再缓冲
+func Buffered(ctx context.Context) (context.Context, *RequestBuffer) {
+	return BufferedWithOptions(ctx, BufferedOptions{})
+}
+
+// BufferedWithOptions 与 Buffered 相同，可指定耗时阈值与缓冲条数
+func BufferedWithOptions(ctx context.Context, opts BufferedOptions) (context.Context, *RequestBuffer) {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultBufferedEntries
+	}
+	b := &RequestBuffer{opts: opts, start: time.Now()}
+	cl := loggerFromContext(ctx)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger := cl.logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &bufferedCore{Core: core, buf: b}
+	}))
+	return context.WithValue(ctx, ctxKey{}, ctxLogger{logger: logger, fields: cl.fields}), b
+}
+
+// Finish 结束请求：err 不为 nil 或耗时达到 Threshold 时按原顺序写出缓冲的日志，否则丢弃，
+// 返回是否写出以及写出时适配器的写入错误。之后通过该 context 写出的日志不再缓冲
+func (b *RequestBuffer) Finish(err error) (bool, error) {
+	keep := err != nil || b.opts.Threshold > 0 && time.Since(b.start) >= b.opts.Threshold
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return false, nil
+	}
+	var werr error
+	if keep {
+		werr = b.flushLocked()
+	}
+	b.entries, b.dropped, b.done = nil, 0, true
+	return keep, werr
+}
+
+// Flush 立即写出缓冲的日志，返回适配器的写入错误，之后的日志不再缓冲
+func (b *RequestBuffer) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return nil
+	}
+	err := b.flushLocked()
+	b.entries, b.dropped, b.done = nil, 0, true
+	return err
+}
+
+// flushLocked 写出缓冲的日志，第一条附加因缓冲已满丢弃的条数 (buffer_dropped)，合并各条的写入错误
+func (b *RequestBuffer) flushLocked() error {
+	var errs []error
+	for i, e := range b.entries {
+		fields := e.fields
+		if i == 0 && b.dropped > 0 {
+			fields = append(fields, zap.Int("buffer_dropped", b.dropped))
+		}
+		errs = append(errs, writeChecked(e.core.Check(e.ent, nil), fields))
+	}
+	return errors.Join(errs...)
+}
+
+// add 缓冲一条日志，返回 false 表示不再缓冲、应直接写出；error 日志触发写出时一并返回写出的错误
+func (b *RequestBuffer) add(core zapcore.Core, ent zapcore.Entry, fields []zapcore.Field) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return false, nil
+	}
+	if ent.Level >= zapcore.ErrorLevel {
+		err := b.flushLocked()
+		b.entries, b.dropped, b.done = nil, 0, true
+		return false, err
+	}
+	if len(b.entries) >= b.opts.MaxEntries {
+		b.entries = slices.Delete(b.entries, 0, 1)
+		b.dropped++
+	}
+	b.entries = append(b.entries, flightEntry{core: core, ent: ent, fields: slices.Clone(fields)})
+	return true, nil
+}
+
+// bufferedCore 将日志交给 RequestBuffer 缓冲，不再缓冲时经由内层 Core 的 Check 直接写出
+type bufferedCore struct {
+	zapcore.Core
+	buf *RequestBuffer
+}
+
+func (c *bufferedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &bufferedCore{Core: c.Core.With(fields), buf: c.buf}
+}
+
+func (c *bufferedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 缓冲的日志写出失败的错误随触发写出的 error 日志一起返回，经 zap 的 ErrorOutput 报告
+func (c *bufferedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buffered, err := c.buf.add(c.Core, ent, fields)
+	if buffered {
+		return nil
+	}
+	return errors.Join(err, writeChecked(c.Core.Check(ent, nil), fields))
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBuffered(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := NewContext(context.Background(), zap.New(core).With(zap.String("request_id", "r1")))
+
+	// 成功的请求丢弃缓冲的日志
+	ctx, buf := Buffered(base)
+	FromContext(ctx).Info("step 1")
+	FromContext(ctx).Debug("below level")
+	if flushed, _ := buf.Finish(nil); flushed || logs.Len() != 0 {
+		t.Fatalf("logged = %d", logs.Len())
+	}
+	// 结束后的日志直接写出
+	FromContext(ctx).Info("after finish")
+	if logs.Len() != 1 {
+		t.Fatalf("logged = %d", logs.Len())
+	}
+	logs.TakeAll()
+
+	// 出错的请求按原顺序写出
+	ctx, buf = Buffered(base)
+	FromContext(ctx).Info("step 1")
+	FromContext(ctx).With(zap.Int("n", 2)).Warn("step 2")
+	if flushed, err := buf.Finish(errors.New("boom")); !flushed || err != nil {
+		t.Fatal("Finish(err) should flush")
+	}
+	entries := logs.TakeAll()
+	if len(entries) != 2 || entries[0].Message != "step 1" || entries[1].ContextMap()["n"] != int64(2) || entries[1].ContextMap()["request_id"] != "r1" {
+		t.Errorf("entries = %v", entries)
+	}
+	if flushed, _ := buf.Finish(errors.New("again")); flushed {
+		t.Error("second Finish should not flush")
+	}
+}
+
+func TestBufferedErrorAndThreshold(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := NewContext(context.Background(), zap.New(core))
+
+	// error 日志立即写出已缓冲的日志，之后不再缓冲
+	ctx, buf := BufferedWithOptions(base, BufferedOptions{MaxEntries: 2})
+	for _, msg := range []string{"a", "b", "c"} {
+		FromContext(ctx).Info(msg)
+	}
+	FromContext(ctx).Error("failed")
+	FromContext(ctx).Info("d")
+	entries := logs.TakeAll()
+	if len(entries) != 4 || entries[0].Message != "b" || entries[0].ContextMap()["buffer_dropped"] != int64(1) || entries[2].Message != "failed" {
+		t.Errorf("entries = %v", entries)
+	}
+	buf.Finish(nil)
+
+	// 耗时超过阈值时写出
+	ctx, buf = BufferedWithOptions(base, BufferedOptions{Threshold: 10 * time.Millisecond})
+	FromContext(ctx).Info("slow")
+	time.Sleep(15 * time.Millisecond)
+	if flushed, _ := buf.Finish(nil); !flushed || logs.Len() != 1 {
+		t.Errorf("logged = %d", logs.Len())
+	}
+}
+
+func TestBufferedWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	base := NewContext(context.Background(), zap.New(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel)))
+
+	// 缓冲日志写出时的错误由 Finish / Flush 返回
+	ctx, buf := Buffered(base)
+	FromContext(ctx).Info("step 1")
+	if flushed, err := buf.Finish(errors.New("boom")); !flushed || err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Finish() = %v, %v", flushed, err)
+	}
+	_, buf = Buffered(base)
+	if err := buf.Flush(); err != nil {
+		t.Errorf("Flush() of empty buffer = %v", err)
+	}
+
+	// 不再缓冲时直接写出的错误返回给调用方
+	core := &bufferedCore{Core: newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel), buf: buf}
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "lost"}, nil); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Write() error = %v", err)
+	}
+	// error 日志触发写出时，缓冲日志与该日志的错误一起返回
+	_, buf = Buffered(base)
+	core.buf = buf
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "buffered"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"}, nil); err == nil || strings.Count(err.Error(), "disk full") != 2 {
+		t.Errorf("Write() error = %v", err)
+	}
+}
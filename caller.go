@@ -0,0 +1,72 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// parseCallerPath 解析 Config.CallerPath，返回 nil 表示使用默认的 包名/文件名:行号
+func parseCallerPath(mode string) zapcore.CallerEncoder {
+	switch mode {
+	case "", "short":
+		return nil
+	case "full":
+		return zapcore.FullCallerEncoder
+	case "module":
+		return trimCallerEncoder(moduleRoots())
+	default:
+		return trimCallerEncoder([]string{mode})
+	}
+}
+
+// trimCallerEncoder 输出相对于 roots 中第一个匹配前缀的路径，均不匹配时退回短路径
+func trimCallerEncoder(roots []string) zapcore.CallerEncoder {
+	prefixes := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if root = strings.TrimRight(filepath.ToSlash(root), "/"); root != "" {
+			prefixes = append(prefixes, root+"/")
+		}
+	}
+	return func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+		if !caller.Defined {
+			enc.AppendString("undefined")
+			return
+		}
+		file := filepath.ToSlash(caller.File)
+		for _, prefix := range prefixes {
+			if rest, ok := strings.CutPrefix(file, prefix); ok {
+				caller.File = rest
+				enc.AppendString(caller.FullPath())
+				return
+			}
+		}
+		zapcore.ShortCallerEncoder(caller, enc)
+	}
+}
+
+// moduleRoots 返回主模块的根：-trimpath 构建时文件路径以模块路径开头，
+// 本地运行时为从工作目录向上查找到的 go.mod 所在目录
+func moduleRoots() []string {
+	var roots []string
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" && bi.Main.Path != "command-line-arguments" {
+		roots = append(roots, bi.Main.Path)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				roots = append(roots, dir)
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return roots
+}
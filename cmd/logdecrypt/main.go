@@ -0,0 +1,33 @@
+// logdecrypt 解密 encrypt=aes-gcm 写入的日志文件，明文输出到 stdout
+//
+//	LOG_KEY=<hex|base64> logdecrypt /var/log/app.log [more files...]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mulan-ext/log"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	keyEnv := pflag.String("key-env", "LOG_KEY", "environment variable holding the hex or base64 encoded key")
+	pflag.Parse()
+	if pflag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: logdecrypt [--key-env LOG_KEY] <file>...")
+		os.Exit(2)
+	}
+
+	key, err := log.ParseEncryptionKey(os.Getenv(*keyEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logdecrypt: %s: %v\n", *keyEnv, err)
+		os.Exit(1)
+	}
+	for _, path := range pflag.Args() {
+		if err := log.DecryptFile(path, key, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "logdecrypt: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
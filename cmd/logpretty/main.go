@@ -0,0 +1,57 @@
+// logpretty 将适配器写出的 JSON 日志转为控制台可读格式输出到 stdout，未指定文件时读取 stdin，
+// .gz 结尾的文件（compress=gzip 的备份）自动解压
+//
+//	logpretty /var/log/app.log /var/log/app-2026-01-01T00-00-00.000.log.gz
+//	tail -F /var/log/app.log | logpretty
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mulan-ext/log"
+)
+
+func main() {
+	out := log.PrettyWriter(os.Stdout)
+	if len(os.Args) < 2 {
+		if _, err := io.Copy(out, os.Stdin); err != nil {
+			fail("stdin", err)
+		}
+	}
+	for _, path := range os.Args[1:] {
+		if err := copyFile(out, path); err != nil {
+			fail(path, err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		fail("stdout", err)
+	}
+}
+
+func copyFile(out io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	_, err = io.Copy(out, r)
+	return err
+}
+
+func fail(name string, err error) {
+	fmt.Fprintf(os.Stderr, "logpretty: %s: %v\n", name, err)
+	os.Exit(1)
+}
@@ -1,27 +1,172 @@
 package log
 
-import "github.com/spf13/pflag"
+import (
+	"io"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
 
 type Config struct {
 	Level        string   `json:"level" yaml:"level"`                // 默认日志级别: debug, info, warn, error
 	ConsoleLevel string   `json:"console_level" yaml:"consoleLevel"` // 控制台日志级别，默认继承 Level
 	Mode         string   `json:"mode" yaml:"mode"`                  // 运行模式: local, server
-	Format       string   `json:"format" yaml:"format"`              // 控制台格式: console, json
+	Format       string   `json:"format" yaml:"format"`              // 控制台格式: console, json, json-pretty, logfmt
 	Adaptors     []string `json:"adaptors" yaml:"adaptors"`          // 输出适配器 DSN 列表
 	Skip         int      `json:"skip" yaml:"skip"`                  // 跳过调用栈层数
 	JSON         bool     `json:"json" yaml:"json"`                  // 是否输出 JSON 格式
+	NoGlobal     bool     `json:"no_global" yaml:"noGlobal"`         // 不替换 zap 全局 logger，需要时调用 Logger.MakeGlobal
+	// Console 是否输出到控制台，nil 表示 true；容器中 stdout 同样被采集、只需要文件或 HTTP 输出时设为 false，
+	// 避免同一条日志写两次
+	Console *bool `json:"console" yaml:"console"`
+	// CloseOnSignal 收到 SIGINT/SIGTERM 时先关闭全部适配器再按原有方式退出，见 Logger.CloseOnSignal
+	CloseOnSignal bool `json:"close_on_signal" yaml:"closeOnSignal"`
+
+	// EnvPrefix 环境变量覆盖的前缀，默认 LOG_：LOG_LEVEL、LOG_CONSOLE_LEVEL、LOG_FORMAT 与 LOG_ADAPTORS
+	// (空白分隔的 DSN 列表) 优先于配置，便于不改配置临时调整已部署的程序；"-" 关闭
+	EnvPrefix string `json:"env_prefix" yaml:"envPrefix"`
+
+	// Probe 创建远端适配器 (HTTP) 时探测是否可达，失败立即输出诊断并记录到 AdaptorStats.LastError，
+	// 适配器仍照常创建；也可以在单个 DSN 上设置 probe=true
+	Probe bool `json:"probe" yaml:"probe"`
+
+	// Routes 适配器路由规则，按顺序匹配，每条日志只写入第一条匹配规则指定的适配器，
+	// 未匹配任何规则时写入全部适配器；控制台不受路由影响
+	Routes []Route `json:"routes" yaml:"routes"`
+
+	StacktraceLevel string `json:"stacktrace_level" yaml:"stacktraceLevel"` // 输出调用栈的最低级别，默认 error，none 关闭
+	DisableCaller   bool   `json:"disable_caller" yaml:"disableCaller"`     // 不记录调用位置
+	CallerPath      string `json:"caller_path" yaml:"callerPath"`           // 调用位置路径: short (默认), full, module (相对模块根目录) 或要去掉的路径前缀
+
+	ConsoleColor      string `json:"console_color" yaml:"consoleColor"`            // 控制台级别颜色: auto, always, never，默认 auto (终端且未设置 NO_COLOR/CI 时启用)
+	ConsoleTimeLayout string `json:"console_time_layout" yaml:"consoleTimeLayout"` // 控制台时间格式: epoch, iso8601, rfc3339, rfc3339nano 或 Go 时间布局
+	ConsoleHideCaller bool   `json:"console_hide_caller" yaml:"consoleHideCaller"` // 控制台不输出调用位置
+	ConsoleOutput     string `json:"console_output" yaml:"consoleOutput"`          // 控制台输出目标: stdout, stderr，默认 stdout
+
+	// TimeZone 控制台与适配器时间戳的时区: UTC、Local 或 IANA 时区名 (如 Asia/Shanghai)，默认本地时间，
+	// DSN 中的 tz 参数优先。毫秒时间戳不受影响；文件备份名只区分 UTC 与本地时间
+	TimeZone string `json:"time_zone" yaml:"timeZone"`
+
+	// Keys 适配器编码器的标准键名映射 (ts, level, msg, caller, logger, stacktrace)，
+	// 如 {"ts": "@timestamp", "msg": "message"}，DSN 中的 keys 参数优先
+	Keys map[string]string `json:"keys" yaml:"keys"`
+
+	// RedactKeys 全局脱敏的字段键名正则，如 "(?i)password|token|secret"，匹配的字段值整体替换为 ***
+	RedactKeys string `json:"redact_keys" yaml:"redactKeys"`
+	// RedactValues 全局脱敏的值规则：内置规则名 (email, card, aws-key, bearer, cn-id, cn-phone 等)、
+	// 规则组 (secrets, pii) 或正则，字符串字段与消息中匹配的部分替换为 ***
+	RedactValues []string `json:"redact_values" yaml:"redactValues"`
+
+	// MaxMessageSize、MaxFieldSize、MaxEntrySize 适配器的消息、字符串字段与单条日志的最大字节数 (如 "4k", "1m")，
+	// 作为 DSN 参数 max-message、max-field、max-entry 的默认值，控制台不受影响
+	MaxMessageSize string `json:"max_message_size" yaml:"maxMessageSize"`
+	MaxFieldSize   string `json:"max_field_size" yaml:"maxFieldSize"`
+	MaxEntrySize   string `json:"max_entry_size" yaml:"maxEntrySize"`
+
+	// FileBufferSize 文件适配器的写缓冲大小 (如 "256k")，作为 DSN 参数 buffer 的默认值，
+	// 此时未设置 flush-interval 的适配器每秒刷新一次
+	FileBufferSize string `json:"file_buffer_size" yaml:"fileBufferSize"`
+
+	// Sampling 采样：每秒内级别与消息相同的日志先输出 Initial 条，之后每 Thereafter 条输出一条，
+	// 作用于控制台与全部适配器，nil 表示不采样
+	Sampling *zap.SamplingConfig `json:"sampling" yaml:"sampling"`
+
+	// Aggregate 高频日志聚合：消息匹配的日志不再逐条写出，按级别、logger 与消息计数，
+	// 每个周期写出一条带 count 与 interval 字段的汇总，作用于控制台与全部适配器，nil 表示不聚合
+	Aggregate *AggregateConfig `json:"aggregate" yaml:"aggregate"`
+
+	// FlightRecorder 飞行记录器保留的日志条数：未达到输出级别的 debug 日志暂存在内存中，
+	// 出现 error 及以上级别的日志时先写出这些日志，以 flight_id 字段关联，0 表示关闭
+	FlightRecorder int `json:"flight_recorder" yaml:"flightRecorder"`
+
+	// Sequence 为每条日志附加进程内递增的 seq 字段与本次运行的 run_id 字段，
+	// 下游可据此发现丢失的日志，并为毫秒时间戳相同的日志排序
+	Sequence bool `json:"sequence" yaml:"sequence"`
+
+	// ExpandErrors 将 error 字段展开为错误链 (<key>_chain)、根因 (<key>_root、<key>_root_type)
+	// 与 pkg/errors 风格错误的调用栈 (<key>_stack)，便于按根因聚合，作用于控制台与全部适配器
+	ExpandErrors bool `json:"expand_errors" yaml:"expandErrors"`
+
+	// LevelOverrides 命名 logger (log.Named) 的级别，如 {"db": "warn"}，只能在各输出级别基础上进一步收紧，
+	// 在 MakeGlobal 时生效
+	LevelOverrides map[string]string `json:"level_overrides" yaml:"levelOverrides"`
+
+	// TraceSpanEvents 通过 FromContext 取得的 logger 记录 error 及以上级别日志时，
+	// 同时写入 context 中正在记录的 OpenTelemetry span 作为事件
+	TraceSpanEvents bool `json:"trace_span_events" yaml:"traceSpanEvents"`
+
+	FatalAsError  bool `json:"fatal_as_error" yaml:"fatalAsError"`   // Fatal 按 error 级别输出且不退出进程，用于测试
+	FatalNoExit   bool `json:"fatal_no_exit" yaml:"fatalNoExit"`     // Fatal 写出并执行 OnFatal 后不调用 os.Exit
+	FatalExitCode int  `json:"fatal_exit_code" yaml:"fatalExitCode"` // Fatal 退出码，默认 1
+
+	// Diagnostics 日志管道自身故障（适配器创建失败、写入或发送失败等）的诊断输出: stderr (默认), stdout, none，
+	// 同一来源每分钟最多一条；DiagnosticsWriter 不为 nil 时优先使用
+	Diagnostics       string    `json:"diagnostics" yaml:"diagnostics"`
+	DiagnosticsWriter io.Writer `json:"-" yaml:"-"`
+
+	// ExtraCores 与控制台、适配器一起写出日志的自定义 Core（如自行实现的 zapcore.Core），不受路由影响，
+	// 全局脱敏对其生效，级别由 Core 自身决定
+	ExtraCores []zapcore.Core `json:"-" yaml:"-"`
+	// ExtraWriters 以适配器的 JSON 编码与 Level 写出日志的自定义 writer，不受路由影响。
+	// Logger 只同步不关闭这些 writer，由调用方在 Logger.Close 之后自行关闭
+	ExtraWriters []io.Writer `json:"-" yaml:"-"`
+
+	// Processors 在日志进入各输出之前依次执行的处理器，可丢弃、修改或补充日志
+	Processors []Processor `json:"-" yaml:"-"`
+	// Hooks 每条写出的日志都会调用的 Hook，可用于计数、转发告警等，见 Logger.AddHook
+	Hooks []func(zapcore.Entry) error `json:"-" yaml:"-"`
+	// OnFatal Fatal 日志写出、适配器关闭后，退出进程前的回调，可用于上报指标等
+	OnFatal func(ent zapcore.Entry) `json:"-" yaml:"-"`
+	// OnRotate 文件适配器滚动后的回调，参数为被滚动的文件路径
+	OnRotate func(path string) `json:"-" yaml:"-"`
+	// OnDrop 日志被丢弃（缓冲区满、重试耗尽、限流）时的回调，adaptor 为脱敏后的 DSN。
+	// 回调可能在写日志的调用方协程中同步执行，不应再通过同一 Logger 记录日志
+	OnDrop func(adaptor string, n int, reason error) `json:"-" yaml:"-"`
 }
 
 func (c *Config) FlagSet() *pflag.FlagSet { return FlagSet() }
 
+// consoleEnabled 是否创建控制台输出，见 Config.Console
+func (c *Config) consoleEnabled() bool { return c.Console == nil || *c.Console }
+
 func FlagSet() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("log", pflag.ContinueOnError)
 	fs.String("log.level", "info", "log level: debug, info, warn, error")
 	fs.String("log.console-level", "", "console log level: debug, info, warn, error")
 	fs.String("log.mode", "", "log mode: local, server")
-	fs.String("log.format", "", "console log format: console, json")
+	fs.String("log.format", "", "console log format: console, json, json-pretty, logfmt")
 	fs.StringSlice("log.adaptors", []string{}, "log adaptors DSN (e.g., file:///var/log/app.log?max-size=100m&max-age=30d)")
 	fs.Int("log.skip", 1, "log skip caller stack frames")
 	fs.Bool("log.json", false, "log output JSON format")
+	fs.Bool("log.console", true, "write logs to the console (stdout/stderr)")
+	fs.Bool("log.no-global", false, "do not replace the zap global logger")
+	fs.String("log.env-prefix", "", "prefix of environment overrides (default LOG_, - disables): LEVEL, CONSOLE_LEVEL, FORMAT, ADAPTORS")
+	fs.Bool("log.probe", false, "check that remote adaptors are reachable when they are created")
+	fs.Bool("log.close-on-signal", false, "flush and close adaptors on SIGINT/SIGTERM before exiting")
+	fs.String("log.stacktrace-level", "", "minimum level that records stacktraces (default error, none disables)")
+	fs.Bool("log.disable-caller", false, "do not record caller")
+	fs.String("log.caller-path", "", "caller path: short, full, module or a path prefix to trim")
+	fs.String("log.console-color", "", "console level colors: auto, always, never")
+	fs.String("log.console-time-layout", "", "console time layout: epoch, iso8601, rfc3339, rfc3339nano or a Go layout")
+	fs.Bool("log.console-hide-caller", false, "hide caller in console output")
+	fs.String("log.console-output", "", "console output: stdout, stderr")
+	fs.String("log.time-zone", "", "time zone of timestamps: UTC, Local or an IANA name (e.g., Asia/Shanghai)")
+	fs.StringToString("log.keys", nil, "adaptor encoder key mapping (e.g., ts=@timestamp,msg=message)")
+	fs.String("log.redact-keys", "", "regexp of field keys whose values are masked (e.g., (?i)password|token|secret)")
+	fs.StringArray("log.redact-values", nil, "value patterns to mask: a builtin rule (email, card, aws-key, bearer, ...), a group (secrets, pii) or a regexp (repeatable)")
+	fs.String("log.max-message-size", "", "maximum adaptor message size before truncation (e.g., 4k)")
+	fs.String("log.max-field-size", "", "maximum adaptor string field size before truncation (e.g., 16k)")
+	fs.String("log.max-entry-size", "", "maximum encoded adaptor entry size (e.g., 1m)")
+	fs.String("log.file-buffer-size", "", "default write buffer size for file adaptors (e.g., 256k)")
+	fs.Bool("log.sequence", false, "add a per-process sequence number (seq) and run id (run_id) to every entry")
+	fs.Bool("log.expand-errors", false, "expand error fields into the cause chain, root cause and stacktrace")
+	fs.Int("log.flight-recorder", 0, "number of suppressed debug entries kept in memory and written when an error is logged")
+	fs.StringToString("log.level-overrides", nil, "named logger levels (e.g., db=warn,http=error)")
+	fs.Bool("log.fatal-as-error", false, "log Fatal at error level without exiting (for tests)")
+	fs.Bool("log.fatal-no-exit", false, "do not call os.Exit after Fatal")
+	fs.Int("log.fatal-exit-code", 1, "exit code used by Fatal")
+	fs.String("log.diagnostics", "", "output for logging pipeline failures: stderr, stdout, none")
+	fs.Bool("log.trace-span-events", false, "record error logs as events on the OpenTelemetry span in context")
 	return fs
 }
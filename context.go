@@ -0,0 +1,69 @@
+package log
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ctxKey context 中保存 logger 的键
+type ctxKey struct{}
+
+// ctxLogger context 中保存的 logger 及通过 With 追加的字段，字段用于生成 span 事件属性
+type ctxLogger struct {
+	logger *zap.Logger
+	fields []zap.Field
+}
+
+// NewContext 返回携带 logger 的 context
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ctxLogger{logger: logger})
+}
+
+// FromContext 取出 context 中的 logger，没有时返回全局 logger。
+// context 中存在有效的 OpenTelemetry span 时自动附加 trace_id、span_id 与 trace_flags 字段，
+// 开启 Config.TraceSpanEvents 时 error 及以上级别的日志同时记录为 span 事件
+func FromContext(ctx context.Context) *zap.Logger {
+	cl := loggerFromContext(ctx)
+	if ctx == nil {
+		return cl.logger
+	}
+	logger := cl.logger
+	span := trace.SpanFromContext(ctx)
+	if sc := span.SpanContext(); sc.IsValid() {
+		logger = logger.With(
+			zap.String(TraceIDKey, sc.TraceID().String()),
+			zap.String(SpanIDKey, sc.SpanID().String()),
+			zap.String(TraceFlagsKey, sc.TraceFlags().String()),
+		)
+	}
+	if spanEvents.Load() && span.IsRecording() {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newSpanEventCore(core, span, cl.fields)
+		}))
+	}
+	return logger
+}
+
+// loggerFromContext 取出 context 中保存的 logger，不附加链路字段
+func loggerFromContext(ctx context.Context) ctxLogger {
+	if ctx != nil {
+		if cl, ok := ctx.Value(ctxKey{}).(ctxLogger); ok {
+			return cl
+		}
+	}
+	return ctxLogger{logger: zap.L()}
+}
+
+// With 在 context 中的 logger 上追加字段并返回新的 context，
+// 之后通过 FromContext 取出的 logger 都会带上这些字段（如 request_id）
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	cl := loggerFromContext(ctx)
+	return context.WithValue(ctx, ctxKey{}, ctxLogger{
+		logger: cl.logger.With(fields...),
+		fields: append(slices.Clip(cl.fields), fields...),
+	})
+}
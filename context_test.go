@@ -0,0 +1,107 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	defer restore()
+
+	// 没有 logger 时使用全局 logger
+	FromContext(context.Background()).Info("global")
+
+	ctx := With(context.Background(), zap.String("request_id", "r-1"))
+	ctx = With(ctx, zap.String("user", "bob"))
+	FromContext(ctx).Info("scoped")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if n := len(entries[0].Context); n != 0 {
+		t.Errorf("global entry has %d fields, want 0", n)
+	}
+	fields := entries[1].ContextMap()
+	if fields["request_id"] != "r-1" || fields["user"] != "bob" {
+		t.Errorf("scoped entry fields = %v", fields)
+	}
+
+	other := zap.NewNop()
+	if got := FromContext(NewContext(ctx, other)); got != other {
+		t.Error("NewContext should replace the logger in context")
+	}
+}
+
+// recordingSpan 记录 AddEvent 调用的测试 span
+type recordingSpan struct {
+	noop.Span
+	sc     trace.SpanContext
+	events []string
+	attrs  []attribute.KeyValue
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+func (s *recordingSpan) IsRecording() bool              { return true }
+func (s *recordingSpan) AddEvent(name string, opts ...trace.EventOption) {
+	s.events = append(s.events, name)
+	cfg := trace.NewEventConfig(opts...)
+	s.attrs = append(s.attrs, cfg.Attributes()...)
+}
+
+func TestContextTraceFields(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	defer restore()
+	defer spanEvents.Store(false)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	span := &recordingSpan{sc: sc}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+	ctx = With(ctx, zap.String("request_id", "r-1"))
+
+	FromContext(ctx).Error("without events")
+	spanEvents.Store(true)
+	FromContext(ctx).With(zap.Int("attempt", 2)).Error("failed", zap.Error(errors.New("boom")))
+	FromContext(ctx).Info("not an event")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	fields := entries[1].ContextMap()
+	if fields[TraceIDKey] != "4bf92f3577b34da6a3ce929d0e0e4736" || fields[SpanIDKey] != "00f067aa0ba902b7" ||
+		fields[TraceFlagsKey] != "01" || fields["request_id"] != "r-1" {
+		t.Errorf("fields = %v", fields)
+	}
+	if len(entries[1].Context) != 6 {
+		t.Errorf("got %d fields, want 6 (trace fields must not be duplicated)", len(entries[1].Context))
+	}
+
+	if len(span.events) != 1 || span.events[0] != "failed" {
+		t.Fatalf("span events = %v, want [failed]", span.events)
+	}
+	attrs := attribute.NewSet(span.attrs...)
+	for key, want := range map[attribute.Key]string{"log.severity": "ERROR", "error": "boom", "request_id": "r-1"} {
+		if v, ok := attrs.Value(key); !ok || v.Emit() != want {
+			t.Errorf("attribute %s = %v, want %s", key, v.Emit(), want)
+		}
+	}
+	if v, _ := attrs.Value("attempt"); v.AsInt64() != 2 {
+		t.Errorf("attribute attempt = %v, want 2", v.Emit())
+	}
+
+}
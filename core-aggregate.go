@@ -0,0 +1,148 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultAggregateInterval 聚合汇总的默认周期
+const defaultAggregateInterval = time.Minute
+
+// AggregateConfig 高频日志聚合，见 Config.Aggregate
+type AggregateConfig struct {
+	// Messages 需要聚合的日志消息，支持 path.Match 通配 (如 "cache miss*")
+	Messages []string `json:"messages" yaml:"messages"`
+	// Interval 汇总周期 (如 "30s")，默认 1m
+	Interval string `json:"interval" yaml:"interval"`
+}
+
+// parseAggregateConfig 校验消息模式并解析周期
+func parseAggregateConfig(cfg *AggregateConfig) (time.Duration, error) {
+	for _, pattern := range cfg.Messages {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return 0, fmt.Errorf("invalid aggregate message pattern %q", pattern)
+		}
+	}
+	if strings.TrimSpace(cfg.Interval) == "" {
+		return defaultAggregateInterval, nil
+	}
+	interval, err := time.ParseDuration(strings.TrimSpace(cfg.Interval))
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid aggregate interval %q", cfg.Interval)
+	}
+	return interval, nil
+}
+
+// aggregateKey 聚合的维度
+type aggregateKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+}
+
+// aggregator 同一 Logger 下所有派生 Core 共享的计数，周期结束时写出汇总
+type aggregator struct {
+	root     zapcore.Core // 写出汇总的 Core，不带 With 添加的字段
+	patterns []string
+	interval time.Duration
+	onError  func(err error) // 定时写出汇总失败时的回调，报告到诊断输出
+
+	mu     sync.Mutex
+	counts map[aggregateKey]int
+	order  []aggregateKey
+	timer  *time.Timer
+}
+
+// aggregateCore 消息匹配的日志只计数，每个周期按 (级别, logger, 消息) 写出一条带 count 与 interval 字段的汇总；
+// 其余日志直接交给内层 Core
+type aggregateCore struct {
+	zapcore.Core
+	agg *aggregator
+}
+
+func newAggregateCore(core zapcore.Core, patterns []string, interval time.Duration, onError func(err error)) *aggregateCore {
+	return &aggregateCore{Core: core, agg: &aggregator{root: core, patterns: patterns, interval: interval, onError: onError, counts: make(map[aggregateKey]int)}}
+}
+
+func (c *aggregateCore) With(fields []zapcore.Field) zapcore.Core {
+	return &aggregateCore{Core: c.Core.With(fields), agg: c.agg}
+}
+
+func (c *aggregateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// DPanic 及以上级别的日志总是逐条写出
+	if ent.Level >= zapcore.DPanicLevel || !c.agg.match(ent.Message) {
+		return c.Core.Check(ent, ce)
+	}
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *aggregateCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	c.agg.add(aggregateKey{level: ent.Level, logger: ent.LoggerName, message: ent.Message})
+	return nil
+}
+
+// Sync 立即写出当前周期的汇总，返回写出汇总的错误
+func (c *aggregateCore) Sync() error {
+	err := c.agg.flush()
+	return errors.Join(err, c.Core.Sync())
+}
+
+func (a *aggregator) match(msg string) bool {
+	for _, pattern := range a.patterns {
+		if ok, _ := path.Match(pattern, msg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// add 计数，周期内的第一条日志启动定时器
+func (a *aggregator) add(key aggregateKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.counts[key]; !ok {
+		a.order = append(a.order, key)
+	}
+	a.counts[key]++
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.interval, a.expire)
+	}
+}
+
+// expire 由定时器调用，写出汇总失败时报告错误
+func (a *aggregator) expire() {
+	if err := a.flush(); err != nil && a.onError != nil {
+		a.onError(err)
+	}
+}
+
+// flush 按首次出现的顺序写出汇总并开始新的周期，返回各条汇总的写入错误
+func (a *aggregator) flush() error {
+	a.mu.Lock()
+	counts, order := a.counts, a.order
+	a.counts, a.order = make(map[aggregateKey]int), nil
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.mu.Unlock()
+
+	now := time.Now()
+	var errs []error
+	for _, key := range order {
+		ent := zapcore.Entry{Level: key.level, LoggerName: key.logger, Message: key.message, Time: now}
+		fields := []zapcore.Field{zap.Int("count", counts[key]), zap.Duration("interval", a.interval)}
+		errs = append(errs, writeChecked(a.root.Check(ent, nil), fields))
+	}
+	return errors.Join(errs...)
+}
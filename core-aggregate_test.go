@@ -0,0 +1,106 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAggregateCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(newAggregateCore(core, []string{"cache miss*", "retrying"}, time.Hour, nil))
+
+	for range 3 {
+		logger.Info("cache miss", zap.String("key", "a"))
+	}
+	logger.Named("db").With(zap.Int("conn", 1)).Warn("retrying")
+	logger.Info("cache miss for user")
+	logger.Info("served")
+	logger.Debug("cache miss")
+	if logs.Len() != 1 || logs.All()[0].Message != "served" {
+		t.Fatalf("entries = %v", logs.AllUntimed())
+	}
+
+	// Sync 立即写出汇总，按首次出现的顺序
+	_ = logger.Sync()
+	entries := logs.TakeAll()[1:]
+	want := []struct {
+		level   zapcore.Level
+		logger  string
+		message string
+		count   int64
+	}{
+		{zapcore.InfoLevel, "", "cache miss", 3},
+		{zapcore.WarnLevel, "db", "retrying", 1},
+		{zapcore.InfoLevel, "", "cache miss for user", 1},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("summaries = %v", entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		fields := e.ContextMap()
+		if e.Level != w.level || e.LoggerName != w.logger || e.Message != w.message || fields["count"] != w.count || fields["interval"] != time.Hour || fields["conn"] != nil {
+			t.Errorf("summary %d = %+v %v", i, e.Entry, fields)
+		}
+	}
+	_ = logger.Sync()
+	if logs.Len() != 0 {
+		t.Errorf("empty interval wrote %d summaries", logs.Len())
+	}
+}
+
+func TestAggregateCoreInterval(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(newAggregateCore(core, []string{"tick"}, 20*time.Millisecond, nil))
+	logger.Info("tick")
+	logger.Info("tick")
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if logs.Len() != 1 || logs.All()[0].ContextMap()["count"] != int64(2) {
+		t.Errorf("entries = %v", logs.AllUntimed())
+	}
+}
+
+func TestAggregateCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	errs := make(chan error, 1)
+	core := newAggregateCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel), []string{"tick"}, 20*time.Millisecond, func(err error) { errs <- err })
+	// 定时写出的汇总失败时报告到 onError
+	_ = core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "tick"}, nil)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("onError = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("summary write error not reported")
+	}
+	// Sync 写出的汇总失败时返回错误
+	core = newAggregateCore(core.Core, []string{"tick"}, time.Hour, nil)
+	_ = core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "tick"}, nil)
+	if err := core.Sync(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Sync() error = %v", err)
+	}
+}
+
+func TestParseAggregateConfig(t *testing.T) {
+	if d, err := parseAggregateConfig(&AggregateConfig{Messages: []string{"a*"}}); err != nil || d != time.Minute {
+		t.Errorf("default interval = %v, %v", d, err)
+	}
+	if d, err := parseAggregateConfig(&AggregateConfig{Interval: "30s"}); err != nil || d != 30*time.Second {
+		t.Errorf("interval = %v, %v", d, err)
+	}
+	for _, cfg := range []AggregateConfig{{Interval: "0s"}, {Interval: "soon"}, {Messages: []string{"[a"}}} {
+		if _, err := parseAggregateConfig(&cfg); err == nil {
+			t.Errorf("%+v: expected error", cfg)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrChaos chaos 适配器注入的写入失败
+var ErrChaos = errors.New("chaos: injected write failure")
+
+// ErrChaosDropped 日志被 chaos 适配器静默丢弃
+var ErrChaosDropped = errors.New("chaos: injected drop")
+
+// chaosCore 按概率对写入注入延迟、失败与丢弃，用于验证日志管道降级时应用的行为。
+// 延迟发生在调用方的写入中，模拟阻塞的输出；通过的日志交给被包装的适配器
+type chaosCore struct {
+	zapcore.Core
+	opts    *ChaosOptions
+	rnd     *chaosRand
+	dropped *atomic.Uint64
+	onDrop  func(n int, reason error)
+}
+
+func newChaosCore(core zapcore.Core, opts *ChaosOptions, onDrop func(n int, reason error)) *chaosCore {
+	return &chaosCore{Core: core, opts: opts, rnd: newChaosRand(opts.Seed), dropped: new(atomic.Uint64), onDrop: onDrop}
+}
+
+// Dropped 返回被静默丢弃的日志条数
+func (c *chaosCore) Dropped() uint64 { return c.dropped.Load() }
+
+func (c *chaosCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *chaosCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *chaosCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.rnd.hit(c.opts.DelayRate) {
+		time.Sleep(c.rnd.between(c.opts.Delay, c.opts.DelayMax))
+	}
+	if c.rnd.hit(c.opts.DropRate) {
+		c.dropped.Add(1)
+		if c.onDrop != nil {
+			c.onDrop(1, ErrChaosDropped)
+		}
+		return nil
+	}
+	if c.rnd.hit(c.opts.ErrorRate) {
+		return ErrChaos
+	}
+	// 被包装的适配器可能有自己的筛选与路由，重新 Check
+	return writeChecked(c.Core.Check(ent, nil), fields)
+}
+
+// chaosRand 可指定种子的并发安全随机数，相同种子得到相同的故障序列
+type chaosRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newChaosRand(seed uint64) *chaosRand {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &chaosRand{rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (r *chaosRand) hit(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Float64() < p
+}
+
+func (r *chaosRand) between(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lo + time.Duration(r.rnd.Int64N(int64(hi-lo)+1))
+}
+
+// chaosCloser chaos 适配器的资源：关闭被包装的适配器，合并其丢弃计数与运行状态
+type chaosCloser struct {
+	core   *chaosCore
+	target *adaptor // 为 nil 表示没有 target
+}
+
+func (c *chaosCloser) Close() error {
+	_, err := c.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 按 ctx 关闭被包装的适配器
+func (c *chaosCloser) CloseContext(ctx context.Context) (DrainResult, error) {
+	if c.target == nil || c.target.closer == nil {
+		return DrainResult{}, nil
+	}
+	return closeContext(ctx, c.target.closer)
+}
+
+func (c *chaosCloser) Dropped() uint64 {
+	n := c.core.Dropped()
+	if c.target != nil {
+		for _, counter := range c.target.counters {
+			n += counter.Dropped()
+		}
+	}
+	return n
+}
+
+func (c *chaosCloser) stats(st *AdaptorStats) {
+	if c.target == nil {
+		return
+	}
+	if r, ok := c.target.closer.(statsReporter); ok {
+		r.stats(st)
+	}
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestChaosCore(t *testing.T) {
+	run := func(opts *ChaosOptions) (written, dropped int, errs int) {
+		core, logs := observer.New(zap.DebugLevel)
+		chaos := newChaosCore(core, opts, func(n int, reason error) {
+			if !errors.Is(reason, ErrChaosDropped) {
+				t.Errorf("drop reason = %v", reason)
+			}
+			dropped += n
+		})
+		// 直接调用 Core.Write 以观察注入的错误
+		for range 1000 {
+			if err := chaos.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "msg"}, nil); err != nil {
+				errs++
+			}
+		}
+		if int(chaos.Dropped()) != dropped {
+			t.Errorf("Dropped() = %d, want %d", chaos.Dropped(), dropped)
+		}
+		return logs.Len(), dropped, errs
+	}
+
+	written, dropped, errs := run(&ChaosOptions{ErrorRate: 0.1, DropRate: 0.2, Seed: 42})
+	if written+dropped+errs != 1000 || dropped < 150 || dropped > 250 || errs < 50 || errs > 120 {
+		t.Errorf("written = %d, dropped = %d, errors = %d", written, dropped, errs)
+	}
+	// 相同种子得到相同的故障序列
+	w2, d2, e2 := run(&ChaosOptions{ErrorRate: 0.1, DropRate: 0.2, Seed: 42})
+	if w2 != written || d2 != dropped || e2 != errs {
+		t.Errorf("seeded run differs: %d/%d/%d vs %d/%d/%d", w2, d2, e2, written, dropped, errs)
+	}
+	if written, _, _ := run(&ChaosOptions{}); written != 1000 {
+		t.Errorf("no faults: written = %d", written)
+	}
+}
+
+func TestChaosCoreDelay(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(newChaosCore(core, &ChaosOptions{Delay: 10 * time.Millisecond, DelayMax: 20 * time.Millisecond, DelayRate: 1}, nil))
+	start := time.Now()
+	for range 3 {
+		logger.Info("slow")
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("3 delayed writes took %v", elapsed)
+	}
+	if logs.Len() != 3 {
+		t.Errorf("written = %d", logs.Len())
+	}
+	if d := newChaosRand(1).between(time.Second, time.Second); d != time.Second {
+		t.Errorf("fixed delay = %v", d)
+	}
+}
+
+func TestChaosCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	core := newChaosCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel), &ChaosOptions{}, nil)
+	// 被包装适配器的写入错误返回给调用方
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "lost"}, nil); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Write() error = %v", err)
+	}
+}
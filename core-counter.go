@@ -0,0 +1,177 @@
+package log
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// counterKey 计数的维度
+type counterKey struct {
+	level  zapcore.Level
+	logger string
+}
+
+// counterCore 不保存日志内容，只按级别与 logger 名称计数，由 counterPusher 定期推送
+type counterCore struct {
+	zapcore.LevelEnabler
+	pusher *counterPusher
+}
+
+func (c *counterCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c *counterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *counterCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	c.pusher.inc(counterKey{level: ent.Level, logger: ent.LoggerName})
+	return nil
+}
+
+func (c *counterCore) Sync() error { return nil }
+
+// counterPusher 以 Prometheus 文本格式定期将计数 PUT 到 Pushgateway 分组，计数从进程启动起累计
+type counterPusher struct {
+	opts   *PushOptions
+	client *http.Client
+	sent   writerStats
+
+	mu     sync.Mutex
+	counts map[counterKey]uint64
+
+	pushMu sync.Mutex // 保证推送按顺序进行
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newCounterPusher(opts *PushOptions) (*counterPusher, error) {
+	tlsConfig, err := buildTLSConfig(&opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	p := &counterPusher{
+		opts:   opts,
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}, Timeout: cmp.Or(opts.Timeout, 5*time.Second)},
+		sent:   writerStats{onError: opts.OnError},
+		counts: make(map[counterKey]uint64),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run(cmp.Or(opts.Interval, 15*time.Second))
+	return p, nil
+}
+
+func (p *counterPusher) inc(key counterKey) {
+	p.mu.Lock()
+	p.counts[key]++
+	p.mu.Unlock()
+}
+
+func (p *counterPusher) run(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = p.push(context.Background())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// push 推送当前计数，还没有日志时不推送
+func (p *counterPusher) push(ctx context.Context) error {
+	p.pushMu.Lock()
+	defer p.pushMu.Unlock()
+	body := p.render()
+	if body == nil {
+		return nil
+	}
+	err := p.send(ctx, body)
+	if err != nil {
+		p.sent.failure(err)
+		return err
+	}
+	p.sent.success(len(body))
+	return nil
+}
+
+// render 按 Prometheus 文本格式输出计数
+func (p *counterPusher) render() []byte {
+	p.mu.Lock()
+	counts := maps.Clone(p.counts)
+	p.mu.Unlock()
+	if len(counts) == 0 {
+		return nil
+	}
+	keys := slices.SortedFunc(maps.Keys(counts), func(a, b counterKey) int {
+		return cmp.Or(cmp.Compare(a.level, b.level), strings.Compare(a.logger, b.logger))
+	})
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# HELP %s Log entries by level and logger.\n# TYPE %s counter\n", p.opts.Metric, p.opts.Metric)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s{level=\"%s\",logger=\"%s\"} %d\n", p.opts.Metric, key.level, labelEscaper.Replace(key.logger), counts[key])
+	}
+	return buf.Bytes()
+}
+
+func (p *counterPusher) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	if p.opts.Username != "" || p.opts.Password != "" {
+		req.SetBasicAuth(p.opts.Username, p.opts.Password)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push counters failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("push counters failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Probe 立即推送一次当前计数
+func (p *counterPusher) Probe(ctx context.Context) error {
+	if err := p.push(ctx); err != nil {
+		return fmt.Errorf("probe failed: %w", err)
+	}
+	return nil
+}
+
+// Close 停止定时推送并推送最终的计数
+func (p *counterPusher) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout)
+		defer cancel()
+		err = p.push(ctx)
+	})
+	return err
+}
+
+func (p *counterPusher) stats(st *AdaptorStats) { p.sent.stats(st) }
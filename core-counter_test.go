@@ -0,0 +1,78 @@
+package log
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCounterCore(t *testing.T) {
+	bodies := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.URL.Path != "/metrics/job/app/instance/web-1" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		bodies <- string(body)
+	}))
+	defer srv.Close()
+
+	pusher, err := newCounterPusher(&PushOptions{URL: srv.URL + "/metrics/job/app/instance/web-1", Metric: "log_entries_total", Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(&counterCore{LevelEnabler: zapcore.InfoLevel, pusher: pusher})
+	logger.Debug("ignored")
+	logger.Info("a", zap.String("secret", "not stored"))
+	logger.Named("db").Error("b")
+	logger.Named("db").Error("c")
+	if err := pusher.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP log_entries_total Log entries by level and logger.\n# TYPE log_entries_total counter\n" +
+		"log_entries_total{level=\"info\",logger=\"\"} 1\n" +
+		"log_entries_total{level=\"error\",logger=\"db\"} 2\n"
+	if got := <-bodies; got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+
+	// 关闭时推送最终的累计计数
+	logger.Warn("d")
+	if err := pusher.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bodies; !strings.Contains(got, "log_entries_total{level=\"warn\",logger=\"\"} 1\n") || !strings.Contains(got, "logger=\"db\"} 2\n") {
+		t.Errorf("final body =\n%s", got)
+	}
+	var st AdaptorStats
+	pusher.stats(&st)
+	if st.Written == 0 || st.LastError != "" {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestCounterPusherFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	var reported error
+	pusher, err := newCounterPusher(&PushOptions{URL: srv.URL, Metric: "m", Interval: time.Hour, OnError: func(err error) { reported = err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有计数时不推送
+	if err := pusher.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	pusher.inc(counterKey{level: zapcore.ErrorLevel})
+	if err := pusher.Close(); err == nil || !strings.Contains(err.Error(), "status 400") || reported == nil {
+		t.Errorf("Close() = %v, reported = %v", err, reported)
+	}
+}
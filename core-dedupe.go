@@ -0,0 +1,198 @@
+package log
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// dedupeState 单条重复日志的统计状态
+type dedupeState struct {
+	start  time.Time
+	entry  zapcore.Entry
+	core   zapcore.Core
+	fields []zapcore.Field
+	count  int
+}
+
+// dedupeShared 同一适配器下所有派生 Core 共享的状态
+type dedupeShared struct {
+	states    map[uint64]*dedupeState
+	lastSweep time.Time
+	window    time.Duration
+	timer     *time.Timer // 有待输出的汇总时，在最早的窗口结束时触发
+	mu        sync.Mutex
+}
+
+// dedupeCore 在时间窗口内合并相同的日志（级别 + 消息 + 字段），
+// 窗口结束后输出一条带 repeated=N 字段的汇总日志
+type dedupeCore struct {
+	zapcore.Core
+	shared  *dedupeShared
+	context uint64
+}
+
+func newDedupeCore(core zapcore.Core, window time.Duration) *dedupeCore {
+	return &dedupeCore{
+		Core: core,
+		shared: &dedupeShared{
+			states:    make(map[uint64]*dedupeState),
+			window:    window,
+			lastSweep: time.Now(),
+		},
+	}
+}
+
+func (c *dedupeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupeCore{
+		Core:    c.Core.With(fields),
+		shared:  c.shared,
+		context: hashFields(c.context, fields),
+	}
+}
+
+func (c *dedupeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := hashFields(c.context, fields)
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d|%d|%s|%s", key, ent.Level, ent.LoggerName, ent.Message)
+	key = h.Sum64()
+
+	now := time.Now()
+	s := c.shared
+	s.mu.Lock()
+	pending := s.sweep(now)
+	state, ok := s.states[key]
+	suppressed := ok && now.Sub(state.start) < s.window
+	if suppressed {
+		state.count++
+		state.entry = ent
+		// 汇总可能由定时器在其他协程输出，字段在调用方协程中复制
+		state.fields = snapshotFields(fields)
+		if s.timer == nil {
+			s.timer = time.AfterFunc(state.start.Add(s.window).Sub(now), s.expire)
+		}
+	} else {
+		// 已过期但 sweep 尚未清理的状态，先输出其汇总
+		if ok && state.count > 0 {
+			pending = append(pending, state)
+		}
+		s.states[key] = &dedupeState{start: now, core: c.Core}
+	}
+	s.mu.Unlock()
+
+	err := flushDedupe(pending)
+	if suppressed {
+		return err
+	}
+	if werr := c.Core.Write(ent, fields); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// Sync 输出所有未完成的汇总日志后同步底层 Core
+func (c *dedupeCore) Sync() error {
+	s := c.shared
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	pending := make([]*dedupeState, 0, len(s.states))
+	for key, state := range s.states {
+		if state.count > 0 {
+			pending = append(pending, state)
+		}
+		delete(s.states, key)
+	}
+	s.mu.Unlock()
+
+	if err := flushDedupe(pending); err != nil {
+		return err
+	}
+	return c.Core.Sync()
+}
+
+// sweep 移除过期的状态并返回需要输出汇总的条目，调用方需持有锁
+func (s *dedupeShared) sweep(now time.Time) []*dedupeState {
+	if now.Sub(s.lastSweep) < s.window {
+		return nil
+	}
+	s.lastSweep = now
+	var pending []*dedupeState
+	for key, state := range s.states {
+		if now.Sub(state.start) < s.window {
+			continue
+		}
+		if state.count > 0 {
+			pending = append(pending, state)
+		}
+		delete(s.states, key)
+	}
+	return pending
+}
+
+// expire 由定时器调用，输出窗口已结束的汇总，使一阵重复日志之后没有新的写入时汇总也能及时输出；
+// 仍有待输出的汇总时按最早的窗口结束时间重新设置定时器。写入错误由被包装的适配器记录到运行状态
+func (s *dedupeShared) expire() {
+	now := time.Now()
+	s.mu.Lock()
+	s.timer = nil
+	var pending []*dedupeState
+	var next time.Time
+	for key, state := range s.states {
+		end := state.start.Add(s.window)
+		if now.Before(end) {
+			if state.count > 0 && (next.IsZero() || end.Before(next)) {
+				next = end
+			}
+			continue
+		}
+		if state.count > 0 {
+			pending = append(pending, state)
+		}
+		delete(s.states, key)
+	}
+	if !next.IsZero() {
+		s.timer = time.AfterFunc(next.Sub(now), s.expire)
+	}
+	s.mu.Unlock()
+	_ = flushDedupe(pending)
+}
+
+// flushDedupe 输出带 repeated 字段的汇总日志
+func flushDedupe(pending []*dedupeState) error {
+	var firstErr error
+	for _, state := range pending {
+		fields := append(state.fields[:len(state.fields):len(state.fields)], zap.Int("repeated", state.count))
+		if err := state.core.Write(state.entry, fields); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// hashFields 计算字段集合的哈希
+func hashFields(seed uint64, fields []zapcore.Field) uint64 {
+	if len(fields) == 0 {
+		return seed
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d|%v", seed, enc.Fields)
+	return h.Sum64()
+}
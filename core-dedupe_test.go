@@ -0,0 +1,66 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDedupeExpiredBeforeSweep(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupeCore(inner, time.Minute)
+	logger := zap.New(core)
+	logger.Info("A")
+	logger.Info("A")
+
+	// 状态在 0.1W 开始，sweep 在 1.05W 运行（当时未过期），现在是 1.2W：已过期但 sweep 尚未清理
+	now := time.Now()
+	s := core.shared
+	s.mu.Lock()
+	for _, state := range s.states {
+		state.start = now.Add(-66 * time.Second)
+	}
+	s.lastSweep = now.Add(-9 * time.Second)
+	s.mu.Unlock()
+	logger.Info("A")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("entries = %v", entries)
+	}
+	if entries[1].ContextMap()["repeated"] != int64(1) || entries[2].ContextMap()["repeated"] != nil {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+// TestDedupeSummaryAfterSilence 重复日志之后没有新的写入，窗口结束时仍输出汇总
+func TestDedupeSummaryAfterSilence(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	core := newDedupeCore(inner, 50*time.Millisecond)
+	logger := zap.New(core)
+	tags := map[string]any{"env": "prod"}
+	for range 3 {
+		logger.Info("A", zap.Any("tags", tags))
+	}
+	tags["env"] = "dev"
+
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.Len() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("summary not written after the window: %v", logs.AllUntimed())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	summary := logs.AllUntimed()[1].ContextMap()
+	if summary["repeated"] != int64(2) || summary["tags"].(map[string]any)["env"] != "prod" {
+		t.Errorf("summary = %v", summary)
+	}
+	core.shared.mu.Lock()
+	defer core.shared.mu.Unlock()
+	if len(core.shared.states) != 0 || core.shared.timer != nil {
+		t.Errorf("states = %d, timer = %v", len(core.shared.states), core.shared.timer)
+	}
+}
@@ -0,0 +1,163 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxErrorChain 展开的错误链最大长度，防止循环引用的错误无限展开
+const maxErrorChain = 32
+
+// errorChainCore 将 error 字段展开为结构化的子字段 (见 Config.ExpandErrors)：
+//   - <key>: 错误消息，与不展开时相同
+//   - <key>_chain: 经 Unwrap 得到的错误链，每项为 {msg, type}，只有一层时省略
+//   - <key>_root、<key>_root_type: 根因的消息与类型，可据此聚合
+//   - <key>_stack: 错误链中第一个提供 StackTrace() 方法 (pkg/errors 风格) 的错误的调用栈，
+//     没有时实现了 fmt.Formatter 的错误以 <key>_verbose 保留 %+v 的输出
+type errorChainCore struct {
+	zapcore.Core
+}
+
+func newErrorChainCore(core zapcore.Core) *errorChainCore {
+	return &errorChainCore{Core: core}
+}
+
+func (c *errorChainCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorChainCore{Core: c.Core.With(expandErrors(fields))}
+}
+
+func (c *errorChainCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *errorChainCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, expandErrors(fields))
+}
+
+// expandErrors 返回展开 error 字段后的字段，不修改传入的切片
+func expandErrors(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		err, ok := f.Interface.(error)
+		if f.Type != zapcore.ErrorType || !ok || err == nil {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields)+4)
+			copy(out, fields[:i])
+		}
+		out = append(out, errorFields(f.Key, err)...)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// errorFields 展开单个错误
+func errorFields(key string, err error) []zapcore.Field {
+	chain, root := errorChain(err)
+	fields := make([]zapcore.Field, 0, 5)
+	fields = append(fields, zap.String(key, safeErrorString(err)))
+	if len(chain) > 1 {
+		fields = append(fields, zap.Array(key+"_chain", chain))
+	}
+	fields = append(fields, zap.String(key+"_root", safeErrorString(root)), zap.String(key+"_root_type", errorType(root)))
+	for _, e := range chain {
+		if stack, ok := errorStack(e); ok {
+			return append(fields, zap.String(key+"_stack", stack))
+		}
+	}
+	// 没有调用栈时保留自定义格式化的详细信息
+	if _, ok := err.(fmt.Formatter); ok {
+		if verbose := fmt.Sprintf("%+v", err); verbose != fields[0].String {
+			fields = append(fields, zap.String(key+"_verbose", verbose))
+		}
+	}
+	return fields
+}
+
+// errorChainArray 按 Unwrap 顺序排列的错误，第一个为原错误
+type errorChainArray []error
+
+func (a errorChainArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range a {
+		_ = enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("msg", safeErrorString(err))
+			enc.AddString("type", errorType(err))
+			return nil
+		}))
+	}
+	return nil
+}
+
+// errorChain 按深度优先顺序展开错误链，errors.Join 等包装多个错误时依次展开各分支，
+// 根因为第一个分支最深处的错误
+func errorChain(err error) (chain errorChainArray, root error) {
+	var walk func(err error)
+	walk = func(err error) {
+		for err != nil && len(chain) < maxErrorChain {
+			chain = append(chain, err)
+			var next error
+			switch u := err.(type) {
+			case interface{ Unwrap() error }:
+				next = u.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, e := range u.Unwrap() {
+					walk(e)
+				}
+				return
+			}
+			if next == nil && root == nil {
+				root = err
+			}
+			err = next
+		}
+	}
+	walk(err)
+	if root == nil {
+		// 超出最大长度时以最后一个为根因
+		root = chain[len(chain)-1]
+	}
+	return chain, root
+}
+
+// errorStack 返回 pkg/errors 风格错误的调用栈：StackTrace() 方法的返回值以 %+v 格式化
+func errorStack(err error) (string, bool) {
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", false
+	}
+	stack := fmt.Sprintf("%+v", m.Call(nil)[0].Interface())
+	if len(stack) > 0 && stack[0] == '\n' {
+		stack = stack[1:]
+	}
+	return stack, stack != ""
+}
+
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// safeErrorString 与 zap 相同，Error() 发生 panic 时返回 panic 信息
+func safeErrorString(err error) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if v := reflect.ValueOf(err); v.Kind() == reflect.Pointer && v.IsNil() {
+				s = "<nil>"
+				return
+			}
+			s = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	return err.Error()
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// stackTrace pkg/errors 风格的调用栈
+type stackTrace []string
+
+func (s stackTrace) Format(f fmt.State, verb rune) {
+	for _, frame := range s {
+		fmt.Fprintf(f, "\n%s", frame)
+	}
+}
+
+type stackError struct {
+	msg   string
+	cause error
+}
+
+func (e *stackError) Error() string { return e.msg + ": " + e.cause.Error() }
+func (e *stackError) Unwrap() error { return e.cause }
+func (e *stackError) StackTrace() stackTrace {
+	return stackTrace{"main.load\n\tmain.go:12", "main.main\n\tmain.go:5"}
+}
+func (e *stackError) Format(f fmt.State, _ rune) { fmt.Fprint(f, e.Error()) }
+
+func TestErrorChainCore(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(newErrorChainCore(core))
+
+	root := &fs.PathError{Op: "open", Path: "app.yaml", Err: fs.ErrNotExist}
+	wrapped := &stackError{msg: "load config", cause: fmt.Errorf("read: %w", root)}
+	logger.Error("failed", zap.Error(wrapped), zap.NamedError("flat", errors.New("plain")), zap.Int("attempt", 1))
+
+	fields := logs.AllUntimed()[0].ContextMap()
+	if fields["error"] != wrapped.Error() || fields["error_root"] != "file does not exist" || fields["error_root_type"] != "*errors.errorString" {
+		t.Errorf("fields = %v", fields)
+	}
+	chain, _ := fields["error_chain"].([]any)
+	if len(chain) != 4 {
+		t.Fatalf("error_chain = %v", fields["error_chain"])
+	}
+	if item := chain[2].(map[string]any); item["type"] != "*fs.PathError" || item["msg"] != root.Error() {
+		t.Errorf("chain[2] = %v", item)
+	}
+	if fields["error_stack"] != "main.load\n\tmain.go:12\nmain.main\n\tmain.go:5" {
+		t.Errorf("error_stack = %q", fields["error_stack"])
+	}
+	// 只有一层的错误不输出 _chain
+	if fields["flat"] != "plain" || fields["flat_root"] != "plain" || fields["flat_chain"] != nil || fields["attempt"] != int64(1) {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestErrorChainJoined(t *testing.T) {
+	first := errors.New("disk full")
+	chain, root := errorChain(fmt.Errorf("flush: %w", errors.Join(first, errors.New("timeout"))))
+	if len(chain) != 4 || root != first {
+		t.Errorf("chain = %v, root = %v", chain, root)
+	}
+}
@@ -0,0 +1,237 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// failoverCore 写入主适配器，主适配器不健康时改为写入备用适配器，恢复后切回。
+// 同步写入失败（文件、syslog 等）立即切换，失败的这条日志改写到备用适配器；
+// 异步发送的适配器（HTTP 等）由 failoverState 定期检查运行状态发现失败
+type failoverCore struct {
+	primary   zapcore.Core
+	secondary zapcore.Core
+	state     *failoverState
+}
+
+func (c *failoverCore) Enabled(lvl zapcore.Level) bool {
+	return c.primary.Enabled(lvl) || c.secondary.Enabled(lvl)
+}
+
+func (c *failoverCore) With(fields []zapcore.Field) zapcore.Core {
+	return &failoverCore{primary: c.primary.With(fields), secondary: c.secondary.With(fields), state: c.state}
+}
+
+func (c *failoverCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *failoverCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.state.failed.Load() {
+		// 被包装的适配器可能有自己的筛选与路由，重新 Check；写入错误经 ErrorOutput 取回
+		inner := c.primary.Check(ent, nil)
+		if inner == nil {
+			return nil
+		}
+		var out errorCapture
+		inner.ErrorOutput = &out
+		inner.Write(fields...)
+		if out.err == nil {
+			return nil
+		}
+		c.state.fail(out.err)
+	}
+	inner := c.secondary.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	var out errorCapture
+	inner.ErrorOutput = &out
+	inner.Write(fields...)
+	return out.err
+}
+
+func (c *failoverCore) Sync() error {
+	return errors.Join(c.primary.Sync(), c.secondary.Sync())
+}
+
+// failoverState 主适配器的健康状态，由 failoverCore 的所有副本共享。
+// 健康时每个间隔检查主适配器是否出现新的发送失败；不健康时每个间隔探测一次（不支持探测的适配器直接重试），
+// 成功后切回主适配器
+type failoverState struct {
+	primary   *adaptor
+	secondary *adaptor
+	failed    atomic.Bool
+	failovers atomic.Uint64
+	onSwitch  func(err error) // 切换时的回调，报告到诊断输出
+
+	mu     sync.Mutex
+	seenAt time.Time // 已处理过的最近一次失败时间
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func newFailoverState(primary, secondary *adaptor, interval time.Duration, onSwitch func(err error)) *failoverState {
+	s := &failoverState{
+		primary:   primary,
+		secondary: secondary,
+		onSwitch:  onSwitch,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	s.seenAt = s.primaryStats().LastErrorAt
+	go s.run(interval)
+	return s
+}
+
+// fail 切换到备用适配器，已切换时忽略
+func (s *failoverState) fail(err error) {
+	if !s.failed.CompareAndSwap(false, true) {
+		return
+	}
+	s.failovers.Add(1)
+	s.mu.Lock()
+	s.seenAt = time.Now()
+	s.mu.Unlock()
+	if s.onSwitch != nil {
+		s.onSwitch(fmt.Errorf("primary unhealthy, switched to secondary: %w", err))
+	}
+}
+
+// recover 切回主适配器，此前记录的失败不再计入
+func (s *failoverState) recover() {
+	at := s.primaryStats().LastErrorAt
+	s.mu.Lock()
+	if at.After(s.seenAt) {
+		s.seenAt = at
+	}
+	s.mu.Unlock()
+	if s.failed.CompareAndSwap(true, false) && s.onSwitch != nil {
+		s.onSwitch(errors.New("primary recovered, switched back"))
+	}
+}
+
+func (s *failoverState) primaryStats() AdaptorStats {
+	var st AdaptorStats
+	if r, ok := s.primary.closer.(statsReporter); ok {
+		r.stats(&st)
+	}
+	return st
+}
+
+func (s *failoverState) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.check(interval)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// check 检查一次主适配器：健康时发现新的发送失败则切换，不健康时探测恢复
+func (s *failoverState) check(timeout time.Duration) {
+	if !s.failed.Load() {
+		st := s.primaryStats()
+		s.mu.Lock()
+		failed := st.LastErrorAt.After(s.seenAt) && st.LastErrorAt.After(st.LastSuccess)
+		s.mu.Unlock()
+		if failed {
+			s.fail(errors.New(st.LastError))
+		}
+		return
+	}
+	if p, ok := s.primary.closer.(prober); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if p.Probe(ctx) != nil {
+			return
+		}
+	}
+	s.recover()
+}
+
+// failoverCloser 故障转移适配器的资源：停止健康检查，关闭两个适配器，合并其丢弃计数与运行状态
+type failoverCloser struct {
+	state *failoverState
+}
+
+func (c *failoverCloser) Close() error {
+	_, err := c.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 按 ctx 关闭两个适配器，合并其排空结果
+func (c *failoverCloser) CloseContext(ctx context.Context) (DrainResult, error) {
+	var total DrainResult
+	var err error
+	c.state.once.Do(func() {
+		close(c.state.stop)
+		<-c.state.done
+		for _, a := range []*adaptor{c.state.primary, c.state.secondary} {
+			if a.closer == nil {
+				continue
+			}
+			result, closeErr := closeContext(ctx, a.closer)
+			total.Flushed += result.Flushed
+			total.Abandoned += result.Abandoned
+			err = errors.Join(err, closeErr)
+		}
+	})
+	return total, err
+}
+
+func (c *failoverCloser) Dropped() uint64 {
+	var n uint64
+	for _, a := range []*adaptor{c.state.primary, c.state.secondary} {
+		for _, counter := range a.counters {
+			n += counter.Dropped()
+		}
+	}
+	return n
+}
+
+func (c *failoverCloser) stats(st *AdaptorStats) {
+	for _, a := range []*adaptor{c.state.primary, c.state.secondary} {
+		if r, ok := a.closer.(statsReporter); ok {
+			r.stats(st)
+		}
+	}
+	st.Active = "primary"
+	if c.state.failed.Load() {
+		st.Active = "secondary"
+	}
+	st.Failovers = c.state.failovers.Load()
+}
+
+// Probe 探测两个适配器，主适配器探测失败时直接切换到备用适配器
+func (c *failoverCloser) Probe(ctx context.Context) error {
+	var errs []error
+	if p, ok := c.state.primary.closer.(prober); ok {
+		if err := p.Probe(ctx); err != nil {
+			c.state.fail(err)
+			errs = append(errs, fmt.Errorf("primary: %w", err))
+		}
+	}
+	if p, ok := c.state.secondary.closer.(prober); ok {
+		if err := p.Probe(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("secondary: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,151 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeSink 可控制写入、探测结果与运行状态的适配器资源
+type fakeSink struct {
+	mu       sync.Mutex
+	lines    []string
+	writeErr error
+	probeErr error
+	st       AdaptorStats
+	closed   bool
+}
+
+func (f *fakeSink) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.writeErr != nil {
+		return 0, f.writeErr
+	}
+	f.lines = append(f.lines, strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (f *fakeSink) Sync() error { return nil }
+
+func (f *fakeSink) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeSink) Probe(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.probeErr
+}
+
+func (f *fakeSink) stats(st *AdaptorStats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st.LastError, st.LastErrorAt, st.LastSuccess = f.st.LastError, f.st.LastErrorAt, f.st.LastSuccess
+}
+
+func (f *fakeSink) set(fn func(f *fakeSink)) {
+	f.mu.Lock()
+	fn(f)
+	f.mu.Unlock()
+}
+
+func newTestFailover(t *testing.T) (*zap.Logger, *fakeSink, *observer.ObservedLogs, *failoverCloser, *[]string) {
+	t.Helper()
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey = ""
+	primary := &fakeSink{}
+	secondaryCore, secondary := observer.New(zapcore.DebugLevel)
+	var mu sync.Mutex
+	var switches []string
+	state := newFailoverState(
+		&adaptor{core: newSinkCore(zapcore.NewJSONEncoder(cfg), primary, zapcore.InfoLevel), closer: primary},
+		&adaptor{core: secondaryCore},
+		time.Hour,
+		func(err error) { mu.Lock(); switches = append(switches, err.Error()); mu.Unlock() },
+	)
+	closer := &failoverCloser{state: state}
+	t.Cleanup(func() { _ = closer.Close() })
+	return zap.New(&failoverCore{primary: state.primary.core, secondary: secondaryCore, state: state}), primary, secondary, closer, &switches
+}
+
+func TestFailoverCoreWriteError(t *testing.T) {
+	logger, primary, secondary, closer, switches := newTestFailover(t)
+	logger.Info("a")
+	logger.Debug("filtered by primary")
+	primary.set(func(f *fakeSink) { f.writeErr = errors.New("disk full") })
+	logger.With(zap.String("k", "v")).Info("b")
+	logger.Info("c")
+
+	if len(primary.lines) != 1 || primary.lines[0] != `{"level":"info","msg":"a"}` {
+		t.Errorf("primary = %q", primary.lines)
+	}
+	// 写入失败的日志改写到备用适配器，之后的日志直接写入备用适配器
+	if got := secondary.AllUntimed(); len(got) != 2 || got[0].Message != "b" || got[0].ContextMap()["k"] != "v" || got[1].Message != "c" {
+		t.Errorf("secondary = %v", got)
+	}
+	var st AdaptorStats
+	closer.stats(&st)
+	if st.Active != "secondary" || st.Failovers != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+	if len(*switches) != 1 || !strings.Contains((*switches)[0], "switched to secondary: disk full") {
+		t.Errorf("switches = %q", *switches)
+	}
+
+	// 探测失败时保持备用适配器，探测成功后切回
+	primary.set(func(f *fakeSink) { f.writeErr, f.probeErr = nil, errors.New("still down") })
+	closer.state.check(time.Second)
+	logger.Info("d")
+	primary.set(func(f *fakeSink) { f.probeErr = nil })
+	closer.state.check(time.Second)
+	logger.Info("e")
+	if secondary.Len() != 3 || len(primary.lines) != 2 || primary.lines[1] != `{"level":"info","msg":"e"}` {
+		t.Errorf("primary = %q, secondary = %d", primary.lines, secondary.Len())
+	}
+	st = AdaptorStats{}
+	closer.stats(&st)
+	if st.Active != "primary" || len(*switches) != 2 || (*switches)[1] != "primary recovered, switched back" {
+		t.Errorf("stats = %+v, switches = %q", st, *switches)
+	}
+	_ = closer.Close()
+	if !primary.closed {
+		t.Error("primary not closed")
+	}
+}
+
+func TestFailoverCoreAsyncFailure(t *testing.T) {
+	logger, primary, secondary, closer, _ := newTestFailover(t)
+	start := time.Now()
+	// 创建前的失败不计入
+	closer.state.check(time.Second)
+	if closer.state.failed.Load() {
+		t.Fatal("failed without new errors")
+	}
+	// 发送成功之后的失败视为不健康
+	primary.set(func(f *fakeSink) {
+		f.st.LastSuccess = start.Add(time.Millisecond)
+		f.st.LastError, f.st.LastErrorAt = "status 503", start.Add(2*time.Millisecond)
+	})
+	closer.state.check(time.Second)
+	logger.Info("to secondary")
+	if !closer.state.failed.Load() || secondary.Len() != 1 {
+		t.Fatalf("failed = %v, secondary = %d", closer.state.failed.Load(), secondary.Len())
+	}
+	// 切回后旧的失败不再触发切换
+	closer.state.check(time.Second)
+	closer.state.check(time.Second)
+	if closer.state.failed.Load() {
+		t.Error("old error triggered failover again")
+	}
+}
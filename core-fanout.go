@@ -0,0 +1,111 @@
+package log
+
+import (
+	"errors"
+	"slices"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sink 单个输出及其级别
+type sink struct {
+	zapcore.LevelEnabler
+	out zapcore.WriteSyncer
+}
+
+// fanoutCore 每条日志只编码一次，再写入所有启用了该级别的输出。
+// 只有一个输出时与 zapcore.NewCore 相同；多个适配器使用同一编码器且没有各自的包装时，
+// 合并为一个 fanoutCore，避免 Tee 中每个适配器重复编码
+type fanoutCore struct {
+	enc   zapcore.Encoder
+	sinks []sink
+}
+
+func newSinkCore(enc zapcore.Encoder, out zapcore.WriteSyncer, lvl zapcore.LevelEnabler) *fanoutCore {
+	return &fanoutCore{enc: enc, sinks: []sink{{LevelEnabler: lvl, out: out}}}
+}
+
+func (c *fanoutCore) Enabled(lvl zapcore.Level) bool {
+	return slices.ContainsFunc(c.sinks, func(s sink) bool { return s.Enabled(lvl) })
+}
+
+func (c *fanoutCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &fanoutCore{enc: c.enc.Clone(), sinks: c.sinks}
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *fanoutCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fanoutCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	var errs []error
+	for _, s := range c.sinks {
+		if !s.Enabled(ent.Level) {
+			continue
+		}
+		if _, err := s.out.Write(buf.Bytes()); err != nil {
+			errs = append(errs, err)
+		}
+		// 与 zapcore.NewCore 相同，error 以上的级别可能导致退出，立即同步
+		if ent.Level > zapcore.ErrorLevel {
+			errs = append(errs, s.out.Sync())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *fanoutCore) Sync() error {
+	var errs []error
+	for _, s := range c.sinks {
+		errs = append(errs, s.out.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// mergeSinks 将直接使用编码器 enc（未经其他包装）的 Core 合并为一个 fanoutCore，其余 Core 原样返回。
+// split-errors 的文件 Core (levelTee) 在各部分都可合并时一并合并
+func mergeSinks(cores []zapcore.Core, enc zapcore.Encoder) []zapcore.Core {
+	merged := &fanoutCore{enc: enc}
+	var rest []zapcore.Core
+	for _, core := range cores {
+		if sinks, ok := sinksOf(core, enc); ok {
+			merged.sinks = append(merged.sinks, sinks...)
+		} else {
+			rest = append(rest, core)
+		}
+	}
+	if len(merged.sinks) < 2 {
+		return cores
+	}
+	return append([]zapcore.Core{merged}, rest...)
+}
+
+func sinksOf(core zapcore.Core, enc zapcore.Encoder) ([]sink, bool) {
+	switch c := core.(type) {
+	case *fanoutCore:
+		return c.sinks, c.enc == enc
+	case levelTee:
+		var sinks []sink
+		for _, part := range c {
+			s, ok := sinksOf(part, enc)
+			if !ok {
+				return nil, false
+			}
+			sinks = append(sinks, s...)
+		}
+		return sinks, true
+	}
+	return nil, false
+}
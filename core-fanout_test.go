@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// countingEncoder 统计 EncodeEntry 调用次数
+type countingEncoder struct {
+	zapcore.Encoder
+	n *int
+}
+
+func (e countingEncoder) Clone() zapcore.Encoder {
+	return countingEncoder{Encoder: e.Encoder.Clone(), n: e.n}
+}
+
+func (e countingEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	*e.n++
+	return e.Encoder.EncodeEntry(ent, fields)
+}
+
+func TestFanoutCore(t *testing.T) {
+	var encoded int
+	enc := countingEncoder{Encoder: zapcore.NewJSONEncoder(jsonEncoderConfig()), n: &encoded}
+	var info, warn, other bytes.Buffer
+	cores := []zapcore.Core{
+		newSinkCore(enc, zapcore.AddSync(&info), zapcore.InfoLevel),
+		levelTee{
+			newSinkCore(enc, zapcore.AddSync(&warn), zapcore.WarnLevel),
+			newSinkCore(enc, zapcore.AddSync(&warn), zapcore.ErrorLevel),
+		},
+		// 使用其他编码器的 Core 不合并
+		newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), zapcore.AddSync(&other), zapcore.InfoLevel),
+	}
+	merged := mergeSinks(cores, enc)
+	if len(merged) != 2 {
+		t.Fatalf("got %d cores, want 2", len(merged))
+	}
+	logger := zap.New(zapcore.NewTee(merged...)).With(zap.String("service", "api"))
+	logger.Info("started")
+	logger.Error("failed")
+
+	if encoded != 2 {
+		t.Errorf("encoded %d times, want 2", encoded)
+	}
+	if got := strings.Count(info.String(), `"service":"api"`); got != 2 {
+		t.Errorf("info sink got %d entries, want 2:\n%s", got, info.String())
+	}
+	// warn 与 error 输出各一条 failed
+	if got := strings.Count(warn.String(), "failed"); got != 2 || strings.Contains(warn.String(), "started") {
+		t.Errorf("warn sinks = %s", warn.String())
+	}
+	if got := strings.Count(other.String(), "\n"); got != 2 {
+		t.Errorf("other sink got %d entries, want 2", got)
+	}
+}
+
+func TestMergeSinksSingle(t *testing.T) {
+	enc := zapcore.NewJSONEncoder(jsonEncoderConfig())
+	cores := []zapcore.Core{newSinkCore(enc, zapcore.AddSync(&bytes.Buffer{}), zapcore.InfoLevel)}
+	if got := mergeSinks(cores, enc); got[0] != cores[0] {
+		t.Error("single core should be returned unchanged")
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// messageFilterCore 只写出消息通过 include / exclude 正则的日志。
+// 它在 Check 阶段直接交给内层 Core，应放在最外层，被过滤的日志不会占用限流令牌
+type messageFilterCore struct {
+	zapcore.Core
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newMessageFilter 编译消息筛选规则，没有规则时返回 nil
+func newMessageFilter(include, exclude string) (*messageFilterCore, error) {
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+	f := &messageFilterCore{}
+	var err error
+	if include != "" {
+		if f.include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("invalid include: %w", err)
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// wrap 返回以 core 为内层、使用相同规则的 Core
+func (c *messageFilterCore) wrap(core zapcore.Core) *messageFilterCore {
+	return &messageFilterCore{Core: core, include: c.include, exclude: c.exclude}
+}
+
+func (c *messageFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return c.wrap(c.Core.With(fields))
+}
+
+func (c *messageFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.include != nil && !c.include.MatchString(ent.Message) {
+		return ce
+	}
+	if c.exclude != nil && c.exclude.MatchString(ent.Message) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// fieldFilterCore 按键名筛选字段：allow 非空时只保留列出的字段，deny 中的字段总是去掉。
+// Namespace 字段总是保留
+type fieldFilterCore struct {
+	zapcore.Core
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+func newFieldFilterCore(core zapcore.Core, allow, deny []string) *fieldFilterCore {
+	c := &fieldFilterCore{Core: core}
+	if len(allow) > 0 {
+		c.allow = make(map[string]bool, len(allow))
+		for _, key := range allow {
+			c.allow[key] = true
+		}
+	}
+	if len(deny) > 0 {
+		c.deny = make(map[string]bool, len(deny))
+		for _, key := range deny {
+			c.deny[key] = true
+		}
+	}
+	return c
+}
+
+func (c *fieldFilterCore) fields(fields []zapcore.Field) []zapcore.Field {
+	fields = resolveLazy(fields)
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Type == zapcore.NamespaceType || (c.allow == nil || c.allow[f.Key]) && !c.deny[f.Key] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func (c *fieldFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldFilterCore{Core: c.Core.With(c.fields(fields)), allow: c.allow, deny: c.deny}
+}
+
+func (c *fieldFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *fieldFilterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.fields(fields))
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMessageFilterCore(t *testing.T) {
+	filter, err := newMessageFilter("^payment", "healthcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(filter.wrap(core)).With(zap.String("service", "api"))
+
+	logger.Info("payment captured")
+	logger.Info("payment healthcheck")
+	logger.Info("user login")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Message != "payment captured" {
+		t.Fatalf("entries = %v", entries)
+	}
+	if entries[0].ContextMap()["service"] != "api" {
+		t.Errorf("context fields lost: %v", entries[0].ContextMap())
+	}
+
+	if f, _ := newMessageFilter("", ""); f != nil {
+		t.Error("expected nil filter without rules")
+	}
+	if _, err := newMessageFilter("(", ""); err == nil {
+		t.Error("expected error for invalid include")
+	}
+}
+
+func TestFieldFilterCore(t *testing.T) {
+	tests := []struct {
+		name        string
+		allow, deny []string
+		want        []string
+	}{
+		{"allow", []string{"user", "order"}, nil, []string{"user", "order"}},
+		{"deny", nil, []string{"payload"}, []string{"user", "order", "trace"}},
+		{"allow and deny", []string{"user", "order"}, []string{"order"}, []string{"user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.DebugLevel)
+			logger := zap.New(newFieldFilterCore(core, tt.allow, tt.deny)).With(zap.String("user", "bob"))
+			logger.Info("order placed", zap.Int("order", 1), zap.String("trace", "t"), zap.String("payload", "{}"))
+
+			fields := logs.AllUntimed()[0].ContextMap()
+			if len(fields) != len(tt.want) {
+				t.Errorf("fields = %v, want keys %v", fields, tt.want)
+			}
+			for _, key := range tt.want {
+				if _, ok := fields[key]; !ok {
+					t.Errorf("missing %s in %v", key, fields)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// flightCore 飞行记录器：未达到输出级别的 debug 日志保存在环形缓冲区中，
+// 出现 error 及以上级别的日志时先回放缓冲区再写出该日志，两者带有相同的 flight_id 字段。
+// 回放的日志以输出允许的最低级别写出，原级别记录在 flight_level 字段。
+// 缓冲区由 With 派生的 logger 共享，回放的是整个 Logger 最近的日志
+type flightCore struct {
+	zapcore.Core
+	rec *flightRecorder
+}
+
+func newFlightCore(core zapcore.Core, size int) *flightCore {
+	return &flightCore{Core: core, rec: &flightRecorder{entries: make([]flightEntry, size)}}
+}
+
+// Enabled debug 日志即使低于输出级别也需要记录
+func (c *flightCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.DebugLevel || c.Core.Enabled(lvl)
+}
+
+func (c *flightCore) With(fields []zapcore.Field) zapcore.Core {
+	return &flightCore{Core: c.Core.With(fields), rec: c.rec}
+}
+
+func (c *flightCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		if ent.Level >= zapcore.DebugLevel {
+			return ce.AddCore(ent, c)
+		}
+		return ce
+	}
+	if ent.Level >= zapcore.ErrorLevel {
+		return ce.AddCore(ent, c)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func (c *flightCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Core.Enabled(ent.Level) {
+		c.rec.add(flightEntry{core: c.Core, ent: ent, fields: slices.Clone(fields)})
+		return nil
+	}
+	id, err := c.rec.dump()
+	if id != "" {
+		fields = append(slices.Clip(fields), zap.String("flight_id", id))
+	}
+	return errors.Join(err, writeChecked(c.Core.Check(ent, nil), fields))
+}
+
+// flightEntry 记录的日志及写入时所在的 Core（包含 With 添加的上下文字段）
+type flightEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// flightRecorder 固定容量的环形缓冲区，满时覆盖最旧的日志
+type flightRecorder struct {
+	mu      sync.Mutex
+	entries []flightEntry
+	next    int
+	count   int
+}
+
+func (r *flightRecorder) add(e flightEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	r.count = min(r.count+1, len(r.entries))
+}
+
+// dump 按时间顺序回放并清空缓冲区，返回本次回放的 flight_id 与回放中的写入错误，缓冲区为空时返回空字符串
+func (r *flightRecorder) dump() (string, error) {
+	r.mu.Lock()
+	entries := make([]flightEntry, 0, r.count)
+	for i := range r.count {
+		entries = append(entries, r.entries[(r.next-r.count+i+len(r.entries))%len(r.entries)])
+	}
+	clear(r.entries)
+	r.next, r.count = 0, 0
+	r.mu.Unlock()
+	if len(entries) == 0 {
+		return "", nil
+	}
+
+	id := randomHex(8)
+	var errs []error
+	for _, e := range entries {
+		ent := e.ent
+		ent.Level = max(zapcore.LevelOf(e.core), ent.Level)
+		errs = append(errs, writeChecked(e.core.Check(ent, nil), append(e.fields, zap.String("flight_id", id), zap.Stringer("flight_level", e.ent.Level))))
+	}
+	return id, errors.Join(errs...)
+}
+
+// randomHex 生成 n 字节随机数的十六进制表示，用作关联 ID
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFlightCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(newFlightCore(core, 3))
+
+	// 只保留最近 3 条 debug 日志，子 logger 的上下文字段随记录一起回放
+	for i := range 4 {
+		logger.Debug("step", zap.Int("i", i))
+	}
+	logger.With(zap.String("req", "r1")).Debug("child")
+	logger.Info("normal")
+	if logs.Len() != 1 {
+		t.Fatalf("got %d entries before error, want 1", logs.Len())
+	}
+	logger.Error("failed")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	id := entries[4].ContextMap()["flight_id"]
+	if entries[4].Message != "failed" || id == nil {
+		t.Fatalf("error entry = %+v", entries[4])
+	}
+	for i, want := range []string{"step", "step", "child"} {
+		ent := entries[i+1]
+		fields := ent.ContextMap()
+		if ent.Message != want {
+			t.Errorf("entry %d = %q, want %q", i+1, ent.Message, want)
+		}
+		if ent.Level != zapcore.InfoLevel || fields["flight_level"] != "debug" || fields["flight_id"] != id {
+			t.Errorf("replayed entry %d = %v %v", i+1, ent.Level, fields)
+		}
+	}
+	if entries[1].ContextMap()["i"] != int64(2) || entries[2].ContextMap()["i"] != int64(3) {
+		t.Errorf("replayed %v, %v, want steps 2 and 3", entries[1].ContextMap(), entries[2].ContextMap())
+	}
+	if got := entries[3].ContextMap()["req"]; got != "r1" {
+		t.Errorf("child context = %v, want r1", got)
+	}
+
+	// 回放后缓冲区清空，没有新记录时 error 不带 flight_id
+	logger.Error("again")
+	if last := logs.AllUntimed()[logs.Len()-1]; last.ContextMap()["flight_id"] != nil {
+		t.Errorf("unexpected flight_id on %+v", last)
+	}
+}
+
+func TestFlightCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	core := newFlightCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.ErrorLevel), 3)
+	if err := core.Write(zapcore.Entry{Level: zapcore.DebugLevel, Message: "step"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 回放与写出的错误都返回给调用方
+	err := core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "failed"}, nil)
+	if err == nil || strings.Count(err.Error(), "disk full") != 2 {
+		t.Errorf("Write() error = %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// mirrorCore 将日志写入主适配器，同时复制一份交给影子适配器，用于在切换前验证新的采集端。
+// 主适配器的写入结果照常返回；影子适配器由后台协程异步写入，变慢或失败都不影响调用方
+type mirrorCore struct {
+	primary zapcore.Core
+	shadow  zapcore.Core
+	m       *mirror
+}
+
+func (c *mirrorCore) Enabled(lvl zapcore.Level) bool {
+	return c.primary.Enabled(lvl) || c.shadow.Enabled(lvl)
+}
+
+func (c *mirrorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &mirrorCore{primary: c.primary.With(fields), shadow: c.shadow.With(fields), m: c.m}
+}
+
+func (c *mirrorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *mirrorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.shadow.Enabled(ent.Level) {
+		c.m.enqueue(flightEntry{core: c.shadow, ent: ent, fields: snapshotFields(fields)})
+	}
+	inner := c.primary.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	err := c.m.write(inner, fields, &c.m.primaryLatency)
+	if err != nil {
+		c.m.primaryErrors.Add(1)
+	}
+	return err
+}
+
+// snapshotFields 复制字段供影子协程稍后编码。引用调用方数据的字段（Object、Array、Reflect、Stringer、
+// Binary 等）在调用方协程中取值并深拷贝，避免调用方返回后修改这些数据造成数据竞争
+func snapshotFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.BinaryType, zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				f.Interface = slices.Clone(b)
+			}
+		case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType, zapcore.StringerType:
+			// 编码失败时 zap 另外添加 <key>Error 字段，一并保留
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			for _, key := range slices.Sorted(maps.Keys(enc.Fields)) {
+				if v, ok := freezeValue(enc.Fields[key]).(string); ok {
+					out = append(out, zapcore.Field{Key: key, Type: zapcore.StringType, String: v})
+				} else {
+					out = append(out, zapcore.Field{Key: key, Type: zapcore.ReflectType, Interface: freezeValue(enc.Fields[key])})
+				}
+			}
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// freezeValue 深拷贝 MapObjectEncoder 取得的值；AddReflected 保留的调用方对象预先编码为 JSON
+func freezeValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		float32, float64, complex64, complex128, time.Time, time.Duration:
+		return v
+	case []byte:
+		return slices.Clone(v)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = freezeValue(item)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = freezeValue(item)
+		}
+		return list
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return json.RawMessage(b)
+	}
+}
+
+// Sync 只同步主适配器，影子适配器在关闭时写完队列
+func (c *mirrorCore) Sync() error { return c.primary.Sync() }
+
+// mirror 影子队列与对比统计，由 mirrorCore 的所有副本共享，同时作为适配器资源
+type mirror struct {
+	primary *adaptor
+	shadow  *adaptor
+	queue   chan flightEntry
+
+	entries        atomic.Uint64
+	shadowDropped  atomic.Uint64
+	primaryErrors  atomic.Uint64
+	shadowErrors   atomic.Uint64
+	primaryLatency latencyHistogram
+	shadowLatency  latencyHistogram
+
+	closeMu sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+func newMirror(primary, shadow *adaptor, buffer int) *mirror {
+	m := &mirror{primary: primary, shadow: shadow, queue: make(chan flightEntry, buffer), done: make(chan struct{})}
+	go m.run()
+	return m
+}
+
+// enqueue 将日志交给影子适配器，队列满或已关闭时丢弃并计数
+func (m *mirror) enqueue(e flightEntry) {
+	m.closeMu.RLock()
+	defer m.closeMu.RUnlock()
+	if m.closed {
+		return
+	}
+	select {
+	case m.queue <- e:
+		m.entries.Add(1)
+	default:
+		m.shadowDropped.Add(1)
+	}
+}
+
+func (m *mirror) run() {
+	defer close(m.done)
+	for e := range m.queue {
+		if inner := e.core.Check(e.ent, nil); inner != nil {
+			if m.write(inner, e.fields, &m.shadowLatency) != nil {
+				m.shadowErrors.Add(1)
+			}
+		}
+	}
+}
+
+// write 写入一条日志并记录耗时，写入错误经 ErrorOutput 取回
+func (m *mirror) write(ce *zapcore.CheckedEntry, fields []zapcore.Field, latency *latencyHistogram) error {
+	var out errorCapture
+	ce.ErrorOutput = &out
+	start := time.Now()
+	ce.Write(fields...)
+	latency.observe(time.Since(start))
+	return out.err
+}
+
+// Close 写完影子队列后关闭两个适配器
+func (m *mirror) Close() error {
+	_, err := m.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 写完影子队列后按 ctx 关闭两个适配器，合并其排空结果
+func (m *mirror) CloseContext(ctx context.Context) (DrainResult, error) {
+	m.closeMu.Lock()
+	if m.closed {
+		m.closeMu.Unlock()
+		return DrainResult{}, nil
+	}
+	m.closed = true
+	close(m.queue)
+	m.closeMu.Unlock()
+	<-m.done
+	var total DrainResult
+	var err error
+	for _, a := range []*adaptor{m.primary, m.shadow} {
+		if a.closer == nil {
+			continue
+		}
+		result, closeErr := closeContext(ctx, a.closer)
+		total.Flushed += result.Flushed
+		total.Abandoned += result.Abandoned
+		err = errors.Join(err, closeErr)
+	}
+	return total, err
+}
+
+// Dropped 只统计主适配器，影子适配器的丢弃计入 MirrorStats
+func (m *mirror) Dropped() uint64 {
+	var n uint64
+	for _, counter := range m.primary.counters {
+		n += counter.Dropped()
+	}
+	return n
+}
+
+// stats 以主适配器的状态为准，影子适配器的状态与对比统计放在 Mirror 中
+func (m *mirror) stats(st *AdaptorStats) {
+	if r, ok := m.primary.closer.(statsReporter); ok {
+		r.stats(st)
+	}
+	ms := &MirrorStats{
+		Shadow:        AdaptorStats{Name: m.shadow.name},
+		Entries:       m.entries.Load(),
+		ShadowDropped: m.shadowDropped.Load(),
+		PrimaryErrors: m.primaryErrors.Load(),
+		ShadowErrors:  m.shadowErrors.Load(),
+	}
+	for _, counter := range m.shadow.counters {
+		ms.Shadow.Dropped += counter.Dropped()
+	}
+	if r, ok := m.shadow.closer.(statsReporter); ok {
+		r.stats(&ms.Shadow)
+	}
+	m.primaryLatency.stats(&ms.PrimaryLatency)
+	m.shadowLatency.stats(&ms.ShadowLatency)
+	st.Mirror = ms
+}
+
+// Probe 探测两个适配器，影子适配器的失败同样报告
+func (m *mirror) Probe(ctx context.Context) error {
+	var errs []error
+	for i, a := range []*adaptor{m.primary, m.shadow} {
+		if p, ok := a.closer.(prober); ok {
+			if err := p.Probe(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", []string{"primary", "shadow"}[i], err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMirrorCore(t *testing.T) {
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey = ""
+	primary, shadow := &fakeSink{}, &fakeSink{}
+	primaryCore := newSinkCore(zapcore.NewJSONEncoder(cfg), primary, zapcore.InfoLevel)
+	shadowCore := newSinkCore(zapcore.NewJSONEncoder(cfg), shadow, zapcore.DebugLevel)
+	m := newMirror(&adaptor{core: primaryCore, closer: primary}, &adaptor{name: "shadow", core: shadowCore, closer: shadow}, 16)
+	logger := zap.New(&mirrorCore{primary: primaryCore, shadow: shadowCore, m: m}, zap.ErrorOutput(zapcore.AddSync(io.Discard)))
+
+	logger.With(zap.Int("n", 1)).Info("a")
+	logger.Debug("shadow only")
+	waitFor(t, func() bool {
+		shadow.mu.Lock()
+		defer shadow.mu.Unlock()
+		return len(shadow.lines) == 2
+	})
+	shadow.set(func(f *fakeSink) { f.writeErr = errors.New("shadow down") })
+	// 影子适配器写入失败不影响主适配器
+	if ce := logger.Check(zapcore.InfoLevel, "b"); ce == nil {
+		t.Fatal("expected entry")
+	} else {
+		ce.Write()
+	}
+	// 等待影子队列写完再恢复影子适配器
+	waitFor(t, func() bool { return m.shadowErrors.Load() != 0 })
+	shadow.set(func(f *fakeSink) { f.writeErr = nil })
+	primary.set(func(f *fakeSink) { f.writeErr = errors.New("disk full") })
+	logger.Info("c")
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(primary.lines) != 2 || primary.lines[0] != `{"level":"info","msg":"a","n":1}` || primary.lines[1] != `{"level":"info","msg":"b"}` {
+		t.Errorf("primary = %q", primary.lines)
+	}
+	if len(shadow.lines) != 3 || shadow.lines[0] != `{"level":"info","msg":"a","n":1}` || shadow.lines[1] != `{"level":"debug","msg":"shadow only"}` {
+		t.Errorf("shadow = %q", shadow.lines)
+	}
+	var st AdaptorStats
+	m.stats(&st)
+	ms := st.Mirror
+	if ms == nil || ms.Entries != 4 || ms.ShadowErrors != 1 || ms.PrimaryErrors != 1 || ms.ShadowDropped != 0 ||
+		ms.PrimaryLatency.Count != 3 || ms.ShadowLatency.Count != 4 || ms.Shadow.Name != "shadow" {
+		t.Errorf("mirror stats = %+v", ms)
+	}
+	if !primary.closed || !shadow.closed {
+		t.Error("adaptors not closed")
+	}
+	// 关闭后不再交给影子适配器
+	logger.Info("closed")
+	if m.Dropped() != 0 || m.entries.Load() != 4 {
+		t.Errorf("entries = %d", m.entries.Load())
+	}
+}
+
+func TestMirrorShadowQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	shadowCore := zapcore.NewCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), zapcore.AddSync(blockingWriter(block)), zapcore.InfoLevel)
+	primaryCore := zapcore.NewNopCore()
+	m := newMirror(&adaptor{core: primaryCore}, &adaptor{core: shadowCore}, 1)
+	logger := zap.New(&mirrorCore{primary: primaryCore, shadow: shadowCore, m: m})
+	// 第一条被后台协程取出后阻塞，第二条占满队列，之后的丢弃
+	for range 5 {
+		logger.Info("x")
+	}
+	close(block)
+	_ = m.Close()
+	var st AdaptorStats
+	m.stats(&st)
+	if st.Mirror.Entries+st.Mirror.ShadowDropped != 5 || st.Mirror.ShadowDropped < 2 {
+		t.Errorf("mirror stats = %+v", st.Mirror)
+	}
+}
+
+// blockingWriter 在通道关闭前阻塞写入
+type blockingWriter chan struct{}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w
+	return len(p), nil
+}
+
+// waitFor 等待后台协程满足条件
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+	}
+}
+
+// TestSnapshotFields 影子协程编码的字段不受调用方返回后修改的影响
+func TestSnapshotFields(t *testing.T) {
+	tags := map[string]any{"env": "prod", "ids": []int{1, 2}}
+	list := []string{"a", "b"}
+	raw := []byte("raw")
+	fields := snapshotFields([]zapcore.Field{
+		zap.Any("tags", tags),
+		zap.Strings("list", list),
+		zap.Binary("raw", raw),
+		zap.Stringer("d", time.Second),
+		zap.Int("n", 1),
+	})
+	tags["env"] = "dev"
+	tags["ids"].([]int)[0] = 9
+	list[0] = "z"
+	raw[0] = 'R'
+
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey = ""
+	buf, err := zapcore.NewJSONEncoder(cfg).EncodeEntry(zapcore.Entry{Message: "m"}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"info","msg":"m","tags":{"env":"prod","ids":[1,2]},"list":["a","b"],"raw":"cmF3","d":"1s","n":1}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("encoded = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrRateLimited 日志因超出适配器限流被丢弃
+var ErrRateLimited = errors.New("log rate limited, dropping log")
+
+// tokenBucket 简单的令牌桶限流器
+type tokenBucket struct {
+	last   time.Time
+	rate   float64
+	burst  float64
+	tokens float64
+	mu     sync.Mutex
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow 尝试取出一个令牌
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimitCore 对单个适配器限流，超出部分丢弃并计数
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *tokenBucket
+	dropped *atomic.Uint64
+	onDrop  func(n int, reason error)
+}
+
+func newRateLimitCore(core zapcore.Core, rate float64, burst int, onDrop func(n int, reason error)) *rateLimitCore {
+	return &rateLimitCore{
+		Core:    core,
+		limiter: newTokenBucket(rate, burst),
+		dropped: new(atomic.Uint64),
+		onDrop:  onDrop,
+	}
+}
+
+// Dropped 返回因限流被丢弃的日志条数
+func (c *rateLimitCore) Dropped() uint64 { return c.dropped.Load() }
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{
+		Core:    c.Core.With(fields),
+		limiter: c.limiter,
+		dropped: c.dropped,
+		onDrop:  c.onDrop,
+	}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.limiter.allow(time.Now()) {
+		c.dropped.Add(1)
+		if c.onDrop != nil {
+			c.onDrop(1, ErrRateLimited)
+		}
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
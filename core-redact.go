@@ -0,0 +1,162 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactMask 替换敏感内容的掩码
+const redactMask = "***"
+
+// redactPatterns 内置的值匹配规则，可在 redact-values 中按名称引用
+var redactPatterns = map[string]string{
+	"email":      `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"card":       `\b(?:\d[ -]?){12,18}\d\b`,
+	"aws-key":    `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"aws-secret": `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`,
+	"bearer":     `(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`,
+	"jwt":        `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	"cn-id":      `\b[1-9]\d{5}(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`,
+	"cn-phone":   `(?:\+86[- ]?|\b)1[3-9]\d{9}\b`,
+}
+
+// redactGroups 内置规则组，可在 redact-values 中按名称一次启用多条规则
+var redactGroups = map[string][]string{
+	"secrets": {"aws-key", "aws-secret", "bearer", "jwt"},
+	"pii":     {"email", "card", "cn-id", "cn-phone"},
+}
+
+// redactor 脱敏规则：键名匹配 keys 的字段整体替换为掩码，字符串值与消息中匹配 values 的部分替换为掩码
+type redactor struct {
+	keys   *regexp.Regexp
+	values []*regexp.Regexp
+}
+
+// newRedactor 编译脱敏规则，values 中的每一项为内置规则名、规则组名 (secrets, pii) 或正则表达式，没有规则时返回 nil
+func newRedactor(keys string, values []string) (*redactor, error) {
+	if keys == "" && len(values) == 0 {
+		return nil, nil
+	}
+	r := &redactor{}
+	if keys != "" {
+		re, err := regexp.Compile(keys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact-keys: %w", err)
+		}
+		r.keys = re
+	}
+	var expanded []string
+	for _, v := range values {
+		if group, ok := redactGroups[v]; ok {
+			expanded = append(expanded, group...)
+		} else {
+			expanded = append(expanded, v)
+		}
+	}
+	for _, v := range expanded {
+		if builtin, ok := redactPatterns[v]; ok {
+			v = builtin
+		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact-values: %w", err)
+		}
+		r.values = append(r.values, re)
+	}
+	return r, nil
+}
+
+// mask 替换字符串中匹配值规则的部分
+func (r *redactor) mask(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllLiteralString(s, redactMask)
+	}
+	return s
+}
+
+// fields 返回脱敏后的字段，不修改传入的切片。嵌套对象与数组只按键名整体脱敏，不检查其中的值
+func (r *redactor) fields(fields []zapcore.Field) []zapcore.Field {
+	fields = resolveLazy(fields)
+	var out []zapcore.Field
+	for i, f := range fields {
+		g, changed := r.field(f)
+		if !changed {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, g)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (r *redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, false
+	}
+	if r.keys != nil && r.keys.MatchString(f.Key) {
+		return zap.String(f.Key, redactMask), true
+	}
+	if len(r.values) == 0 {
+		return f, false
+	}
+	var s string
+	switch f.Type {
+	case zapcore.StringType:
+		s = f.String
+	case zapcore.ByteStringType:
+		s = string(f.Interface.([]byte))
+	case zapcore.StringerType:
+		s = fmt.Sprint(f.Interface)
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			return f, false
+		}
+		s = err.Error()
+	default:
+		return f, false
+	}
+	masked := r.mask(s)
+	if masked == s {
+		return f, false
+	}
+	return zap.String(f.Key, masked), true
+}
+
+// redactCore 在编码前对消息与字段脱敏
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func newRedactCore(core zapcore.Core, r *redactor) *redactCore {
+	return &redactCore{Core: core, r: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.mask(ent.Message)
+	return c.Core.Write(ent, c.r.fields(fields))
+}
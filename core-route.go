@@ -0,0 +1,167 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Route 路由规则，按顺序匹配，日志只写入第一条匹配规则的 To 列出的适配器。
+// 未设置的条件视为满足，没有条件的规则可作为默认规则放在最后
+type Route struct {
+	Level  string            `json:"level" yaml:"level"`   // 最低级别
+	Logger string            `json:"logger" yaml:"logger"` // logger 名称，同时匹配其子 logger (name.xxx)
+	Fields map[string]string `json:"fields" yaml:"fields"` // 字段值（按字符串比较）均相等
+	Tenant string            `json:"tenant" yaml:"tenant"` // 租户 (ForTenant)，等同于 Fields 中的 TenantKey
+	To     []string          `json:"to" yaml:"to"`         // 适配器名称（DSN 参数 name，默认为 scheme），为空表示丢弃
+}
+
+// compiledRoute 解析后的路由规则，targets 为 routerCore.cores 的下标
+type compiledRoute struct {
+	level    zapcore.Level
+	levelSet bool
+	logger   string
+	fields   map[string]string
+	targets  []int
+}
+
+func (r *compiledRoute) match(ent zapcore.Entry, fields map[string]string) bool {
+	if r.levelSet && ent.Level < r.level {
+		return false
+	}
+	if r.logger != "" && ent.LoggerName != r.logger && !strings.HasPrefix(ent.LoggerName, r.logger+".") {
+		return false
+	}
+	for k, v := range r.fields {
+		if got, ok := fields[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// compileRoutes 将路由规则中的适配器名称解析为下标，names 为全部已配置适配器的名称，
+// aliases 为实际创建成功的适配器名称（与 routerCore.cores 一一对应）
+func compileRoutes(routes []Route, names, aliases []string) ([]compiledRoute, error) {
+	compiled := make([]compiledRoute, 0, len(routes))
+	for i, route := range routes {
+		r := compiledRoute{logger: route.Logger, fields: route.Fields}
+		if route.Tenant != "" {
+			r.fields = maps.Clone(route.Fields)
+			if r.fields == nil {
+				r.fields = make(map[string]string, 1)
+			}
+			r.fields[TenantKey] = route.Tenant
+		}
+		if route.Level != "" {
+			lvl, err := zapcore.ParseLevel(route.Level)
+			if err != nil {
+				return nil, fmt.Errorf("invalid level in route %d: %w", i, err)
+			}
+			r.level, r.levelSet = lvl, true
+		}
+		for _, to := range route.To {
+			if !slices.Contains(names, to) {
+				return nil, fmt.Errorf("unknown adaptor %q in route %d", to, i)
+			}
+			// 同名适配器全部写入；创建失败的适配器没有对应下标
+			for j, alias := range aliases {
+				if alias == to && !slices.Contains(r.targets, j) {
+					r.targets = append(r.targets, j)
+				}
+			}
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// routerCore 按路由规则把每条日志分发给适配器，规则只在写入时匹配一次，
+// 未匹配任何规则的日志写入全部适配器。为了按字段匹配，With 添加的上下文字段同时保存在 Core 中
+type routerCore struct {
+	cores    []zapcore.Core
+	routes   []compiledRoute
+	context  []zapcore.Field
+	byFields bool // 存在按字段匹配的规则
+}
+
+func newRouterCore(cores []zapcore.Core, routes []compiledRoute) *routerCore {
+	c := &routerCore{cores: cores, routes: routes}
+	for _, r := range routes {
+		if len(r.fields) > 0 {
+			c.byFields = true
+		}
+	}
+	return c
+}
+
+func (c *routerCore) Enabled(lvl zapcore.Level) bool {
+	return slices.ContainsFunc(c.cores, func(core zapcore.Core) bool { return core.Enabled(lvl) })
+}
+
+func (c *routerCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &routerCore{routes: c.routes, byFields: c.byFields, cores: make([]zapcore.Core, len(c.cores))}
+	for i, core := range c.cores {
+		clone.cores[i] = core.With(fields)
+	}
+	if c.byFields {
+		clone.context = append(slices.Clip(c.context), fields...)
+	}
+	return clone
+}
+
+func (c *routerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 经由目标适配器的 Check 写出，使适配器自身的级别、筛选与限流生效，合并各适配器的写入错误
+func (c *routerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var values map[string]string
+	if c.byFields {
+		values = fieldStrings(c.context, fields)
+	}
+	var errs []error
+	for _, r := range c.routes {
+		if r.match(ent, values) {
+			for _, i := range r.targets {
+				errs = append(errs, writeChecked(c.cores[i].Check(ent, nil), fields))
+			}
+			return errors.Join(errs...)
+		}
+	}
+	for _, core := range c.cores {
+		errs = append(errs, writeChecked(core.Check(ent, nil), fields))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *routerCore) Sync() error {
+	var errs []error
+	for _, core := range c.cores {
+		errs = append(errs, core.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// fieldStrings 将顶层字段转换为字符串值，用于路由匹配
+func fieldStrings(context, fields []zapcore.Field) map[string]string {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range context {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	values := make(map[string]string, len(enc.Fields))
+	for k, v := range enc.Fields {
+		values[k] = fmt.Sprint(v)
+	}
+	return values
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRouterCore(t *testing.T) {
+	errCore, errLogs := observer.New(zap.DebugLevel)
+	accessCore, accessLogs := observer.New(zap.DebugLevel)
+	fileCore, fileLogs := observer.New(zap.InfoLevel)
+	routes, err := compileRoutes([]Route{
+		{Level: "error", To: []string{"sentry", "file"}},
+		{Logger: "access", To: []string{"loki"}},
+		{Fields: map[string]string{"audit": "true"}, To: nil},
+		{To: []string{"file"}},
+	}, []string{"sentry", "loki", "file"}, []string{"sentry", "loki", "file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(newRouterCore([]zapcore.Core{errCore, accessCore, fileCore}, routes))
+
+	logger.Error("boom")
+	logger.Named("access").Info("GET /")
+	logger.Named("access.v2").Info("GET /v2")
+	logger.With(zap.Bool("audit", true)).Info("audit record")
+	logger.Info("started")
+	logger.Debug("below file level")
+
+	if got := errLogs.Len(); got != 1 {
+		t.Errorf("sentry got %d entries, want 1", got)
+	}
+	if got := accessLogs.Len(); got != 2 {
+		t.Errorf("loki got %d entries, want 2", got)
+	}
+	var msgs []string
+	for _, e := range fileLogs.AllUntimed() {
+		msgs = append(msgs, e.Message)
+	}
+	if len(msgs) != 2 || msgs[0] != "boom" || msgs[1] != "started" {
+		t.Errorf("file got %v", msgs)
+	}
+}
+
+func TestRouterCoreUnmatched(t *testing.T) {
+	a, aLogs := observer.New(zap.DebugLevel)
+	b, bLogs := observer.New(zap.DebugLevel)
+	routes, err := compileRoutes([]Route{{Level: "error", To: []string{"a"}}}, []string{"a", "b"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := zap.New(newRouterCore([]zapcore.Core{a, b}, routes))
+	logger.Info("everywhere")
+	logger.Error("only a")
+	if aLogs.Len() != 2 || bLogs.Len() != 1 {
+		t.Errorf("a=%d b=%d, want 2 and 1", aLogs.Len(), bLogs.Len())
+	}
+}
+
+func TestRouterCoreWriteError(t *testing.T) {
+	ok, okLogs := observer.New(zap.DebugLevel)
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	failing := newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel)
+	routes, err := compileRoutes([]Route{{Level: "error", To: []string{"a", "b"}}}, []string{"a", "b"}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := newRouterCore([]zapcore.Core{ok, failing}, routes)
+	// 命中路由与未命中路由时，目标适配器的写入错误都返回给调用方
+	for _, lvl := range []zapcore.Level{zapcore.ErrorLevel, zapcore.InfoLevel} {
+		if err := core.Write(zapcore.Entry{Level: lvl, Message: "lost"}, nil); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("Write(%s) error = %v", lvl, err)
+		}
+	}
+	if okLogs.Len() != 2 {
+		t.Errorf("healthy target got %d entries, want 2", okLogs.Len())
+	}
+}
+
+func TestCompileRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   Route
+		wantErr bool
+	}{
+		{"known", Route{Level: "warn", To: []string{"file"}}, false},
+		{"failed adaptor", Route{To: []string{"http"}}, false},
+		{"unknown adaptor", Route{To: []string{"kafka"}}, true},
+		{"invalid level", Route{Level: "loud", To: []string{"file"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := compileRoutes([]Route{tt.route}, []string{"file", "http"}, []string{"file"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.route.To[0] == "http" && len(routes[0].targets) != 0 {
+				t.Errorf("targets = %v, want none", routes[0].targets)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sampleCore 按哈希确定性地转发一定比例的日志，用于按量计费的输出只需要少量 info 日志的场景。
+// 相同的日志（设置 key 时该字段的值，否则 trace ID，都没有时消息与 logger 名称）总是得到相同的结果；
+// warn 及以上级别总是转发
+type sampleCore struct {
+	zapcore.Core
+	key       string          // 采样字段，为空时按 TraceIDKey
+	threshold uint64          // 哈希值小于 threshold 的日志转发
+	context   []zapcore.Field // With 添加的采样字段
+	skipped   *atomic.Uint64
+}
+
+func newSampleCore(core zapcore.Core, opts *SampleOptions) *sampleCore {
+	threshold := uint64(math.MaxUint64)
+	if opts.Rate < 1 {
+		threshold = uint64(math.Ldexp(opts.Rate, 64))
+	}
+	key := opts.Key
+	if key == "" {
+		key = TraceIDKey
+	}
+	return &sampleCore{Core: core, key: key, threshold: threshold, skipped: new(atomic.Uint64)}
+}
+
+func (c *sampleCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	for _, f := range fields {
+		if f.Key == c.key {
+			clone.context = append(clone.context[:len(clone.context):len(clone.context)], f)
+		}
+	}
+	return &clone
+}
+
+// Check 只判断级别，采样字段在 Write 中才可见；略过的日志不交给被包装的适配器编码
+func (c *sampleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *sampleCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	h := entryHash(ent)
+	if v, ok := sampleKey(c.key, c.context, fields); ok {
+		h = stringHash(v)
+	}
+	if !c.keep(ent.Level, h) {
+		c.skipped.Add(1)
+		return nil
+	}
+	// 被包装的适配器可能有自己的筛选与路由，重新 Check
+	return writeChecked(c.Core.Check(ent, nil), fields)
+}
+
+func (c *sampleCore) keep(lvl zapcore.Level, h uint64) bool {
+	return lvl >= zapcore.WarnLevel || c.threshold == math.MaxUint64 || h < c.threshold
+}
+
+// entryHash 按 logger 名称与消息计算哈希，与时间无关，重放或重试的日志得到相同的结果
+func entryHash(ent zapcore.Entry) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(ent.LoggerName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(ent.Message))
+	return mix64(h.Sum64())
+}
+
+func stringHash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return mix64(h.Sum64())
+}
+
+// mix64 打散 FNV 的高位，使相近的输入在 [0, 2^64) 上均匀分布
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// sampleKey 查找采样字段的值，同名字段以最后一个为准
+func sampleKey(key string, context, fields []zapcore.Field) (string, bool) {
+	for _, list := range [][]zapcore.Field{fields, context} {
+		for i := len(list) - 1; i >= 0; i-- {
+			f := list[i]
+			if f.Key != key {
+				continue
+			}
+			if f.Type == zapcore.StringType {
+				return f.String, true
+			}
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			return fmt.Sprint(enc.Fields[key]), true
+		}
+	}
+	return "", false
+}
+
+// sampleCloser 采样适配器的资源：关闭被包装的适配器，合并其丢弃计数与运行状态
+type sampleCloser struct {
+	core   *sampleCore
+	target *adaptor
+}
+
+func (c *sampleCloser) Close() error {
+	_, err := c.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 按 ctx 关闭被包装的适配器
+func (c *sampleCloser) CloseContext(ctx context.Context) (DrainResult, error) {
+	if c.target.closer == nil {
+		return DrainResult{}, nil
+	}
+	return closeContext(ctx, c.target.closer)
+}
+
+func (c *sampleCloser) Dropped() uint64 {
+	var n uint64
+	for _, counter := range c.target.counters {
+		n += counter.Dropped()
+	}
+	return n
+}
+
+func (c *sampleCloser) stats(st *AdaptorStats) {
+	if r, ok := c.target.closer.(statsReporter); ok {
+		r.stats(st)
+	}
+	st.SampledOut = c.core.skipped.Load()
+}
+
+func (c *sampleCloser) Probe(ctx context.Context) error {
+	if p, ok := c.target.closer.(prober); ok {
+		return p.Probe(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampleCore(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	core := newSampleCore(inner, &SampleOptions{Rate: 0.1})
+	logger := zap.New(core)
+	for i := range 10000 {
+		logger.Info(fmt.Sprintf("req-%d", i))
+	}
+	kept := logs.Len()
+	logger.Warn("always")
+	logger.Error("always")
+	if kept < 800 || kept > 1200 {
+		t.Errorf("kept %d of 10000 at rate 0.1", kept)
+	}
+	if logs.FilterMessage("always").Len() != 2 {
+		t.Error("warn and above must not be sampled")
+	}
+	if got := core.skipped.Load(); got != uint64(10000-kept) {
+		t.Errorf("skipped = %d", got)
+	}
+
+	// 相同的日志得到相同的结果，与时间无关
+	logs.TakeAll()
+	for i := range 10000 {
+		if ce := logger.Check(zapcore.InfoLevel, fmt.Sprintf("req-%d", i)); ce != nil {
+			ce.Time = time.Now().Add(time.Duration(i) * time.Hour)
+			ce.Write()
+		}
+	}
+	if logs.Len() != kept {
+		t.Errorf("replay kept %d, want %d", logs.Len(), kept)
+	}
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "same"}
+	named := ent
+	named.LoggerName = "other"
+	if entryHash(ent) == entryHash(named) {
+		t.Error("logger name must be part of the hash")
+	}
+
+	// 有 trace ID 时按 trace ID 采样，同一个请求的日志同进同出
+	logs.TakeAll()
+	for i := range 500 {
+		traced := logger.With(zap.String(TraceIDKey, fmt.Sprintf("t-%d", i)))
+		traced.Info("start")
+		traced.Info("end")
+		if n := logs.Len(); n != 0 && n != 2 {
+			t.Fatalf("trace t-%d partially sampled: %d", i, n)
+		}
+		logs.TakeAll()
+	}
+}
+
+func TestSampleCoreKey(t *testing.T) {
+	inner, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newSampleCore(inner, &SampleOptions{Rate: 0.2, Key: "trace_id"}))
+	// 同一个 trace 的日志同进同出，With 与调用时的字段都可以
+	kept := 0
+	for i := range 500 {
+		traced := logger.With(zap.String("trace_id", fmt.Sprintf("t-%d", i)))
+		traced.Info("start")
+		traced.Info("end", zap.Int("status", 200))
+		logger.Debug("step", zap.String("trace_id", fmt.Sprintf("t-%d", i)))
+		if n := logs.Len(); n != kept && n != kept+3 {
+			t.Fatalf("trace t-%d partially sampled: %d", i, n-kept)
+		}
+		kept = logs.Len()
+	}
+	if kept < 3*50 || kept > 3*150 {
+		t.Errorf("kept %d entries of 500 traces at rate 0.2", kept)
+	}
+	// 非字符串类型的字段按字符串形式采样
+	a, _ := sampleKey("id", nil, []zapcore.Field{zap.Int("id", 42)})
+	b, _ := sampleKey("id", []zapcore.Field{zap.String("id", "42")}, nil)
+	if a != "42" || b != "42" {
+		t.Errorf("sampleKey = %q, %q", a, b)
+	}
+}
+
+func TestSampleCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	core := newSampleCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel), &SampleOptions{Rate: 1})
+	if err := core.Write(zapcore.Entry{Level: zapcore.WarnLevel, Message: "lost"}, nil); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Write() error = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sequence 进程内所有 Logger 共享的日志序号
+var sequence atomic.Uint64
+
+// runID 本次运行的 ID，进程重启后序号从 1 开始，由 run_id 区分
+var runID = sync.OnceValue(func() string { return randomHex(8) })
+
+// sequenceCore 为每条写出的日志附加递增的 seq 字段与本次运行的 run_id 字段，
+// 序号在内层 Core 接受日志后才分配，被采样或级别过滤的日志不占用序号
+type sequenceCore struct {
+	zapcore.Core
+}
+
+func newSequenceCore(core zapcore.Core) sequenceCore {
+	return sequenceCore{core.With([]zapcore.Field{zap.String("run_id", runID())})}
+}
+
+func (c sequenceCore) With(fields []zapcore.Field) zapcore.Core {
+	return sequenceCore{c.Core.With(fields)}
+}
+
+func (c sequenceCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c sequenceCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// 被包装的 Core（如采样）拒绝的日志不占用序号
+	ce := c.Core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	return writeChecked(ce, append(slices.Clip(fields), zap.Uint64("seq", sequence.Add(1))))
+}
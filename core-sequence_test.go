@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSequenceCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	// 采样在内层，被丢弃的日志不占用序号
+	sampled := zapcore.NewSamplerWithOptions(core, time.Hour, 2, 0)
+	logger := zap.New(newSequenceCore(sampled))
+
+	for range 4 {
+		logger.Info("repeated")
+	}
+	logger.Debug("below level")
+	logger.With(zap.String("req", "r1")).Warn("child")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	first := entries[0].ContextMap()["seq"].(uint64)
+	for i, e := range entries {
+		fields := e.ContextMap()
+		if fields["seq"] != first+uint64(i) {
+			t.Errorf("entry %d seq = %v, want %d", i, fields["seq"], first+uint64(i))
+		}
+		if fields["run_id"] != runID() {
+			t.Errorf("entry %d run_id = %v, want %s", i, fields["run_id"], runID())
+		}
+	}
+}
+
+func TestSequenceCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("broken pipe")}
+	var errOut bytes.Buffer
+	logger := zap.New(newSequenceCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel)), zap.ErrorOutput(zapcore.AddSync(&errOut)))
+	logger.Info("lost")
+	if !strings.Contains(errOut.String(), "broken pipe") {
+		t.Errorf("ErrorOutput = %q", errOut.String())
+	}
+}
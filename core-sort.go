@@ -0,0 +1,75 @@
+package log
+
+import (
+	"slices"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sortedCore 以确定的顺序输出字段：order 中列出的字段在前（按列出顺序），其余按键名排序。
+// 为了与调用时的字段一起排序，With 添加的上下文字段保存在 Core 中，每次写入时重新编码
+type sortedCore struct {
+	zapcore.Core
+	rank    map[string]int
+	context []zapcore.Field
+}
+
+func newSortedCore(core zapcore.Core, order []string) *sortedCore {
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	return &sortedCore{Core: core, rank: rank}
+}
+
+func (c *sortedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sortedCore{
+		Core:    c.Core,
+		rank:    c.rank,
+		context: append(slices.Clip(c.context), fields...),
+	}
+}
+
+func (c *sortedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sortedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	all = append(all, resolveLazy(c.context)...)
+	all = append(all, resolveLazy(fields)...)
+	// Namespace 之后的字段属于该命名空间，只在相邻的 Namespace 之间排序
+	start := 0
+	for i := 0; i <= len(all); i++ {
+		if i == len(all) || all[i].Type == zapcore.NamespaceType {
+			slices.SortStableFunc(all[start:i], c.compare)
+			start = i + 1
+		}
+	}
+	return c.Core.Write(ent, all)
+}
+
+// compare 指定顺序的字段在前，其余按键名排序
+func (c *sortedCore) compare(a, b zapcore.Field) int {
+	ra, aok := c.rank[a.Key]
+	rb, bok := c.rank[b.Key]
+	switch {
+	case aok && bok:
+		return ra - rb
+	case aok:
+		return -1
+	case bok:
+		return 1
+	case a.Key < b.Key:
+		return -1
+	case a.Key > b.Key:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSortedCore(t *testing.T) {
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey, cfg.CallerKey = "", ""
+	var out bytes.Buffer
+	inner := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), zapcore.AddSync(&out), zapcore.DebugLevel)
+	logger := zap.New(newSortedCore(inner, []string{"request_id", "user"}))
+
+	logger.With(zap.String("zone", "b"), zap.String("user", "bob")).Info("sorted",
+		zap.Int("count", 1),
+		zap.String("request_id", "r-1"),
+		zap.Namespace("detail"),
+		zap.String("y", "2"),
+		zap.String("x", "1"),
+	)
+
+	want := `{"level":"info","msg":"sorted","request_id":"r-1","user":"bob","count":1,"zone":"b","detail":{"x":"1","y":"2"}}` + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
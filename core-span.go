@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// spanEvents 是否将 error 及以上级别的日志记录为 span 事件，由 Config.TraceSpanEvents 设置
+var spanEvents atomic.Bool
+
+// spanEventCore 在原 Core 之外，把 error 及以上级别的日志作为事件写入 span
+type spanEventCore struct {
+	zapcore.Core
+	span    trace.Span
+	context []zapcore.Field
+}
+
+func newSpanEventCore(core zapcore.Core, span trace.Span, fields []zapcore.Field) *spanEventCore {
+	return &spanEventCore{Core: core, span: span, context: fields}
+}
+
+func (c *spanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	return &spanEventCore{
+		Core:    c.Core.With(fields),
+		span:    c.span,
+		context: append(slices.Clip(c.context), fields...),
+	}
+}
+
+func (c *spanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if ent.Level >= zapcore.ErrorLevel {
+		ce = ce.AddCore(ent, spanEventRecorder{c})
+	}
+	return ce
+}
+
+// spanEventRecorder 只负责记录 span 事件，日志本身由被包装的 Core 写出
+type spanEventRecorder struct{ *spanEventCore }
+
+func (r spanEventRecorder) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range r.context {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	attrs := make([]attribute.KeyValue, 0, len(enc.Fields)+2)
+	attrs = append(attrs,
+		attribute.String("log.severity", ent.Level.CapitalString()),
+		attribute.String("log.message", ent.Message),
+	)
+	for k, v := range enc.Fields {
+		attrs = append(attrs, spanAttribute(k, v))
+	}
+	r.span.AddEvent(ent.Message, trace.WithTimestamp(ent.Time), trace.WithAttributes(attrs...))
+	return nil
+}
+
+func (r spanEventRecorder) Sync() error { return nil }
+
+// spanAttribute 将编码后的字段值转换为 span 属性，非基本类型以字符串记录
+func spanAttribute(k string, v any) attribute.KeyValue {
+	switch v := v.(type) {
+	case string:
+		return attribute.String(k, v)
+	case bool:
+		return attribute.Bool(k, v)
+	case int:
+		return attribute.Int(k, v)
+	case int64:
+		return attribute.Int64(k, v)
+	case int32:
+		return attribute.Int64(k, int64(v))
+	case uint32:
+		return attribute.Int64(k, int64(v))
+	case float64:
+		return attribute.Float64(k, v)
+	case float32:
+		return attribute.Float64(k, float64(v))
+	case time.Duration:
+		return attribute.String(k, v.String())
+	case time.Time:
+		return attribute.String(k, v.Format(time.RFC3339Nano))
+	default:
+		return attribute.String(k, fmt.Sprint(v))
+	}
+}
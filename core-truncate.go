@@ -0,0 +1,138 @@
+package log
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncateMarker 追加在被截断内容之后的标记
+const truncateMarker = "...truncated"
+
+// truncateString 将 s 截断为不超过 limit 字节（不拆分 UTF-8 字符）并追加标记
+func truncateString(s string, limit int) string {
+	n := limit
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncateMarker
+}
+
+// truncateCore 截断过长的消息与字符串字段，并记录原始长度：
+// 消息对应 msg_length 字段，字段 k 对应 k_length 字段
+type truncateCore struct {
+	zapcore.Core
+	maxMessage int // 0 表示不限制
+	maxField   int
+}
+
+func newTruncateCore(core zapcore.Core, maxMessage, maxField int) *truncateCore {
+	return &truncateCore{Core: core, maxMessage: maxMessage, maxField: maxField}
+}
+
+func (c *truncateCore) With(fields []zapcore.Field) zapcore.Core {
+	return &truncateCore{Core: c.Core.With(c.fields(fields)), maxMessage: c.maxMessage, maxField: c.maxField}
+}
+
+func (c *truncateCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *truncateCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = c.fields(fields)
+	if c.maxMessage > 0 && len(ent.Message) > c.maxMessage {
+		fields = append(fields, zap.Int("msg_length", len(ent.Message)))
+		ent.Message = truncateString(ent.Message, c.maxMessage)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// fields 返回截断后的字段，不修改传入的切片。只检查顶层的字符串、字节与 error 字段，
+// 嵌套对象等其他类型由 max-entry 兜底
+func (c *truncateCore) fields(fields []zapcore.Field) []zapcore.Field {
+	fields = resolveLazy(fields)
+	if c.maxField <= 0 {
+		return fields
+	}
+	var out []zapcore.Field
+	for i, f := range fields {
+		s, ok := c.oversized(f)
+		if !ok {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields)+1)
+			copy(out, fields[:i])
+		}
+		out = append(out, zap.String(f.Key, truncateString(s, c.maxField)), zap.Int(f.Key+"_length", len(s)))
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// oversized 返回超过 maxField 的字段值
+func (c *truncateCore) oversized(f zapcore.Field) (string, bool) {
+	var s string
+	switch f.Type {
+	case zapcore.StringType:
+		s = f.String
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		b, _ := f.Interface.([]byte)
+		if len(b) <= c.maxField {
+			return "", false
+		}
+		s = string(b)
+	case zapcore.StringerType:
+		s = fmt.Sprint(f.Interface)
+	case zapcore.ErrorType:
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			return "", false
+		}
+		s = err.Error()
+	default:
+		return "", false
+	}
+	return s, len(s) > c.maxField
+}
+
+// limitEncoder 限制单条日志编码后的字节数：超出时丢弃本次调用的字段并截断消息，
+// 以 entry_size 字段记录原始大小。With 添加的上下文字段仍然保留
+type limitEncoder struct {
+	zapcore.Encoder
+	max int
+}
+
+func newLimitEncoder(enc zapcore.Encoder, max int) zapcore.Encoder {
+	return &limitEncoder{Encoder: enc, max: max}
+}
+
+func (e *limitEncoder) Clone() zapcore.Encoder {
+	return &limitEncoder{Encoder: e.Encoder.Clone(), max: e.max}
+}
+
+func (e *limitEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil || buf.Len() <= e.max {
+		return buf, err
+	}
+	size := buf.Len()
+	buf.Free()
+	// 消息最多保留上限的一半，给标准键与上下文字段留出空间
+	if limit := e.max / 2; len(ent.Message) > limit {
+		ent.Message = truncateString(ent.Message, limit)
+	}
+	ent.Stack = ""
+	return e.Encoder.EncodeEntry(ent, []zapcore.Field{zap.Int("entry_size", size), zap.Bool("truncated", true)})
+}
@@ -0,0 +1,82 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTruncateString(t *testing.T) {
+	if got := truncateString("abcdef", 3); got != "abc"+truncateMarker {
+		t.Errorf("got %q", got)
+	}
+	// 不拆分多字节字符
+	if got := truncateString("日志内容", 4); got != "日"+truncateMarker {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTruncateCore(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(newTruncateCore(core, 8, 4)).With(zap.String("ctx", "context value"))
+	logger.Info("a very long message",
+		zap.String("body", "0123456789"),
+		zap.Error(errors.New("boom boom")),
+		zap.String("short", "ok"),
+		zap.Int("n", 123456789),
+	)
+
+	ent := logs.AllUntimed()[0]
+	if ent.Message != "a very l"+truncateMarker {
+		t.Errorf("message = %q", ent.Message)
+	}
+	fields := ent.ContextMap()
+	want := map[string]any{
+		"ctx":          "cont" + truncateMarker,
+		"ctx_length":   int64(13),
+		"body":         "0123" + truncateMarker,
+		"body_length":  int64(10),
+		"error":        "boom" + truncateMarker,
+		"error_length": int64(9),
+		"short":        "ok",
+		"n":            int64(123456789),
+		"msg_length":   int64(19),
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
+
+func TestLimitEncoder(t *testing.T) {
+	enc := newLimitEncoder(zapcore.NewJSONEncoder(jsonEncoderConfig()), 200)
+	enc.AddString("service", "api")
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "upload"}
+
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("n", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, `"n":1`) || strings.Contains(got, "truncated") {
+		t.Errorf("small entry changed: %s", got)
+	}
+	buf.Free()
+
+	buf, err = enc.Clone().EncodeEntry(ent, []zapcore.Field{zap.String("payload", strings.Repeat("x", 1000))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	got := buf.String()
+	if buf.Len() > 200 || strings.Contains(got, "payload") {
+		t.Errorf("entry not limited: %s", got)
+	}
+	if !strings.Contains(got, `"truncated":true`) || !strings.Contains(got, `"entry_size":`) || !strings.Contains(got, `"service":"api"`) {
+		t.Errorf("missing markers or context: %s", got)
+	}
+}
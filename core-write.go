@@ -0,0 +1,39 @@
+package log
+
+import (
+	"errors"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// errorCapture 作为 CheckedEntry 的 ErrorOutput，记录写入错误
+type errorCapture struct {
+	err error
+}
+
+func (e *errorCapture) Write(p []byte) (int, error) {
+	if e.err == nil {
+		// CheckedEntry 的格式为 "<时间> write error: <错误>"
+		msg := strings.TrimSpace(string(p))
+		if _, after, ok := strings.Cut(msg, " write error: "); ok {
+			msg = after
+		}
+		e.err = errors.New(msg)
+	}
+	return len(p), nil
+}
+
+func (e *errorCapture) Sync() error { return nil }
+
+// writeChecked 写入被包装 Core 重新 Check 得到的日志，返回经 ErrorOutput 取回的写入错误，
+// 使包装 Core 的调用方（最终是 zap.ErrorOutput）仍能看到被包装 Core 的编码与写入错误
+func writeChecked(ce *zapcore.CheckedEntry, fields []zapcore.Field) error {
+	if ce == nil {
+		return nil
+	}
+	var out errorCapture
+	ce.ErrorOutput = &out
+	ce.Write(fields...)
+	return out.err
+}
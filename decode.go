@@ -0,0 +1,207 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DecodeEntry 将适配器默认 JSON 格式（标准键 ts, level, logger, caller, msg, stacktrace）的一行日志解码为 Entry，
+// 其余字段按原顺序放入 Fields：字符串、布尔与数字还原为对应类型，对象与数组保持原始 JSON。
+// ts 可以是数字（按数值大小识别为秒、毫秒或纳秒）或 RFC3339/ISO8601 字符串
+func DecodeEntry(data []byte) (Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Entry{}, errors.New("log entry is not a JSON object")
+	}
+	var e Entry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Entry{}, err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return Entry{}, fmt.Errorf("decode %s: %w", key, err)
+		}
+		switch key {
+		case "ts":
+			if e.Time, err = decodeTime(raw); err != nil {
+				return Entry{}, err
+			}
+		case "level":
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return Entry{}, fmt.Errorf("decode level: %w", err)
+			}
+			if e.Level, err = zapcore.ParseLevel(s); err != nil {
+				return Entry{}, err
+			}
+		case "logger":
+			_ = json.Unmarshal(raw, &e.LoggerName)
+		case "msg":
+			_ = json.Unmarshal(raw, &e.Message)
+		case "stacktrace":
+			_ = json.Unmarshal(raw, &e.Stack)
+		case "caller":
+			var s string
+			_ = json.Unmarshal(raw, &s)
+			if i := strings.LastIndexByte(s, ':'); i > 0 {
+				n, _ := strconv.Atoi(s[i+1:])
+				e.Caller = zapcore.EntryCaller{Defined: true, File: s[:i], Line: n}
+			}
+		default:
+			e.Fields = append(e.Fields, decodeField(key, raw))
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// decodeTime 解析 ts：数字小于 1e11 视为秒，小于 1e14 视为毫秒，否则为纳秒
+func decodeTime(raw json.RawMessage) (time.Time, error) {
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		f, err := n.Float64()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("decode ts: %w", err)
+		}
+		switch {
+		case f < 1e11:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		case f < 1e14:
+			ms, frac := math.Modf(f)
+			return time.UnixMilli(int64(ms)).Add(time.Duration(frac * 1e6)), nil
+		default:
+			i, _ := n.Int64()
+			return time.Unix(0, i), nil
+		}
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return time.Time{}, fmt.Errorf("decode ts: %w", err)
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("decode ts: unsupported time %q", s)
+}
+
+func decodeField(key string, raw json.RawMessage) zapcore.Field {
+	switch raw[0] {
+	case '"':
+		var s string
+		_ = json.Unmarshal(raw, &s)
+		return zap.String(key, s)
+	case 't', 'f':
+		return zap.Bool(key, raw[0] == 't')
+	case '{', '[', 'n':
+		return zap.Reflect(key, raw)
+	}
+	n := json.Number(raw)
+	if i, err := n.Int64(); err == nil {
+		return zap.Int64(key, i)
+	}
+	f, _ := n.Float64()
+	return zap.Float64(key, f)
+}
+
+// prettyWriter 见 PrettyWriter
+type prettyWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	enc     zapcore.Encoder
+	partial []byte
+}
+
+// PrettyWriter 返回将适配器 JSON 日志转为控制台可读格式写入 out 的 Writer，用于查看滚动后的文件或采集端收到的批次。
+// 按行处理，每行可以是一条日志或 payload=array 的一个批次；无法解码的行原样输出。
+// out 为终端时为级别着色。Close 输出末尾不完整的一行，不关闭 out
+func PrettyWriter(out io.Writer) io.WriteCloser {
+	cfg := consoleEncoderConfig()
+	cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	if f, ok := out.(*os.File); !ok || !autoColor(f) {
+		cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	return &prettyWriter{out: out, enc: zapcore.NewConsoleEncoder(cfg)}
+}
+
+func (w *prettyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data := p
+	if len(w.partial) > 0 {
+		data = append(w.partial, p...)
+		w.partial = nil
+	}
+	for {
+		line, rest, ok := bytes.Cut(data, []byte{'\n'})
+		if !ok {
+			w.partial = append(w.partial, data...)
+			return len(p), nil
+		}
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+		data = rest
+	}
+}
+
+func (w *prettyWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) == 0 {
+		return nil
+	}
+	line := w.partial
+	w.partial = nil
+	return w.writeLine(line)
+}
+
+// writeLine 输出一行：一条日志或日志数组，其中有无法解码的日志时整行原样输出
+func (w *prettyWriter) writeLine(line []byte) error {
+	trimmed := bytes.TrimSpace(line)
+	var raws []json.RawMessage
+	if len(trimmed) == 0 || trimmed[0] != '[' || json.Unmarshal(trimmed, &raws) != nil {
+		raws = []json.RawMessage{trimmed}
+	}
+	entries := make([]Entry, 0, len(raws))
+	for _, raw := range raws {
+		e, err := DecodeEntry(raw)
+		if err != nil {
+			_, err := w.out.Write(append(line, '\n'))
+			return err
+		}
+		entries = append(entries, e)
+	}
+	for _, e := range entries {
+		buf, err := w.enc.EncodeEntry(e.Entry, e.Fields)
+		if err != nil {
+			return err
+		}
+		_, err = w.out.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDecodeEntry(t *testing.T) {
+	ts := time.UnixMilli(1767225600123)
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       ts,
+		LoggerName: "db",
+		Message:    "slow query",
+		Caller:     zapcore.EntryCaller{Defined: true, File: "/src/app/store/query.go", Line: 42},
+		Stack:      "main.main\n\t/src/app/main.go:10",
+	}
+	buf, err := zapcore.NewJSONEncoder(jsonEncoderConfig()).EncodeEntry(ent, []zapcore.Field{
+		zap.String("table", "users"),
+		zap.Int("rows", 3),
+		zap.Float64("ratio", 0.5),
+		zap.Bool("cached", false),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Strings("tags", []string{"a", "b"}),
+		zap.Error(errors.New("timeout")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := DecodeEntry(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Time.Equal(ts) || e.Level != ent.Level || e.LoggerName != "db" || e.Message != ent.Message || e.Stack != ent.Stack {
+		t.Errorf("entry = %+v", e.Entry)
+	}
+	if e.Caller.TrimmedPath() != "store/query.go:42" {
+		t.Errorf("caller = %s", e.Caller.TrimmedPath())
+	}
+	want := []zapcore.Field{
+		zap.String("table", "users"),
+		zap.Int64("rows", 3),
+		zap.Float64("ratio", 0.5),
+		zap.Bool("cached", false),
+		zap.String("elapsed", "1.5s"),
+	}
+	if len(e.Fields) != 7 {
+		t.Fatalf("fields = %v", e.Fields)
+	}
+	for i, f := range want {
+		if !e.Fields[i].Equals(f) {
+			t.Errorf("field %d = %+v, want %+v", i, e.Fields[i], f)
+		}
+	}
+	if e.Fields[5].Key != "tags" || e.Fields[6].Key != "error" {
+		t.Errorf("fields out of order: %v", e.Fields)
+	}
+
+	for _, bad := range []string{"", "not json", `["x"]`, `{"level":"loud"}`, `{"ts":"yesterday"}`} {
+		if _, err := DecodeEntry([]byte(bad)); err == nil {
+			t.Errorf("DecodeEntry(%q) expected error", bad)
+		}
+	}
+}
+
+func TestDecodeTime(t *testing.T) {
+	want := time.Date(2026, 1, 1, 0, 0, 0, 250_000_000, time.UTC)
+	for _, raw := range []string{"1767225600.25", "1767225600250", "1767225600250000000", `"2026-01-01T00:00:00.25Z"`, `"2026-01-01T08:00:00.250+0800"`} {
+		got, err := decodeTime([]byte(raw))
+		if err != nil {
+			t.Errorf("%s: %v", raw, err)
+			continue
+		}
+		if got.Sub(want).Abs() > time.Microsecond {
+			t.Errorf("%s = %v, want %v", raw, got.UTC(), want)
+		}
+	}
+}
+
+func TestPrettyWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := PrettyWriter(&out)
+	input := `{"level":"info","ts":1767225600000,"msg":"hello","user":"alice"}` + "\n" +
+		"plain text line\n" +
+		`[{"level":"error","ts":1767225600000,"msg":"first"},{"level":"debug","ts":1767225600000,"msg":"second","n":1}]` + "\n" +
+		`{"level":"warn","msg":"tail"}`
+	// 按字节写入，验证跨 Write 的行拼接
+	for i := range len(input) {
+		if _, err := w.Write([]byte{input[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Contains(out.String(), "tail") {
+		t.Error("incomplete line written before Close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines:\n%s", len(lines), out.String())
+	}
+	for i, want := range [][]string{
+		{"INFO", "hello", `{"user": "alice"}`},
+		{"plain text line"},
+		{"ERROR", "first"},
+		{"DEBUG", "second", `{"n": 1}`},
+		{"WARN", "tail"},
+	} {
+		for _, s := range want {
+			if !strings.Contains(lines[i], s) {
+				t.Errorf("line %d = %q, missing %q", i, lines[i], s)
+			}
+		}
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Error("colored output for a non-terminal writer")
+	}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diagnosticsInterval 同一来源两条诊断信息之间的最小间隔
+var diagnosticsInterval = time.Minute
+
+// diagnostics 将日志管道自身的故障（适配器创建失败、写入或发送失败、zap 内部错误）限速输出到旁路，
+// 同一来源每个周期最多输出一条，期间被抑制的条数附在下一条之后。nil 表示不输出
+type diagnostics struct {
+	out  io.Writer
+	mu   sync.Mutex
+	last map[string]*diagnosticState
+}
+
+type diagnosticState struct {
+	at         time.Time
+	suppressed int
+}
+
+// newDiagnostics 根据配置创建诊断输出：DiagnosticsWriter 优先，其次 Diagnostics (stderr, stdout, none)
+func newDiagnostics(cfg *Config) (*diagnostics, error) {
+	out := cfg.DiagnosticsWriter
+	if out == nil {
+		switch v := strings.ToLower(strings.TrimSpace(cfg.Diagnostics)); v {
+		case "", "stderr":
+			out = os.Stderr
+		case "stdout":
+			out = os.Stdout
+		case "none", "off":
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("invalid diagnostics output %q", cfg.Diagnostics)
+		}
+	}
+	return &diagnostics{out: out, last: make(map[string]*diagnosticState)}, nil
+}
+
+// report 输出来源 source 的故障，周期内重复的故障只计数
+func (d *diagnostics) report(source string, err error) {
+	if d == nil || err == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	st := d.last[source]
+	if st == nil {
+		st = &diagnosticState{}
+		d.last[source] = st
+	} else if now.Sub(st.at) < diagnosticsInterval {
+		st.suppressed++
+		return
+	}
+	msg := fmt.Sprintf("log: %s: %v", source, err)
+	if st.suppressed > 0 {
+		msg += fmt.Sprintf(" (%d similar messages suppressed)", st.suppressed)
+	}
+	st.at, st.suppressed = now, 0
+	_, _ = io.WriteString(d.out, msg+"\n")
+}
+
+// hook 返回绑定来源的回调，d 为 nil 时返回 nil
+func (d *diagnostics) hook(source string) func(err error) {
+	if d == nil {
+		return nil
+	}
+	return func(err error) { d.report(source, err) }
+}
+
+// Write 作为 zap 的 ErrorOutput，按行限速输出 zap 内部错误（Core 写入失败、Hook 返回错误等）
+func (d *diagnostics) Write(p []byte) (int, error) {
+	if line := bytes.TrimSpace(p); len(line) > 0 {
+		d.report("zap", errors.New(string(line)))
+	}
+	return len(p), nil
+}
+
+func (d *diagnostics) Sync() error { return nil }
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticsThrottle(t *testing.T) {
+	var out bytes.Buffer
+	d, err := newDiagnostics(&Config{DiagnosticsWriter: &out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		d.report("file:///var/log/app.log", errors.New("disk full"))
+	}
+	d.report("https://logs.example.com", errors.New("503"))
+	if got := strings.Count(out.String(), "\n"); got != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", got, out.String())
+	}
+
+	// 周期结束后输出下一条，并附上被抑制的条数
+	d.last["file:///var/log/app.log"].at = time.Now().Add(-diagnosticsInterval)
+	d.report("file:///var/log/app.log", errors.New("disk full"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if want := "log: file:///var/log/app.log: disk full (2 similar messages suppressed)"; lines[2] != want {
+		t.Errorf("got %q, want %q", lines[2], want)
+	}
+}
+
+func TestNewDiagnostics(t *testing.T) {
+	if d, err := newDiagnostics(&Config{Diagnostics: "none"}); d != nil || err != nil {
+		t.Errorf("none: %v, %v", d, err)
+	}
+	if _, err := newDiagnostics(&Config{Diagnostics: "syslog"}); err == nil {
+		t.Error("expected error for unknown output")
+	}
+	// nil 时静默
+	var d *diagnostics
+	d.report("x", errors.New("ignored"))
+	if d.hook("x") != nil {
+		t.Error("expected nil hook")
+	}
+}
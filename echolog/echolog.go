@@ -0,0 +1,97 @@
+// Package echolog 提供接入 mulan-ext/log 的 echo 请求日志与 panic 恢复中间件
+package echolog
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mulan-ext/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger 返回请求日志中间件。请求级 logger（带 request_id、method、path 字段）写入请求 context，
+// 处理函数可通过 log.FromContext(c.Request().Context()) 取得；请求结束后按状态码输出一条访问日志：
+// 5xx 为 error，4xx 为 warn，其余为 info
+func Logger(logger *log.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+			c.SetRequest(req.WithContext(log.NewContext(req.Context(), requestLogger(logger, c))))
+
+			err := next(c)
+			if err != nil {
+				// 交给 echo 的错误处理写出响应，以便记录最终状态码
+				c.Error(err)
+			}
+
+			res := c.Response()
+			fields := []zap.Field{
+				zap.Int("status", res.Status),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("client_ip", c.RealIP()),
+				zap.Int64("bytes", res.Size),
+			}
+			if route := c.Path(); route != "" {
+				fields = append(fields, zap.String("route", route))
+			}
+			if err != nil {
+				fields = append(fields, zap.Error(err))
+			}
+			if ce := log.FromContext(c.Request().Context()).Check(statusLevel(res.Status), "request"); ce != nil {
+				ce.Write(fields...)
+			}
+			return nil
+		}
+	}
+}
+
+// Recovery 返回 panic 恢复中间件，记录 panic 值与调用栈后将其作为错误返回给 echo 的错误处理。
+// 注册在 Logger 之后时，发生 panic 的请求仍会输出状态码为 500 的访问日志
+func Recovery(logger *log.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						panic(r)
+					}
+					requestLogger(logger, c).Error("panic recovered", zap.Any("panic", r), zap.StackSkip("stacktrace", 1))
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// requestLogger 创建带请求字段的 logger
+func requestLogger(logger *log.Logger, c echo.Context) *zap.Logger {
+	req := c.Request()
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("path", req.URL.Path),
+	}
+	id := req.Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	if id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	return logger.Logger.With(fields...)
+}
+
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
@@ -0,0 +1,58 @@
+package echolog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mulan-ext/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &log.Logger{Logger: zap.New(core)}
+
+	e := echo.New()
+	e.Use(Logger(logger), Recovery(logger))
+	e.GET("/users/:id", func(c echo.Context) error {
+		log.FromContext(c.Request().Context()).Debug("loading user")
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/missing", func(c echo.Context) error { return echo.ErrNotFound })
+	e.GET("/boom", func(c echo.Context) error { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(echo.HeaderXRequestID, "r-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+
+	entries := logs.AllUntimed()
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	if got := entries[0].ContextMap()["request_id"]; entries[0].Message != "loading user" || got != "r-1" {
+		t.Errorf("handler entry = %q request_id=%v", entries[0].Message, got)
+	}
+	access := entries[1].ContextMap()
+	if access["status"] != int64(200) || access["route"] != "/users/:id" || access["path"] != "/users/7" {
+		t.Errorf("access fields = %v", access)
+	}
+	if entries[2].Level != zapcore.WarnLevel || entries[2].ContextMap()["status"] != int64(404) {
+		t.Errorf("not found entry = %s %v", entries[2].Level, entries[2].ContextMap())
+	}
+	if entries[3].Message != "panic recovered" || entries[3].ContextMap()["stacktrace"] == "" {
+		t.Errorf("panic entry = %q %v", entries[3].Message, entries[3].ContextMap())
+	}
+	if entries[4].Level != zapcore.ErrorLevel || entries[4].ContextMap()["status"] != int64(500) {
+		t.Errorf("panic access entry = %s %v", entries[4].Level, entries[4].ContextMap())
+	}
+}
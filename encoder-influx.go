@@ -0,0 +1,155 @@
+package log
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var influxPool = buffer.NewPool()
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	influxStringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// influxEncoder 将日志编码为 InfluxDB line protocol 数据点，每条日志一行:
+//
+//	<measurement>,level=<level>[,logger=<name>][,<tag>=<v>...] message="...",count=1i[,<field>=<v>...] <UnixNano>
+//
+// 默认把顶层的数值与布尔字段作为 field，Fields 不为空时只输出列出的字段；
+// Tags 中的字段作为 tag，只适合取值有限的字段（如 service、route）。嵌套对象与数组不输出
+type influxEncoder struct {
+	*zapcore.MapObjectEncoder // With 添加的上下文字段
+	opts                      *InfluxOptions
+}
+
+func newInfluxEncoder(opts *InfluxOptions) *influxEncoder {
+	return &influxEncoder{MapObjectEncoder: zapcore.NewMapObjectEncoder(), opts: opts}
+}
+
+func (e *influxEncoder) Clone() zapcore.Encoder {
+	clone := newInfluxEncoder(e.opts)
+	maps.Copy(clone.Fields, e.Fields)
+	return clone
+}
+
+func (e *influxEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	values := e.Clone().(*influxEncoder)
+	for _, f := range fields {
+		f.AddTo(values)
+	}
+
+	buf := influxPool.Get()
+	buf.AppendString(influxMeasurementEscaper.Replace(e.opts.Measurement))
+	buf.AppendString(",level=")
+	buf.AppendString(ent.Level.String())
+	if ent.LoggerName != "" {
+		buf.AppendString(",logger=")
+		buf.AppendString(influxKeyEscaper.Replace(ent.LoggerName))
+	}
+	for _, key := range e.opts.Tags {
+		if v, ok := influxTagValue(values.Fields[key]); ok {
+			buf.AppendByte(',')
+			buf.AppendString(influxKeyEscaper.Replace(key))
+			buf.AppendByte('=')
+			buf.AppendString(influxKeyEscaper.Replace(v))
+		}
+	}
+
+	buf.AppendString(` message="`)
+	buf.AppendString(influxStringEscaper.Replace(ent.Message))
+	buf.AppendString(`",count=1i`)
+	keys := e.opts.Fields
+	if len(keys) == 0 {
+		keys = slices.Sorted(maps.Keys(values.Fields))
+	}
+	for _, key := range keys {
+		if key == "message" || key == "count" || slices.Contains(e.opts.Tags, key) {
+			continue
+		}
+		v, ok := influxFieldValue(values.Fields[key], len(e.opts.Fields) > 0)
+		if !ok {
+			continue
+		}
+		buf.AppendByte(',')
+		buf.AppendString(influxKeyEscaper.Replace(key))
+		buf.AppendByte('=')
+		buf.AppendString(v)
+	}
+	buf.AppendByte(' ')
+	buf.AppendInt(ent.Time.UnixNano())
+	buf.AppendByte('\n')
+	return buf, nil
+}
+
+// influxFieldValue 将字段值转换为 line protocol 的 field 值，默认只接受数值与布尔值，
+// selected 为 true (字段由 fields 参数列出) 时字符串等其他值以字符串输出
+func influxFieldValue(v any, selected bool) (string, bool) {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v) + "i", true
+	case int64:
+		return strconv.FormatInt(v, 10) + "i", true
+	case int32:
+		return strconv.FormatInt(int64(v), 10) + "i", true
+	case int16:
+		return strconv.FormatInt(int64(v), 10) + "i", true
+	case int8:
+		return strconv.FormatInt(int64(v), 10) + "i", true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10) + "u", true
+	case uint64:
+		return strconv.FormatUint(v, 10) + "u", true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10) + "u", true
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10) + "u", true
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10) + "u", true
+	case uintptr:
+		return strconv.FormatUint(uint64(v), 10) + "u", true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case time.Duration:
+		// 与 JSON 编码不同，时长以纳秒整数输出，便于聚合
+		return strconv.FormatInt(int64(v), 10) + "i", true
+	case nil, map[string]any, []any:
+		return "", false
+	}
+	if !selected {
+		return "", false
+	}
+	return `"` + influxStringEscaper.Replace(fmt.Sprint(v)) + `"`, true
+}
+
+// influxTagValue 将字段值转换为 tag 值，空值与嵌套对象不作为 tag
+func influxTagValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil, map[string]any, []any:
+		return "", false
+	case string:
+		return v, v != ""
+	default:
+		s := fmt.Sprint(v)
+		return s, s != ""
+	}
+}
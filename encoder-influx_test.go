@@ -0,0 +1,43 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInfluxEncoder(t *testing.T) {
+	ts := time.Unix(1700000000, 123)
+	enc := newInfluxEncoder(&InfluxOptions{Measurement: "app logs", Tags: []string{"service", "route"}})
+	with := enc.Clone()
+	zap.String("service", "api,v2").AddTo(with)
+	zap.Int("shard", 3).AddTo(with)
+
+	buf, err := with.EncodeEntry(zapcore.Entry{Level: zapcore.WarnLevel, LoggerName: "http", Message: `slow "GET"` + "\nretry", Time: ts}, []zapcore.Field{
+		zap.Duration("latency", 1500*time.Millisecond),
+		zap.Float64("ratio", 0.5),
+		zap.Uint64("bytes", 42),
+		zap.Bool("cached", false),
+		zap.String("user", "bob"),
+		zap.Any("meta", map[string]any{"a": 1}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `app\ logs,level=warn,logger=http,service=api\,v2 message="slow \"GET\"\nretry",count=1i,bytes=42u,cached=false,latency=1500000000i,ratio=0.5,shard=3i 1700000000000000123` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("line =\n%s\nwant\n%s", got, want)
+	}
+
+	// fields 列出时只输出这些字段，字符串字段以字符串输出
+	enc = newInfluxEncoder(&InfluxOptions{Measurement: "logs", Fields: []string{"user", "latency", "missing"}})
+	buf, _ = enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "done", Time: ts}, []zapcore.Field{
+		zap.String("user", "bob"), zap.Duration("latency", time.Millisecond), zap.Int("n", 1),
+	})
+	want = `logs,level=info message="done",count=1i,user="bob",latency=1000000i 1700000000000000123` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("line =\n%s\nwant\n%s", got, want)
+	}
+}
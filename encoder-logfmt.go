@@ -0,0 +1,267 @@
+package log
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder 输出 logfmt 格式: ts=... level=info msg="..." key=val
+// 嵌套对象展开为 a.b=val，数组与反射值以 JSON 字符串输出
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf       *buffer.Buffer
+	namespace string
+}
+
+// NewLogfmtEncoder 创建 logfmt 编码器，时间、级别等格式沿用 EncoderConfig
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{EncoderConfig: &cfg, buf: logfmtPool.Get()}
+}
+
+func logfmtEncoderConfig() zapcore.EncoderConfig {
+	cfg := jsonEncoderConfig()
+	cfg.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	return cfg
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{EncoderConfig: e.EncoderConfig, buf: logfmtPool.Get(), namespace: e.namespace}
+	_, _ = clone.buf.Write(e.buf.Bytes())
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{EncoderConfig: e.EncoderConfig, buf: logfmtPool.Get()}
+	if e.TimeKey != "" && e.EncodeTime != nil {
+		final.addPrimitive(e.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeTime(ent.Time, enc) })
+	}
+	if e.LevelKey != "" && e.EncodeLevel != nil {
+		final.addPrimitive(e.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeLevel(ent.Level, enc) })
+	}
+	if ent.LoggerName != "" && e.NameKey != "" {
+		nameEncoder := e.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = zapcore.FullNameEncoder
+		}
+		final.addPrimitive(e.NameKey, func(enc zapcore.PrimitiveArrayEncoder) { nameEncoder(ent.LoggerName, enc) })
+	}
+	if ent.Caller.Defined && e.CallerKey != "" && e.EncodeCaller != nil {
+		final.addPrimitive(e.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeCaller(ent.Caller, enc) })
+	}
+	if e.FunctionKey != "" && ent.Caller.Function != "" {
+		final.AddString(e.FunctionKey, ent.Caller.Function)
+	}
+	if e.MessageKey != "" {
+		final.AddString(e.MessageKey, ent.Message)
+	}
+	// With 添加的上下文字段
+	if e.buf.Len() > 0 {
+		final.sep()
+		_, _ = final.buf.Write(e.buf.Bytes())
+	}
+	final.namespace = e.namespace
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	if ent.Stack != "" && e.StacktraceKey != "" {
+		final.namespace = ""
+		final.AddString(e.StacktraceKey, ent.Stack)
+	}
+	final.buf.AppendString(cmp.Or(e.LineEnding, zapcore.DefaultLineEnding))
+	return final.buf, nil
+}
+
+// sep 在字段之间写入空格
+func (e *logfmtEncoder) sep() {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+}
+
+// key 写入带命名空间前缀的键名，键中的空白、等号与引号替换为下划线
+func (e *logfmtEncoder) key(k string) {
+	e.sep()
+	e.buf.AppendString(strings.Map(sanitizeKeyRune, e.namespace+k))
+	e.buf.AppendByte('=')
+}
+
+func sanitizeKeyRune(r rune) rune {
+	if r <= ' ' || r == '=' || r == '"' {
+		return '_'
+	}
+	return r
+}
+
+// value 写入值，必要时加引号转义
+func (e *logfmtEncoder) value(v string) {
+	if needsQuote(v) {
+		e.buf.AppendString(strconv.Quote(v))
+		return
+	}
+	e.buf.AppendString(v)
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// addPrimitive 通过 EncoderConfig 中的编码函数生成值
+func (e *logfmtEncoder) addPrimitive(k string, fn func(zapcore.PrimitiveArrayEncoder)) {
+	e.key(k)
+	e.value(encodePrimitive(fn))
+}
+
+// encodePrimitive 调用 EncoderConfig 中的编码函数并返回字符串，多个值以逗号连接
+func encodePrimitive(fn func(zapcore.PrimitiveArrayEncoder)) string {
+	var arr logfmtPrimitives
+	fn(&arr)
+	return strings.Join(arr, ",")
+}
+
+func (e *logfmtEncoder) addJSON(k string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.key(k)
+	e.value(string(data))
+	return nil
+}
+
+func (e *logfmtEncoder) AddArray(k string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(k, arr); err != nil {
+		return err
+	}
+	return e.addJSON(k, m.Fields[k])
+}
+
+func (e *logfmtEncoder) AddObject(k string, obj zapcore.ObjectMarshaler) error {
+	prev := e.namespace
+	e.namespace = prev + k + "."
+	defer func() { e.namespace = prev }()
+	return obj.MarshalLogObject(e)
+}
+
+func (e *logfmtEncoder) AddBinary(k string, v []byte) {
+	e.AddString(k, base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *logfmtEncoder) AddByteString(k string, v []byte) { e.AddString(k, string(v)) }
+func (e *logfmtEncoder) AddBool(k string, v bool)         { e.key(k); e.buf.AppendBool(v) }
+
+func (e *logfmtEncoder) AddComplex128(k string, v complex128) {
+	e.AddString(k, strconv.FormatComplex(v, 'g', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(k string, v complex64) {
+	e.AddString(k, strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(k string, v time.Duration) {
+	if e.EncodeDuration == nil {
+		e.AddString(k, v.String())
+		return
+	}
+	e.addPrimitive(k, func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeDuration(v, enc) })
+}
+
+func (e *logfmtEncoder) AddFloat64(k string, v float64) { e.key(k); e.appendFloat(v, 64) }
+func (e *logfmtEncoder) AddFloat32(k string, v float32) { e.key(k); e.appendFloat(float64(v), 32) }
+
+func (e *logfmtEncoder) appendFloat(v float64, bitSize int) {
+	switch {
+	case math.IsNaN(v):
+		e.buf.AppendString("NaN")
+	case math.IsInf(v, 1):
+		e.buf.AppendString("+Inf")
+	case math.IsInf(v, -1):
+		e.buf.AppendString("-Inf")
+	default:
+		e.buf.AppendFloat(v, bitSize)
+	}
+}
+
+func (e *logfmtEncoder) AddInt(k string, v int)       { e.AddInt64(k, int64(v)) }
+func (e *logfmtEncoder) AddInt64(k string, v int64)   { e.key(k); e.buf.AppendInt(v) }
+func (e *logfmtEncoder) AddInt32(k string, v int32)   { e.AddInt64(k, int64(v)) }
+func (e *logfmtEncoder) AddInt16(k string, v int16)   { e.AddInt64(k, int64(v)) }
+func (e *logfmtEncoder) AddInt8(k string, v int8)     { e.AddInt64(k, int64(v)) }
+func (e *logfmtEncoder) AddString(k, v string)        { e.key(k); e.value(v) }
+func (e *logfmtEncoder) AddUint(k string, v uint)     { e.AddUint64(k, uint64(v)) }
+func (e *logfmtEncoder) AddUint64(k string, v uint64) { e.key(k); e.buf.AppendUint(v) }
+func (e *logfmtEncoder) AddUint32(k string, v uint32) { e.AddUint64(k, uint64(v)) }
+func (e *logfmtEncoder) AddUint16(k string, v uint16) { e.AddUint64(k, uint64(v)) }
+func (e *logfmtEncoder) AddUint8(k string, v uint8)   { e.AddUint64(k, uint64(v)) }
+func (e *logfmtEncoder) AddUintptr(k string, v uintptr) {
+	e.AddUint64(k, uint64(v))
+}
+
+func (e *logfmtEncoder) AddTime(k string, v time.Time) {
+	if e.EncodeTime == nil {
+		e.AddString(k, v.Format(time.RFC3339Nano))
+		return
+	}
+	e.addPrimitive(k, func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeTime(v, enc) })
+}
+
+func (e *logfmtEncoder) AddReflected(k string, v any) error {
+	if s, ok := v.(string); ok {
+		e.AddString(k, s)
+		return nil
+	}
+	return e.addJSON(k, v)
+}
+
+func (e *logfmtEncoder) OpenNamespace(k string) {
+	e.namespace += k + "."
+}
+
+// logfmtPrimitives 收集 EncoderConfig 编码函数输出的值
+type logfmtPrimitives []string
+
+func (a *logfmtPrimitives) AppendBool(v bool)         { *a = append(*a, strconv.FormatBool(v)) }
+func (a *logfmtPrimitives) AppendByteString(v []byte) { *a = append(*a, string(v)) }
+func (a *logfmtPrimitives) AppendComplex128(v complex128) {
+	*a = append(*a, strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (a *logfmtPrimitives) AppendComplex64(v complex64) { a.AppendComplex128(complex128(v)) }
+func (a *logfmtPrimitives) AppendFloat64(v float64) {
+	*a = append(*a, strconv.FormatFloat(v, 'g', -1, 64))
+}
+func (a *logfmtPrimitives) AppendFloat32(v float32) {
+	*a = append(*a, strconv.FormatFloat(float64(v), 'g', -1, 32))
+}
+func (a *logfmtPrimitives) AppendInt(v int)                { a.AppendInt64(int64(v)) }
+func (a *logfmtPrimitives) AppendInt64(v int64)            { *a = append(*a, strconv.FormatInt(v, 10)) }
+func (a *logfmtPrimitives) AppendInt32(v int32)            { a.AppendInt64(int64(v)) }
+func (a *logfmtPrimitives) AppendInt16(v int16)            { a.AppendInt64(int64(v)) }
+func (a *logfmtPrimitives) AppendInt8(v int8)              { a.AppendInt64(int64(v)) }
+func (a *logfmtPrimitives) AppendString(v string)          { *a = append(*a, v) }
+func (a *logfmtPrimitives) AppendUint(v uint)              { a.AppendUint64(uint64(v)) }
+func (a *logfmtPrimitives) AppendUint64(v uint64)          { *a = append(*a, strconv.FormatUint(v, 10)) }
+func (a *logfmtPrimitives) AppendUint32(v uint32)          { a.AppendUint64(uint64(v)) }
+func (a *logfmtPrimitives) AppendUint16(v uint16)          { a.AppendUint64(uint64(v)) }
+func (a *logfmtPrimitives) AppendUint8(v uint8)            { a.AppendUint64(uint64(v)) }
+func (a *logfmtPrimitives) AppendUintptr(v uintptr)        { a.AppendUint64(uint64(v)) }
+func (a *logfmtPrimitives) AppendDuration(v time.Duration) { *a = append(*a, v.String()) }
+func (a *logfmtPrimitives) AppendTime(v time.Time)         { *a = append(*a, v.Format(time.RFC3339Nano)) }
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logfmtUser struct{ name string }
+
+func (u logfmtUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return nil
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	enc := NewLogfmtEncoder(logfmtEncoderConfig())
+	enc.AddString("service", "api")
+	ent := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		Message: "request done",
+	}
+
+	tests := []struct {
+		name  string
+		field zap.Field
+		want  string
+	}{
+		{"plain string", zap.String("path", "/users"), "path=/users"},
+		{"quoted string", zap.String("query", `a=1 "b"`), `query="a=1 \"b\""`},
+		{"empty string", zap.String("empty", ""), `empty=""`},
+		{"int", zap.Int("status", 200), "status=200"},
+		{"bool", zap.Bool("cached", true), "cached=true"},
+		{"duration", zap.Duration("took", 1500*time.Millisecond), "took=1.5s"},
+		{"error", zap.Error(errors.New("boom failed")), `error="boom failed"`},
+		{"object", zap.Object("user", logfmtUser{name: "bob"}), "user.name=bob"},
+		{"array", zap.Strings("tags", []string{"a", "b"}), `tags="[\"a\",\"b\"]"`},
+		{"key sanitized", zap.String("bad key", "v"), "bad_key=v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(ent, []zapcore.Field{tt.field})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			buf.Free()
+			prefix := `ts=2024-05-01T08:30:00Z level=info msg="request done" service=api `
+			if want := prefix + tt.want + "\n"; got != want {
+				t.Errorf("got  %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLogfmtEncoderNamespace(t *testing.T) {
+	enc := NewLogfmtEncoder(logfmtEncoderConfig())
+	enc.OpenNamespace("req")
+	enc.AddString("id", "1")
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "ns"}, []zapcore.Field{zap.Int("size", 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	if got := buf.String(); !strings.HasSuffix(got, "msg=ns req.id=1 req.size=3\n") {
+		t.Errorf("got %q", got)
+	}
+}
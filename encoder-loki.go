@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var lokiPool = buffer.NewPool()
+
+// lokiEncoder 将内层编码器输出的日志行包装为 Loki push API 的一个 stream:
+//
+//	{"stream":{"env":"prod","level":"info"},"values":[["<UnixNano>","<日志行>"]]}
+//
+// 每条日志一个 stream，Loki 按标签合并，配合 payload=envelope (键名 streams) 发送
+type lokiEncoder struct {
+	zapcore.Encoder
+	labels []byte // 静态标签，已编码为 "k":"v",
+}
+
+func newLokiEncoder(inner zapcore.Encoder, labels map[string]string) *lokiEncoder {
+	var b []byte
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		b = appendJSONString(b, name)
+		b = append(b, ':')
+		b = appendJSONString(b, labels[name])
+		b = append(b, ',')
+	}
+	return &lokiEncoder{Encoder: inner, labels: b}
+}
+
+func (e *lokiEncoder) Clone() zapcore.Encoder {
+	return &lokiEncoder{Encoder: e.Encoder.Clone(), labels: e.labels}
+}
+
+func (e *lokiEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer line.Free()
+	buf := lokiPool.Get()
+	buf.AppendString(`{"stream":{`)
+	buf.Write(e.labels)
+	buf.AppendString(`"level":"`)
+	buf.AppendString(ent.Level.String())
+	buf.AppendString(`"},"values":[["`)
+	buf.AppendInt(ent.Time.UnixNano())
+	buf.AppendString(`",`)
+	buf.Write(appendJSONString(nil, string(bytes.TrimRight(line.Bytes(), "\n"))))
+	buf.AppendString("]]}\n")
+	return buf, nil
+}
+
+// appendJSONString 将 s 编码为 JSON 字符串追加到 b，无效的 UTF-8 替换为 U+FFFD
+func appendJSONString(b []byte, s string) []byte {
+	quoted, _ := json.Marshal(s)
+	return append(b, quoted...)
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLokiEncoder(t *testing.T) {
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey = ""
+	enc := newLokiEncoder(zapcore.NewJSONEncoder(cfg), map[string]string{"service": "api", "env": `prod"1`})
+	with := enc.Clone()
+	zap.String("request_id", "r1").AddTo(with)
+
+	buf, err := with.EncodeEntry(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: time.Unix(1700000000, 5)}, []zapcore.Field{zap.Int("attempt", 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &stream); err != nil {
+		t.Fatalf("%s: %v", buf, err)
+	}
+	if len(stream.Stream) != 3 || stream.Stream["env"] != `prod"1` || stream.Stream["service"] != "api" || stream.Stream["level"] != "error" {
+		t.Errorf("labels = %v", stream.Stream)
+	}
+	if len(stream.Values) != 1 || stream.Values[0][0] != "1700000000000000005" ||
+		stream.Values[0][1] != `{"level":"error","msg":"db down","request_id":"r1","attempt":3}` {
+		t.Errorf("values = %q", stream.Values)
+	}
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	ansiKey   = "\x1b[34m" // 键名使用蓝色
+	ansiReset = "\x1b[0m"
+)
+
+// prettyJSONEncoder 输出缩进的多行 JSON，color 为 true 时为键名着色，供本地开发使用
+type prettyJSONEncoder struct {
+	zapcore.Encoder
+	color bool
+}
+
+// NewPrettyJSONEncoder 创建缩进 JSON 编码器
+func NewPrettyJSONEncoder(cfg zapcore.EncoderConfig, color bool) zapcore.Encoder {
+	return &prettyJSONEncoder{Encoder: zapcore.NewJSONEncoder(cfg), color: color}
+}
+
+func (e *prettyJSONEncoder) Clone() zapcore.Encoder {
+	return &prettyJSONEncoder{Encoder: e.Encoder.Clone(), color: e.color}
+}
+
+func (e *prettyJSONEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	raw, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.Free()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimRight(raw.Bytes(), "\r\n"), "", "  "); err != nil {
+		return nil, err
+	}
+	out := logfmtPool.Get()
+	if e.color {
+		colorizeKeys(out, indented.Bytes())
+	} else {
+		_, _ = out.Write(indented.Bytes())
+	}
+	out.AppendByte('\n')
+	return out, nil
+}
+
+// colorizeKeys 为 JSON 中的键名（后跟冒号的字符串）加上颜色
+func colorizeKeys(out *buffer.Buffer, data []byte) {
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			out.AppendByte(data[i])
+			i++
+			continue
+		}
+		// 找到字符串结尾，跳过转义字符
+		end := i + 1
+		for end < len(data) && data[end] != '"' {
+			if data[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		end = min(end+1, len(data))
+		if end < len(data) && data[end] == ':' {
+			out.AppendString(ansiKey)
+			_, _ = out.Write(data[i:end])
+			out.AppendString(ansiReset)
+		} else {
+			_, _ = out.Write(data[i:end])
+		}
+		i = end
+	}
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPrettyJSONEncoder(t *testing.T) {
+	cfg := jsonEncoderConfig()
+	cfg.TimeKey = ""
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: `say "hi":`}
+	fields := []zapcore.Field{zap.String("user", "bob")}
+
+	buf, err := NewPrettyJSONEncoder(cfg, false).EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"level\": \"info\",\n  \"msg\": \"say \\\"hi\\\":\",\n  \"user\": \"bob\"\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	buf.Free()
+
+	buf, err = NewPrettyJSONEncoder(cfg, true).EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	want = "{\n  " + ansiKey + `"level"` + ansiReset + ": \"info\",\n  " +
+		ansiKey + `"msg"` + ansiReset + ": \"say \\\"hi\\\":\",\n  " +
+		ansiKey + `"user"` + ansiReset + ": \"bob\"\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
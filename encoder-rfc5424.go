@@ -0,0 +1,226 @@
+package log
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// syslog facility 名称与编号
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// RFC5424Options RFC5424 编码器选项，零值使用默认值
+type RFC5424Options struct {
+	Facility int    // syslog facility 编号，默认 1 (user)
+	Hostname string // HOSTNAME，默认 os.Hostname()
+	AppName  string // APP-NAME，默认使用 logger 名称，没有时为程序名
+	SDID     string // 承载日志字段的 SD-ID，默认 fields@32473
+
+	Location *time.Location // TIMESTAMP 的时区，默认本地时间
+}
+
+// rfc5424Encoder 按 RFC5424 输出: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID k="v"...] MSG
+// 日志字段（嵌套对象以点号展开）按键名排序后写入结构化数据
+type rfc5424Encoder struct {
+	*zapcore.MapObjectEncoder
+	cfg    *zapcore.EncoderConfig
+	opts   RFC5424Options
+	procID string
+}
+
+// NewRFC5424Encoder 创建 RFC5424 编码器
+func NewRFC5424Encoder(cfg zapcore.EncoderConfig, opts RFC5424Options) zapcore.Encoder {
+	if opts.Facility == 0 {
+		opts.Facility = syslogFacilities["user"]
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	opts.SDID = cmp.Or(opts.SDID, "fields@32473")
+	return &rfc5424Encoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              &cfg,
+		opts:             opts,
+		procID:           strconv.Itoa(os.Getpid()),
+	}
+}
+
+// parseSyslogFacility 解析 facility 名称或编号
+func parseSyslogFacility(s string) (int, error) {
+	if n, ok := syslogFacilities[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 23 {
+		return 0, fmt.Errorf("invalid syslog facility: %s", s)
+	}
+	return n, nil
+}
+
+// syslogSeverity 将 zap 级别映射为 syslog severity
+func syslogSeverity(lvl zapcore.Level) int {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel:
+		return 2
+	case zapcore.PanicLevel:
+		return 1
+	case zapcore.FatalLevel:
+		return 0
+	default:
+		return 5
+	}
+}
+
+func (e *rfc5424Encoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	maps.Copy(clone.Fields, e.Fields)
+	return &rfc5424Encoder{MapObjectEncoder: clone, cfg: e.cfg, opts: e.opts, procID: e.procID}
+}
+
+func (e *rfc5424Encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*rfc5424Encoder)
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	if ent.Caller.Defined && e.cfg.CallerKey != "" {
+		final.AddString(e.cfg.CallerKey, ent.Caller.TrimmedPath())
+	}
+	if ent.Stack != "" && e.cfg.StacktraceKey != "" {
+		final.AddString(e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	buf := logfmtPool.Get()
+	buf.AppendByte('<')
+	buf.AppendInt(int64(e.opts.Facility*8 + syslogSeverity(ent.Level)))
+	buf.AppendString(">1 ")
+	if ent.Time.IsZero() {
+		buf.AppendByte('-')
+	} else {
+		t := ent.Time
+		if e.opts.Location != nil {
+			t = t.In(e.opts.Location)
+		}
+		buf.AppendString(t.Format("2006-01-02T15:04:05.000000Z07:00"))
+	}
+	appName := e.opts.AppName
+	if appName == "" {
+		appName = cmp.Or(ent.LoggerName, filepath.Base(os.Args[0]))
+	}
+	for _, v := range []struct {
+		s     string
+		limit int
+	}{{e.opts.Hostname, 255}, {appName, 48}, {e.procID, 128}, {"-", 32}} {
+		buf.AppendByte(' ')
+		buf.AppendString(syslogHeaderField(v.s, v.limit))
+	}
+	buf.AppendByte(' ')
+	final.appendStructuredData(buf)
+	if ent.Message != "" {
+		buf.AppendByte(' ')
+		buf.AppendString(ent.Message)
+	}
+	buf.AppendString(cmp.Or(e.cfg.LineEnding, zapcore.DefaultLineEnding))
+	return buf, nil
+}
+
+// appendStructuredData 写入结构化数据，没有字段时为 "-"
+func (e *rfc5424Encoder) appendStructuredData(buf *buffer.Buffer) {
+	params := make(map[string]string)
+	flattenSDParams(params, "", e.Fields)
+	if len(params) == 0 {
+		buf.AppendByte('-')
+		return
+	}
+	buf.AppendByte('[')
+	buf.AppendString(e.opts.SDID)
+	for _, k := range slices.Sorted(maps.Keys(params)) {
+		buf.AppendByte(' ')
+		buf.AppendString(k)
+		buf.AppendString(`="`)
+		buf.AppendString(sdEscaper.Replace(params[k]))
+		buf.AppendByte('"')
+	}
+	buf.AppendByte(']')
+}
+
+// sdEscaper 转义 PARAM-VALUE 中的 '"'、'\' 与 ']'
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// flattenSDParams 将字段展开为 PARAM-NAME -> 字符串值，嵌套对象以点号连接
+func flattenSDParams(params map[string]string, prefix string, fields map[string]any) {
+	for k, v := range fields {
+		name := sdParamName(prefix + k)
+		switch v := v.(type) {
+		case map[string]any:
+			flattenSDParams(params, name+".", v)
+		case string:
+			params[name] = v
+		case time.Time:
+			params[name] = v.Format(time.RFC3339Nano)
+		case time.Duration:
+			params[name] = v.String()
+		case fmt.Stringer:
+			params[name] = v.String()
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				data = []byte(fmt.Sprint(v))
+			}
+			params[name] = string(data)
+		}
+	}
+}
+
+// sdParamName PARAM-NAME 只能包含除 '='、空格、']'、'"' 外的可打印 ASCII，最长 32 字符
+func sdParamName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// syslogHeaderField 头部字段只能包含可打印 ASCII，空值写为 "-"
+func syslogHeaderField(s string, limit int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRFC5424Encoder(t *testing.T) {
+	enc := NewRFC5424Encoder(jsonEncoderConfig(), RFC5424Options{
+		Facility: syslogFacilities["local0"],
+		Hostname: "web-1",
+		AppName:  "shop",
+	})
+	enc.AddString("request_id", "r-1")
+	ent := zapcore.Entry{
+		Level:   zapcore.ErrorLevel,
+		Time:    time.Date(2024, 5, 1, 8, 30, 0, 123456000, time.UTC),
+		Message: "payment failed",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.Int("amount", 42),
+		zap.String("reason", `card "declined"]`),
+		zap.Object("user", logfmtUser{name: "bob"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+
+	pid := strconv.Itoa(os.Getpid())
+	want := `<131>1 2024-05-01T08:30:00.123456Z web-1 shop ` + pid +
+		` - [fields@32473 amount="42" reason="card \"declined\"\]" request_id="r-1" user.name="bob"] payment failed` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestRFC5424EncoderNoFields(t *testing.T) {
+	enc := NewRFC5424Encoder(jsonEncoderConfig(), RFC5424Options{Hostname: "web-1"})
+	buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, LoggerName: "api", Message: "ok"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	want := "<14>1 - web-1 api " + strconv.Itoa(os.Getpid()) + " - - ok\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseSyslogFacility(t *testing.T) {
+	for input, want := range map[string]int{"local0": 16, "USER": 1, "23": 23} {
+		if got, err := parseSyslogFacility(input); err != nil || got != want {
+			t.Errorf("parseSyslogFacility(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"24", "local9", ""} {
+		if _, err := parseSyslogFacility(input); err == nil {
+			t.Errorf("parseSyslogFacility(%q) expected error", input)
+		}
+	}
+}
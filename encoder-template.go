@@ -0,0 +1,94 @@
+package log
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultTemplate 未指定模板时使用的行格式
+const DefaultTemplate = "{{.Time}} [{{.Level}}] {{.Logger}}: {{.Message}} {{.Fields}}"
+
+// TemplateEntry 模板可用的字段，均已按 EncoderConfig 格式化为字符串
+type TemplateEntry struct {
+	Time    string
+	Level   string
+	Logger  string
+	Caller  string
+	Message string
+	Fields  string // logfmt 格式的上下文与日志字段
+	Stack   string
+}
+
+// templateEncoder 按 text/template 输出纯文本行，字段部分复用 logfmt 编码
+type templateEncoder struct {
+	*logfmtEncoder
+	tmpl *template.Template
+}
+
+// NewTemplateEncoder 创建模板编码器，每行末尾自动补换行
+func NewTemplateEncoder(cfg zapcore.EncoderConfig, text string) (zapcore.Encoder, error) {
+	tmpl, err := template.New("log").Option("missingkey=error").Parse(cmp.Or(text, DefaultTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &templateEncoder{
+		logfmtEncoder: &logfmtEncoder{EncoderConfig: &cfg, buf: logfmtPool.Get()},
+		tmpl:          tmpl,
+	}, nil
+}
+
+func templateEncoderConfig() zapcore.EncoderConfig {
+	cfg := logfmtEncoderConfig()
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	return cfg
+}
+
+func (e *templateEncoder) Clone() zapcore.Encoder {
+	return &templateEncoder{logfmtEncoder: e.logfmtEncoder.Clone().(*logfmtEncoder), tmpl: e.tmpl}
+}
+
+func (e *templateEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	// 仅编码字段部分
+	fieldEnc := e.logfmtEncoder.Clone().(*logfmtEncoder)
+	for _, f := range fields {
+		f.AddTo(fieldEnc)
+	}
+	data := TemplateEntry{
+		Logger:  ent.LoggerName,
+		Message: ent.Message,
+		Fields:  fieldEnc.buf.String(),
+		Stack:   ent.Stack,
+	}
+	fieldEnc.buf.Free()
+	if e.EncodeTime != nil {
+		data.Time = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeTime(ent.Time, enc) })
+	}
+	if e.EncodeLevel != nil {
+		data.Level = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeLevel(ent.Level, enc) })
+	}
+	if ent.Caller.Defined && e.EncodeCaller != nil {
+		data.Caller = encodePrimitive(func(enc zapcore.PrimitiveArrayEncoder) { e.EncodeCaller(ent.Caller, enc) })
+	}
+
+	var line bytes.Buffer
+	if err := e.tmpl.Execute(&line, data); err != nil {
+		return nil, fmt.Errorf("execute log template: %w", err)
+	}
+	out := logfmtPool.Get()
+	_, _ = out.Write(bytes.TrimRight(line.Bytes(), " "))
+	if data.Stack != "" && !strings.Contains(e.tmpl.Root.String(), ".Stack") {
+		out.AppendByte('\n')
+		out.AppendString(data.Stack)
+	}
+	lineEnding := cmp.Or(e.LineEnding, zapcore.DefaultLineEnding)
+	if !bytes.HasSuffix(out.Bytes(), []byte(lineEnding)) {
+		out.AppendString(lineEnding)
+	}
+	return out, nil
+}
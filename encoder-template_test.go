@@ -0,0 +1,70 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTemplateEncoder(t *testing.T) {
+	ent := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		LoggerName: "api",
+		Message:    "slow request",
+	}
+	tests := []struct {
+		name   string
+		text   string
+		fields []zapcore.Field
+		stack  string
+		want   string
+	}{
+		{"default", "", []zapcore.Field{zap.Int("ms", 1200)}, "", "2024-05-01T08:30:00Z [WARN] api: slow request ms=1200\n"},
+		{"no fields", "", nil, "", "2024-05-01T08:30:00Z [WARN] api: slow request\n"},
+		{"custom layout", "{{.Level}}|{{.Message}}|{{.Fields}}", []zapcore.Field{zap.String("path", "/a b")}, "", "WARN|slow request|path=\"/a b\"\n"},
+		{"stack appended", "{{.Message}}", nil, "main.go:1", "slow request\nmain.go:1\n"},
+		{"stack in template", "{{.Message}} {{.Stack}}", nil, "main.go:1", "slow request main.go:1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := NewTemplateEncoder(templateEncoderConfig(), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := ent
+			e.Stack = tt.stack
+			buf, err := enc.EncodeEntry(e, tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer buf.Free()
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewTemplateEncoder(templateEncoderConfig(), "{{.Message"); err == nil {
+		t.Error("expected error for malformed template")
+	}
+}
+
+func TestTemplateEncoderWith(t *testing.T) {
+	enc, err := NewTemplateEncoder(templateEncoderConfig(), "{{.Message}} {{.Fields}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.AddString("service", "api")
+	buf, err := enc.Clone().EncodeEntry(zapcore.Entry{Message: "hi"}, []zapcore.Field{zap.Int("n", 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	if got, want := buf.String(), "hi service=api n=1\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
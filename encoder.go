@@ -0,0 +1,60 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]func(zapcore.EncoderConfig) zapcore.Encoder{}
+)
+
+// inLocation 返回先将时间转换到 loc 再编码的 TimeEncoder，loc 为 nil 时原样返回
+func inLocation(enc zapcore.TimeEncoder, loc *time.Location) zapcore.TimeEncoder {
+	if enc == nil || loc == nil {
+		return enc
+	}
+	return func(t time.Time, pae zapcore.PrimitiveArrayEncoder) { enc(t.In(loc), pae) }
+}
+
+// builtinFormats 内置的编码格式，不允许被注册覆盖
+var builtinFormats = map[string]bool{"json": true, "json-pretty": true, "logfmt": true, "template": true, "rfc5424": true}
+
+// RegisterEncoder 注册自定义编码器，之后可在 DSN 中通过 format=<name> 使用，
+// 构建函数收到与 JSON 适配器相同的 EncoderConfig。
+// 名称为空、与内置格式冲突或重复注册时 panic，应在 init 或创建 Logger 之前调用
+func RegisterEncoder(name string, build func(zapcore.EncoderConfig) zapcore.Encoder) {
+	if name == "" || build == nil {
+		panic("log: RegisterEncoder requires a name and a build function")
+	}
+	if builtinFormats[name] {
+		panic(fmt.Sprintf("log: encoder %q is built in", name))
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if _, ok := encoders[name]; ok {
+		panic(fmt.Sprintf("log: encoder %q already registered", name))
+	}
+	encoders[name] = build
+}
+
+// lookupEncoder 查找已注册的自定义编码器
+func lookupEncoder(name string) (func(zapcore.EncoderConfig) zapcore.Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	build, ok := encoders[name]
+	return build, ok
+}
+
+// validFormat 判断编码格式是内置的或已注册
+func validFormat(name string) bool {
+	if builtinFormats[name] {
+		return true
+	}
+	_, ok := lookupEncoder(name)
+	return ok
+}
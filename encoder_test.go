@@ -0,0 +1,72 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// registerTestEncoder 注册测试用编码器，测试结束时注销，使测试可以重复运行
+func registerTestEncoder(t *testing.T, name string, build func(zapcore.EncoderConfig) zapcore.Encoder) {
+	t.Helper()
+	RegisterEncoder(name, build)
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		delete(encoders, name)
+		encodersMu.Unlock()
+	})
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	registerTestEncoder(t, "test-message", func(cfg zapcore.EncoderConfig) zapcore.Encoder {
+		cfg.MessageKey = "message"
+		return zapcore.NewJSONEncoder(cfg)
+	})
+
+	logFile := filepath.Join(t.TempDir(), "custom.log")
+	logger, err := NewWithConfig(&Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?format=test-message"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.L().Info("custom encoded")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `"message":"custom encoded"`) {
+		t.Errorf("expected custom message key, got %q", content)
+	}
+
+	if _, err := parseCoreOptions("file:///var/log/app.log?format=unregistered"); err == nil {
+		t.Error("expected error for unregistered format")
+	}
+}
+
+func TestRegisterEncoderPanics(t *testing.T) {
+	build := func(cfg zapcore.EncoderConfig) zapcore.Encoder { return zapcore.NewJSONEncoder(cfg) }
+	registerTestEncoder(t, "test-dup", build)
+	for name, fn := range map[string]func(){
+		"builtin":   func() { RegisterEncoder("json", build) },
+		"duplicate": func() { RegisterEncoder("test-dup", build) },
+		"empty":     func() { RegisterEncoder("", build) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// applyEnv 返回应用环境变量覆盖后的配置副本，环境变量优先于 Config，未设置或为空的变量不覆盖。
+// 变量名为 Config.EnvPrefix (默认 LOG_) 加 LEVEL、CONSOLE_LEVEL、FORMAT、ADAPTORS，
+// ADAPTORS 为空白分隔的 DSN 列表（DSN 中可能含有逗号），替换全部适配器
+func applyEnv(cfg *Config) (*Config, error) {
+	prefix := cmp.Or(cfg.EnvPrefix, "LOG_")
+	if prefix == "-" {
+		return cfg, nil
+	}
+	c := *cfg
+	for _, v := range []struct {
+		name  string
+		level bool
+		dst   *string
+	}{
+		{"LEVEL", true, &c.Level},
+		{"CONSOLE_LEVEL", true, &c.ConsoleLevel},
+		{"FORMAT", false, &c.Format},
+	} {
+		name := prefix + v.name
+		val := strings.TrimSpace(os.Getenv(name))
+		if val == "" {
+			continue
+		}
+		if v.level {
+			if _, err := zapcore.ParseLevel(val); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+		*v.dst = val
+	}
+	if val := strings.Fields(os.Getenv(prefix + "ADAPTORS")); len(val) > 0 {
+		c.Adaptors = val
+	}
+	return &c, nil
+}
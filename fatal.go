@@ -0,0 +1,51 @@
+package log
+
+import (
+	"cmp"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// exit 进程退出函数，测试中替换
+var exit = os.Exit
+
+// fatalHook Fatal 日志写出后的处理：默认关闭全部适配器（确保缓冲的日志写出）后以 FatalExitCode 退出
+type fatalHook struct {
+	cfg    *Config
+	logger *Logger
+}
+
+func (h *fatalHook) OnWrite(ce *zapcore.CheckedEntry, _ []zapcore.Field) {
+	if h.cfg.FatalAsError {
+		return
+	}
+	if h.cfg.FatalNoExit {
+		// 进程继续运行，只同步不关闭适配器
+		_ = h.logger.Sync()
+	} else {
+		_ = h.logger.Close()
+	}
+	if h.cfg.OnFatal != nil {
+		h.cfg.OnFatal(ce.Entry)
+	}
+	if !h.cfg.FatalNoExit {
+		exit(cmp.Or(h.cfg.FatalExitCode, 1))
+	}
+}
+
+// fatalAsErrorCore 将 Fatal 日志按 Error 级别输出
+type fatalAsErrorCore struct {
+	zapcore.Core
+}
+
+func (c fatalAsErrorCore) With(fields []zapcore.Field) zapcore.Core {
+	return fatalAsErrorCore{c.Core.With(fields)}
+}
+
+func (c fatalAsErrorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level == zapcore.FatalLevel {
+		ent.Level = zapcore.ErrorLevel
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFatalHook(t *testing.T) {
+	var code int
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+	restore := zap.ReplaceGlobals(zap.NewNop())
+	defer restore()
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantCode int
+		wantHook bool
+		level    string
+	}{
+		{"default exits after close", Config{}, 1, false, `"level":"fatal"`},
+		{"custom code and hook", Config{FatalExitCode: 3}, 3, true, `"level":"fatal"`},
+		{"no exit", Config{FatalNoExit: true}, 0, true, `"level":"fatal"`},
+		{"as error", Config{FatalAsError: true}, 0, false, `"level":"error"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code = 0
+			logFile := filepath.Join(t.TempDir(), "fatal.log")
+			cfg := tt.cfg
+			cfg.Level = "info"
+			cfg.ConsoleOutput = "stderr"
+			cfg.Adaptors = []string{"file://" + logFile}
+			var hooked zapcore.Entry
+			if tt.wantHook {
+				cfg.OnFatal = func(ent zapcore.Entry) { hooked = ent }
+			}
+			logger, err := NewWithConfig(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logger.Close()
+
+			logger.Fatal("unrecoverable")
+
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantHook && hooked.Message != "unrecoverable" {
+				t.Errorf("OnFatal entry = %+v", hooked)
+			}
+			// 退出前适配器已关闭或同步，日志已写入文件
+			data, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.level) {
+				t.Errorf("log file = %s, want %s", data, tt.level)
+			}
+		})
+	}
+}
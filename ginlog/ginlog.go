@@ -0,0 +1,84 @@
+// Package ginlog 提供接入 mulan-ext/log 的 gin 请求日志与 panic 恢复中间件
+package ginlog
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mulan-ext/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDHeader 读取请求 ID 的请求头
+const RequestIDHeader = "X-Request-Id"
+
+// Logger 返回请求日志中间件。请求级 logger（带 request_id、method、path 字段）写入请求 context，
+// 处理函数可通过 log.FromContext(c.Request.Context()) 取得；请求结束后按状态码输出一条访问日志：
+// 5xx 为 error，4xx 为 warn，其余为 info
+func Logger(logger *log.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Request = c.Request.WithContext(log.NewContext(c.Request.Context(), requestLogger(logger, c)))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("bytes", max(c.Writer.Size(), 0)),
+		}
+		if route := c.FullPath(); route != "" {
+			fields = append(fields, zap.String("route", route))
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+		if ce := log.FromContext(c.Request.Context()).Check(statusLevel(status), "request"); ce != nil {
+			ce.Write(fields...)
+		}
+	}
+}
+
+// Recovery 返回 panic 恢复中间件，记录 panic 值与调用栈后以 500 结束请求。
+// 注册在 Logger 之后时，发生 panic 的请求仍会输出状态码为 500 的访问日志
+func Recovery(logger *log.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				requestLogger(logger, c).Error("panic recovered", zap.Any("panic", r), zap.StackSkip("stacktrace", 1))
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// requestLogger 创建带请求字段的 logger
+func requestLogger(logger *log.Logger, c *gin.Context) *zap.Logger {
+	fields := []zap.Field{
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+	}
+	if id := c.GetHeader(RequestIDHeader); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	return logger.Logger.With(fields...)
+}
+
+func statusLevel(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
@@ -0,0 +1,55 @@
+package ginlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mulan-ext/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &log.Logger{Logger: zap.New(core)}
+
+	r := gin.New()
+	r.Use(Logger(logger), Recovery(logger))
+	r.GET("/users/:id", func(c *gin.Context) {
+		log.FromContext(c.Request.Context()).Debug("loading user")
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/boom", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(RequestIDHeader, "r-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+
+	entries := logs.AllUntimed()
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+	if got := entries[0].ContextMap()["request_id"]; entries[0].Message != "loading user" || got != "r-1" {
+		t.Errorf("handler entry = %q request_id=%v", entries[0].Message, got)
+	}
+	access := entries[1].ContextMap()
+	if access["status"] != int64(200) || access["route"] != "/users/:id" || access["path"] != "/users/7" {
+		t.Errorf("access fields = %v", access)
+	}
+	if entries[2].Message != "panic recovered" || entries[2].ContextMap()["stacktrace"] == "" {
+		t.Errorf("panic entry = %q %v", entries[2].Message, entries[2].ContextMap())
+	}
+	if entries[3].Level != zapcore.ErrorLevel || entries[3].ContextMap()["status"] != int64(500) {
+		t.Errorf("panic access entry = %s %v", entries[3].Level, entries[3].ContextMap())
+	}
+}
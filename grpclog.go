@@ -0,0 +1,52 @@
+package log
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// GRPCLogger 实现 google.golang.org/grpc/grpclog 的 LoggerV2 与 DepthLoggerV2 接口，
+// 通过 grpclog.SetLoggerV2(log.NewGRPCLogger(logger)) 将 gRPC 内部日志转入当前 Logger。
+// 为避免引入 gRPC 依赖，这里按方法集实现接口而不直接引用 grpclog 包
+type GRPCLogger struct {
+	logger *zap.Logger
+	sugar  *zap.SugaredLogger
+}
+
+// NewGRPCLogger 创建 gRPC logger。gRPC 的 verbosity 映射到 zap 级别：
+// V(0) 对应 info，V(1) 及以上对应 debug，即开启 debug 级别时才输出 gRPC 的详细日志
+func NewGRPCLogger(logger *Logger) *GRPCLogger {
+	l := logger.Logger.Named("grpc")
+	return &GRPCLogger{logger: l, sugar: l.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+func (g *GRPCLogger) Info(args ...any)                    { g.sugar.Info(args...) }
+func (g *GRPCLogger) Infoln(args ...any)                  { g.sugar.Infoln(args...) }
+func (g *GRPCLogger) Infof(format string, args ...any)    { g.sugar.Infof(format, args...) }
+func (g *GRPCLogger) Warning(args ...any)                 { g.sugar.Warn(args...) }
+func (g *GRPCLogger) Warningln(args ...any)               { g.sugar.Warnln(args...) }
+func (g *GRPCLogger) Warningf(format string, args ...any) { g.sugar.Warnf(format, args...) }
+func (g *GRPCLogger) Error(args ...any)                   { g.sugar.Error(args...) }
+func (g *GRPCLogger) Errorln(args ...any)                 { g.sugar.Errorln(args...) }
+func (g *GRPCLogger) Errorf(format string, args ...any)   { g.sugar.Errorf(format, args...) }
+func (g *GRPCLogger) Fatal(args ...any)                   { g.sugar.Fatal(args...) }
+func (g *GRPCLogger) Fatalln(args ...any)                 { g.sugar.Fatalln(args...) }
+func (g *GRPCLogger) Fatalf(format string, args ...any)   { g.sugar.Fatalf(format, args...) }
+
+// V 报告 gRPC 的 verbosity 级别 l 是否输出
+func (g *GRPCLogger) V(l int) bool {
+	if l <= 0 {
+		return g.logger.Core().Enabled(zapcore.InfoLevel)
+	}
+	return g.logger.Core().Enabled(zapcore.DebugLevel)
+}
+
+// InfoDepth 等 Depth 方法由 gRPC 调用，depth 为相对 gRPC 调用方的额外栈深度
+func (g *GRPCLogger) InfoDepth(depth int, args ...any)    { g.depth(depth).Info(args...) }
+func (g *GRPCLogger) WarningDepth(depth int, args ...any) { g.depth(depth).Warn(args...) }
+func (g *GRPCLogger) ErrorDepth(depth int, args ...any)   { g.depth(depth).Error(args...) }
+func (g *GRPCLogger) FatalDepth(depth int, args ...any)   { g.depth(depth).Fatal(args...) }
+
+func (g *GRPCLogger) depth(depth int) *zap.SugaredLogger {
+	return g.logger.WithOptions(zap.AddCallerSkip(depth + 1)).Sugar()
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGRPCLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	g := NewGRPCLogger(&Logger{Logger: zap.New(core, zap.AddCaller())})
+
+	if !g.V(0) || g.V(2) {
+		t.Errorf("V(0)=%v V(2)=%v, want true false at info level", g.V(0), g.V(2))
+	}
+
+	g.Infof("channel %d created", 1)
+	g.Warningln("transport", "closing")
+	g.ErrorDepth(0, "handshake failed")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	want := []struct {
+		level zapcore.Level
+		msg   string
+	}{
+		{zapcore.InfoLevel, "channel 1 created"},
+		{zapcore.WarnLevel, "transport closing"},
+		{zapcore.ErrorLevel, "handshake failed"},
+	}
+	for i, ent := range entries {
+		if ent.Level != want[i].level || ent.Message != want[i].msg || ent.LoggerName != "grpc" {
+			t.Errorf("entry %d = %s %s %q", i, ent.LoggerName, ent.Level, ent.Message)
+		}
+		if file := filepath.Base(ent.Caller.File); file != "grpclog_test.go" {
+			t.Errorf("entry %d caller = %s, want grpclog_test.go", i, ent.Caller)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 就绪检查的水位线：缓冲区或磁盘队列使用超过该比例时视为未就绪
+const readinessWatermark = 0.9
+
+// Healthy 检查各适配器最近一次写入或发送是否成功：最近一次失败晚于最近一次成功的适配器视为不健康，
+// 返回包含适配器名称与失败原因的错误，全部健康时返回 nil。可用于应用的健康检查接口
+func (l *Logger) Healthy() error {
+	var errs []error
+	for _, st := range l.Stats() {
+		if err := st.healthy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Readiness 在 Healthy 的基础上检查背压：HTTP 缓冲区或磁盘队列使用超过 90% 时视为未就绪，
+// 此时新日志很可能被丢弃或阻塞写入
+func (l *Logger) Readiness() error {
+	var errs []error
+	for _, st := range l.Stats() {
+		if err := st.healthy(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if st.QueueSize > 0 && float64(st.Queued) >= float64(st.QueueSize)*readinessWatermark {
+			errs = append(errs, fmt.Errorf("%s: buffer almost full (%d/%d)", st.Name, st.Queued, st.QueueSize))
+		}
+		if st.SpoolMax > 0 && float64(st.SpoolBytes) >= float64(st.SpoolMax)*readinessWatermark {
+			errs = append(errs, fmt.Errorf("%s: spool almost full (%d/%d bytes)", st.Name, st.SpoolBytes, st.SpoolMax))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (st *AdaptorStats) healthy() error {
+	if st.LastError == "" || st.LastSuccess.After(st.LastErrorAt) {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", st.Name, st.LastError)
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeReporter 返回固定运行状态的适配器资源
+type fakeReporter AdaptorStats
+
+func (f fakeReporter) Close() error { return nil }
+
+func (f fakeReporter) stats(st *AdaptorStats) {
+	name := st.Name
+	*st = AdaptorStats(f)
+	st.Name = name
+}
+
+func TestLoggerHealth(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		stats     AdaptorStats
+		healthy   string // 期望错误包含的内容，空表示 nil
+		readiness string
+	}{
+		{"idle", AdaptorStats{}, "", ""},
+		{"ok", AdaptorStats{LastSuccess: now, QueueSize: 100, Queued: 10}, "", ""},
+		{"recovered", AdaptorStats{LastError: "timeout", LastErrorAt: now.Add(-time.Minute), LastSuccess: now}, "", ""},
+		{"failing", AdaptorStats{LastError: "HTTP error: 503", LastErrorAt: now, LastSuccess: now.Add(-time.Minute)}, "http: HTTP error: 503", "http: HTTP error: 503"},
+		{"buffer full", AdaptorStats{LastSuccess: now, QueueSize: 100, Queued: 95}, "", "buffer almost full (95/100)"},
+		{"spool full", AdaptorStats{LastSuccess: now, SpoolBytes: 950, SpoolMax: 1000}, "", "spool almost full"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Logger{adaptors: []*adaptor{{name: "http", closer: fakeReporter(tt.stats)}}}
+			check := func(what string, err error, want string) {
+				if want == "" {
+					if err != nil {
+						t.Errorf("%s() = %v, want nil", what, err)
+					}
+				} else if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("%s() = %v, want %q", what, err, want)
+				}
+			}
+			check("Healthy", l.Healthy(), tt.healthy)
+			check("Readiness", l.Readiness(), tt.readiness)
+		})
+	}
+}
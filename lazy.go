@@ -0,0 +1,82 @@
+package log
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Lazy 返回延迟求值的字段：fn 只在日志确实写出时调用（至少一个适配器接受该日志），
+// 同一条日志写入多个适配器时只调用一次，用于计算代价较高的调试字段。
+// 在 With 中使用时，配置了脱敏、截断或字段筛选的适配器会在创建子 logger 时求值
+func Lazy(fn func() zap.Field) zap.Field {
+	return zap.Inline(&lazyField{fn: fn})
+}
+
+// lazyField 以内联对象的形式编码求值后的字段
+type lazyField struct {
+	once  sync.Once
+	fn    func() zap.Field
+	field zap.Field
+}
+
+func (f *lazyField) resolve() zap.Field {
+	f.once.Do(func() { f.field = f.fn() })
+	return f.field
+}
+
+func (f *lazyField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	f.resolve().AddTo(enc)
+	return nil
+}
+
+// resolveLazy 返回将 Lazy 字段替换为求值结果的字段，不修改传入的切片，
+// 使按键名与值处理字段的 Core 看到实际的字段
+func resolveLazy(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		lazy, ok := f.Interface.(*lazyField)
+		if !ok || f.Type != zapcore.InlineMarshalerType {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, lazy.resolve())
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// LogFn 在 logger 启用 lvl 级别时才调用 fn 生成消息与字段，可用于 FromContext 取出的 logger
+func LogFn(logger *zap.Logger, lvl zapcore.Level, fn func() (msg string, fields []zap.Field)) {
+	logFn(logger, lvl, fn)
+}
+
+// DebugFn 在 Debug 级别启用时才调用 fn 生成消息与字段
+func (l *Logger) DebugFn(fn func() (msg string, fields []zap.Field)) {
+	logFn(l.Logger, zapcore.DebugLevel, fn)
+}
+
+// LogFn 在 lvl 级别启用时才调用 fn 生成消息与字段
+func (l *Logger) LogFn(lvl zapcore.Level, fn func() (msg string, fields []zap.Field)) {
+	logFn(l.Logger, lvl, fn)
+}
+
+// logFn 只能由导出的包装函数直接调用，调用位置跳过 logFn 与包装函数两层
+func logFn(logger *zap.Logger, lvl zapcore.Level, fn func() (string, []zap.Field)) {
+	if !logger.Core().Enabled(lvl) {
+		return
+	}
+	msg, fields := fn()
+	if ce := logger.WithOptions(zap.AddCallerSkip(2)).Check(lvl, msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
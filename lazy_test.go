@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	lazy := func() zap.Field {
+		return Lazy(func() zap.Field {
+			calls++
+			return zap.String("dump", "expensive")
+		})
+	}
+
+	// 级别未启用时不求值
+	info, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(info)
+	logger.Debug("skipped", lazy())
+	if calls != 0 || logs.Len() != 0 {
+		t.Fatalf("calls = %d, logged = %d", calls, logs.Len())
+	}
+
+	// 写入多个 Core 时只求值一次
+	var a, b bytes.Buffer
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	logger = zap.New(zapcore.NewTee(
+		zapcore.NewCore(enc, zapcore.AddSync(&a), zap.DebugLevel),
+		zapcore.NewCore(enc.Clone(), zapcore.AddSync(&b), zap.DebugLevel),
+	))
+	logger.Debug("written", lazy())
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	for _, out := range []string{a.String(), b.String()} {
+		if !strings.Contains(out, `"dump":"expensive"`) {
+			t.Errorf("output = %s", out)
+		}
+	}
+}
+
+func TestLazyProcessedFields(t *testing.T) {
+	r, err := newRedactor("(?i)password", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(newRedactCore(newFieldFilterCore(core, nil, []string{"internal"}), r))
+	logger.Info("login",
+		Lazy(func() zap.Field { return zap.String("password", "hunter2") }),
+		Lazy(func() zap.Field { return zap.String("internal", "x") }),
+		Lazy(func() zap.Field { return zap.Int("attempt", 1) }),
+	)
+	fields := logs.AllUntimed()[0].ContextMap()
+	if len(fields) != 2 || fields["password"] != redactMask || fields["attempt"] != int64(1) {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestLoggerDebugFn(t *testing.T) {
+	logger, logs := NewTestLogger(t)
+	calls := 0
+	fn := func() (string, []zap.Field) {
+		calls++
+		return "state", []zap.Field{zap.Int("n", calls)}
+	}
+	logger.DebugFn(fn)
+	logs.AssertLogged(zapcore.DebugLevel, "state")
+	if entry := logs.All()[0]; !strings.HasSuffix(entry.Caller.File, "lazy_test.go") {
+		t.Errorf("caller = %s", entry.Caller)
+	}
+
+	// 级别未启用时不调用 fn
+	core, observed := observer.New(zap.InfoLevel)
+	LogFn(zap.New(core), zapcore.DebugLevel, fn)
+	(&Logger{Logger: zap.New(core)}).DebugFn(fn)
+	if calls != 1 || observed.Len() != 0 {
+		t.Errorf("calls = %d, logged = %d", calls, observed.Len())
+	}
+	LogFn(zap.New(core, zap.AddCaller()), zapcore.WarnLevel, fn)
+	if entry := observed.All()[0]; entry.Level != zapcore.WarnLevel || !strings.HasSuffix(entry.Caller.File, "lazy_test.go") {
+		t.Errorf("entry = %+v", entry)
+	}
+}
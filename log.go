@@ -1,10 +1,18 @@
 package log
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -13,29 +21,180 @@ import (
 // Logger 包装 zap.Logger，提供清理功能
 type Logger struct {
 	*zap.Logger
-	closers []io.Closer
+	closers  []io.Closer
+	adaptors []*adaptor
+	// MakeGlobal 时应用的全局设置
+	levelOverrides map[string]zapcore.Level
+	spanEvents     bool
+	// stopSignal 取消 Config.CloseOnSignal 的信号监听，关闭时调用
+	stopSignal func()
+	// slog 桥接不经过 zap.Logger 的选项，由 slogHandler 自行应用
+	stacktrace    zapcore.LevelEnabler // nil 表示不输出调用栈
+	disableCaller bool
 }
 
 type MultiHandler struct {
-	cores   []zapcore.Core
-	closers []io.Closer
+	cores    []zapcore.Core
+	closers  multiCloser
+	adaptors []*adaptor
+}
+
+// adaptor 单个输出适配器及其统计来源
+type adaptor struct {
+	name     string // 脱敏后的 DSN
+	alias    string // 路由规则中的名称，见 CoreOptions.Name
+	core     zapcore.Core
+	closer   io.Closer
+	counters []dropCounter
+	probe    bool // DSN 参数 probe
+}
+
+// prober 可探测远端是否可达的适配器资源，见 Config.Probe
+type prober interface {
+	Probe(ctx context.Context) error
+}
+
+// dropCounter 可统计丢弃日志条数的组件
+type dropCounter interface {
+	Dropped() uint64
+}
+
+// levelRange 仅允许 [min, max] 区间内的级别
+type levelRange struct {
+	min zapcore.Level
+	max zapcore.Level
+}
+
+func (r levelRange) Enabled(lvl zapcore.Level) bool { return lvl >= r.min && lvl <= r.max }
+
+// levelTee 与 zapcore.NewTee 相同，但 Write 只写入启用了该级别的 Core，
+// 这样外层包装 Core 直接调用 Write 时，各 Core 的级别仍然生效
+type levelTee []zapcore.Core
+
+func (t levelTee) Enabled(lvl zapcore.Level) bool {
+	for _, c := range t {
+		if c.Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t levelTee) With(fields []zapcore.Field) zapcore.Core {
+	clone := make(levelTee, len(t))
+	for i, c := range t {
+		clone[i] = c.With(fields)
+	}
+	return clone
+}
+
+func (t levelTee) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	for _, c := range t {
+		ce = c.Check(ent, ce)
+	}
+	return ce
+}
+
+func (t levelTee) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var errs []error
+	for _, c := range t {
+		if c.Enabled(ent.Level) {
+			errs = append(errs, c.Write(ent, fields))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t levelTee) Sync() error {
+	var errs []error
+	for _, c := range t {
+		errs = append(errs, c.Sync())
+	}
+	return errors.Join(errs...)
 }
 
 type resolvedConfig struct {
 	level        zapcore.Level
 	consoleLevel zapcore.Level
 	format       string
+	console      consoleOptions
+	stacktrace   zapcore.LevelEnabler // nil 表示不输出调用栈
+	encodeCaller zapcore.CallerEncoder
+	overrides    map[string]zapcore.Level
+	redactor     *redactor
+	// 适配器截断选项的默认值
+	maxMessage, maxField, maxEntry int
+	fileBuffer                     int            // 文件适配器写缓冲的默认值
+	location                       *time.Location // 时间戳的时区，nil 表示本地时间
+	aggregateInterval              time.Duration  // Config.Aggregate 的汇总周期
+	diag                           *diagnostics   // nil 表示不输出诊断信息
+}
+
+// consoleOptions 控制台 Core 的输出选项
+type consoleOptions struct {
+	output       *os.File
+	encodeTime   zapcore.TimeEncoder
+	color        bool
+	hideCaller   bool
+	encodeCaller zapcore.CallerEncoder
+	location     *time.Location
 }
 
-// Close 关闭所有资源
+// Close 关闭所有资源，HTTP 适配器按 drain-timeout 排空缓冲区，见 CloseContext
 func (l *Logger) Close() error {
-	var firstErr error
-	for _, closer := range l.closers {
-		if err := closer.Close(); err != nil && firstErr == nil {
-			firstErr = err
+	_, err := l.CloseContext(context.Background())
+	return err
+}
+
+// Dropped 返回各适配器丢弃的日志条数，键为脱敏后的 DSN
+func (l *Logger) Dropped() map[string]uint64 {
+	dropped := make(map[string]uint64, len(l.adaptors))
+	for _, a := range l.adaptors {
+		var n uint64
+		for _, c := range a.counters {
+			n += c.Dropped()
 		}
+		dropped[a.name] += n
+	}
+	return dropped
+}
+
+// With 返回附加字段的 Logger，与原 Logger 共享适配器资源，仍可调用 Close 与 Dropped
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	child := *l
+	child.Logger = l.Logger.With(fields...)
+	return &child
+}
+
+// WithValues 以键值对形式附加字段，规则同 zap.SugaredLogger.With
+func (l *Logger) WithValues(kv ...any) *Logger {
+	child := *l
+	child.Logger = l.Logger.Sugar().With(kv...).Desugar()
+	return &child
+}
+
+// AddHook 返回注册了 Hook 的 Logger，每条实际写出的日志都会调用 Hook，
+// Hook 返回的错误写入 zap 的 ErrorOutput，不影响日志本身
+func (l *Logger) AddHook(hooks ...func(zapcore.Entry) error) *Logger {
+	child := *l
+	child.Logger = l.Logger.WithOptions(zap.Hooks(hooks...))
+	return &child
+}
+
+// MakeGlobal 将当前 Logger 设为 zap 全局 logger，并应用 LevelOverrides 与 TraceSpanEvents，
+// 返回恢复之前全局 logger 的函数
+func (l *Logger) MakeGlobal() func() {
+	for name, lvl := range l.levelOverrides {
+		setNamedLevel(name, lvl)
+	}
+	prevSpanEvents := spanEvents.Swap(l.spanEvents)
+	prevGlobal := global.Swap(l)
+	restore := zap.ReplaceGlobals(l.Logger)
+	return func() {
+		restore()
+		spanEvents.Store(prevSpanEvents)
+		global.Store(prevGlobal)
 	}
-	return firstErr
 }
 
 // New 创建日志实例（简化版）
@@ -48,34 +207,128 @@ func NewWithConfig(cfg *Config, name ...string) (*Logger, error) {
 	if cfg == nil {
 		cfg = &Config{Level: "info"}
 	}
-
+	cfg, err := applyEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
 	resolved, err := resolveConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
-
 	handler, err := newMultiHandler(cfg, resolved)
 	if err != nil {
 		return nil, err
 	}
 
-	zapLogger := zap.New(
-		zapcore.NewTee(handler.cores...),
-		zap.AddCaller(),
-		zap.AddStacktrace(zapcore.ErrorLevel),
-	)
+	core := wrapCore(cfg, resolved, zapcore.NewTee(handler.cores...))
+	if cfg.FatalAsError {
+		core = fatalAsErrorCore{core}
+	}
+	hook := &fatalHook{cfg: cfg}
+	opts := []zap.Option{zap.WithCaller(!cfg.DisableCaller), zap.WithFatalHook(hook), zap.ErrorOutput(resolved.diag)}
+	if resolved.stacktrace != nil {
+		opts = append(opts, zap.AddStacktrace(resolved.stacktrace))
+	}
+	if len(cfg.Hooks) > 0 {
+		opts = append(opts, zap.Hooks(cfg.Hooks...))
+	}
+	zapLogger := zap.New(core, opts...)
 	zapLogger = zapLogger.WithOptions(zap.AddCallerSkip(cfg.Skip))
 	if len(name) > 0 {
 		zapLogger = zapLogger.Named(name[0]).With(zap.String("service", name[0]))
 	}
-	zap.ReplaceGlobals(zapLogger)
-	return &Logger{Logger: zapLogger, closers: handler.closers}, nil
+	hook.logger = &Logger{
+		Logger:         zapLogger,
+		closers:        handler.closers,
+		adaptors:       handler.adaptors,
+		levelOverrides: resolved.overrides,
+		spanEvents:     cfg.TraceSpanEvents,
+		stacktrace:     resolved.stacktrace,
+		disableCaller:  cfg.DisableCaller,
+	}
+	if !cfg.NoGlobal {
+		hook.logger.MakeGlobal()
+	}
+	if cfg.CloseOnSignal {
+		hook.logger.stopSignal = hook.logger.CloseOnSignal(0)
+	}
+	return hook.logger, nil
+}
+
+// NewCore 根据配置创建控制台与全部适配器组成的 Core，不创建 Logger、不修改全局 logger，
+// 供自行构建 zap.Logger 的程序复用 DSN 适配器。返回的 Closer 先同步 Core 再关闭适配器资源。
+// 与 Logger 相关的选项不生效：Skip、DisableCaller、StacktraceLevel、Hooks、CloseOnSignal、NoGlobal，
+// 以及依赖 Fatal 钩子的 FatalAsError、FatalNoExit、FatalExitCode、OnFatal
+func NewCore(cfg *Config) (zapcore.Core, io.Closer, error) {
+	if cfg == nil {
+		cfg = &Config{Level: "info"}
+	}
+	cfg, err := applyEnv(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := resolveConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := newMultiHandler(cfg, resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	core := wrapCore(cfg, resolved, zapcore.NewTee(handler.cores...))
+	return core, coreCloser{core: core, closers: handler.closers}, nil
+}
+
+// wrapCore 按配置包装控制台与适配器组成的 Core，NewWithConfig 与 NewCore 共用，顺序即处理顺序（由内到外）。
+// FatalAsError 与 Fatal 钩子配合使用，只由 NewWithConfig 在此之外添加
+func wrapCore(cfg *Config, resolved resolvedConfig, core zapcore.Core) zapcore.Core {
+	if cfg.ExpandErrors {
+		core = newErrorChainCore(core)
+	}
+	if len(cfg.Processors) > 0 {
+		core = newProcessorCore(core, cfg.Processors)
+	}
+	if cfg.FlightRecorder > 0 {
+		core = newFlightCore(core, cfg.FlightRecorder)
+	}
+	if cfg.Sampling != nil {
+		core = newSampler(core, cfg.Sampling)
+	}
+	if cfg.Aggregate != nil && len(cfg.Aggregate.Messages) > 0 {
+		core = newAggregateCore(core, cfg.Aggregate.Messages, resolved.aggregateInterval, resolved.diag.hook("aggregate"))
+	}
+	if cfg.Sequence {
+		core = newSequenceCore(core)
+	}
+	return core
+}
+
+// newSampler 按 Config.Sampling 以 1 秒为周期采样
+func newSampler(core zapcore.Core, cfg *zap.SamplingConfig) zapcore.Core {
+	var opts []zapcore.SamplerOption
+	if cfg.Hook != nil {
+		opts = append(opts, zapcore.SamplerHook(cfg.Hook))
+	}
+	return zapcore.NewSamplerWithOptions(core, time.Second, cfg.Initial, cfg.Thereafter, opts...)
+}
+
+// coreCloser 关闭前先同步 Core，确保包装 Core 中缓存的日志写出
+type coreCloser struct {
+	core    zapcore.Core
+	closers multiCloser
+}
+
+func (c coreCloser) Close() error {
+	_ = c.core.Sync()
+	return c.closers.Close()
 }
 
 func resolveConfig(cfg *Config) (resolvedConfig, error) {
 	mode := strings.ToLower(strings.TrimSpace(cfg.Mode))
 	format := strings.ToLower(strings.TrimSpace(cfg.Format))
 	defaultLevel := zapcore.InfoLevel
+	// 收集全部问题一并返回，便于 Validate 一次报告
+	var errs []error
 
 	switch mode {
 	case "", "server", "prod", "production":
@@ -88,7 +341,7 @@ func resolveConfig(cfg *Config) (resolvedConfig, error) {
 			format = "console"
 		}
 	default:
-		return resolvedConfig{}, fmt.Errorf("invalid log mode %q", cfg.Mode)
+		errs = append(errs, fmt.Errorf("invalid log mode %q", cfg.Mode))
 	}
 
 	if cfg.JSON && format == "" {
@@ -97,27 +350,163 @@ func resolveConfig(cfg *Config) (resolvedConfig, error) {
 	if format == "" {
 		format = "console"
 	}
-	if format != "console" && format != "json" {
-		return resolvedConfig{}, fmt.Errorf("invalid log format %q", cfg.Format)
+	if format != "console" && format != "json" && format != "json-pretty" && format != "logfmt" {
+		errs = append(errs, fmt.Errorf("invalid log format %q", cfg.Format))
 	}
 
 	level, err := parseLevelOrDefault(cfg.Level, defaultLevel)
 	if err != nil {
-		return resolvedConfig{}, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+		errs = append(errs, fmt.Errorf("invalid log level %q: %w", cfg.Level, err))
 	}
 
 	consoleLevel, err := parseLevelOrDefault(cfg.ConsoleLevel, level)
 	if err != nil {
-		return resolvedConfig{}, fmt.Errorf("invalid console log level %q: %w", cfg.ConsoleLevel, err)
+		errs = append(errs, fmt.Errorf("invalid console log level %q: %w", cfg.ConsoleLevel, err))
+	}
+
+	console, err := resolveConsoleOptions(cfg)
+	if err != nil {
+		errs = append(errs, err)
 	}
 
+	var stacktrace zapcore.LevelEnabler
+	switch v := strings.ToLower(strings.TrimSpace(cfg.StacktraceLevel)); v {
+	case "none", "off":
+	default:
+		lvl, err := parseLevelOrDefault(v, zapcore.ErrorLevel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid stacktrace level %q: %w", cfg.StacktraceLevel, err))
+		}
+		stacktrace = lvl
+	}
+	overrides := make(map[string]zapcore.Level, len(cfg.LevelOverrides))
+	for name, v := range cfg.LevelOverrides {
+		lvl, err := zapcore.ParseLevel(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid level for logger %q: %w", name, err))
+			continue
+		}
+		overrides[name] = lvl
+	}
+	redactor, err := newRedactor(cfg.RedactKeys, cfg.RedactValues)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	encodeCaller := parseCallerPath(strings.TrimSpace(cfg.CallerPath))
+	console.encodeCaller = encodeCaller
+	location, err := parseTimeZone(cfg.TimeZone)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	console.location = location
+	diag, err := newDiagnostics(cfg)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var aggregateInterval time.Duration
+	if cfg.Aggregate != nil {
+		if aggregateInterval, err = parseAggregateConfig(cfg.Aggregate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var limits [3]int
+	for i, v := range []struct{ name, value string }{
+		{"max message size", cfg.MaxMessageSize},
+		{"max field size", cfg.MaxFieldSize},
+		{"max entry size", cfg.MaxEntrySize},
+	} {
+		if strings.TrimSpace(v.value) == "" {
+			continue
+		}
+		size, err := parseBytesString(v.value)
+		if err != nil || size <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s %q", v.name, v.value))
+			continue
+		}
+		limits[i] = int(size)
+	}
+	var fileBuffer int
+	if v := strings.TrimSpace(cfg.FileBufferSize); v != "" {
+		if fileBuffer, err = parseBufferSize(v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid file buffer size %q: %w", cfg.FileBufferSize, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return resolvedConfig{}, err
+	}
 	return resolvedConfig{
 		level:        level,
 		consoleLevel: consoleLevel,
 		format:       format,
+		console:      console,
+		stacktrace:   stacktrace,
+		encodeCaller: encodeCaller,
+		overrides:    overrides,
+		redactor:     redactor,
+		maxMessage:   limits[0],
+		maxField:     limits[1],
+		maxEntry:     limits[2],
+		fileBuffer:   fileBuffer,
+		location:     location,
+		diag:         diag,
+
+		aggregateInterval: aggregateInterval,
 	}, nil
 }
 
+func resolveConsoleOptions(cfg *Config) (consoleOptions, error) {
+	opts := consoleOptions{output: os.Stdout, hideCaller: cfg.ConsoleHideCaller}
+
+	switch output := strings.ToLower(strings.TrimSpace(cfg.ConsoleOutput)); output {
+	case "", "stdout":
+	case "stderr":
+		opts.output = os.Stderr
+	default:
+		return consoleOptions{}, fmt.Errorf("invalid console output %q", cfg.ConsoleOutput)
+	}
+
+	switch color := strings.ToLower(strings.TrimSpace(cfg.ConsoleColor)); color {
+	case "", "auto":
+		opts.color = autoColor(opts.output)
+	case "always":
+		opts.color = true
+	case "never":
+	default:
+		return consoleOptions{}, fmt.Errorf("invalid console color %q", cfg.ConsoleColor)
+	}
+
+	opts.encodeTime = parseTimeLayout(cfg.ConsoleTimeLayout)
+	return opts, nil
+}
+
+// autoColor 输出为终端且未设置 NO_COLOR、CI 环境变量时启用颜色
+func autoColor(out *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("CI") != "" {
+		return false
+	}
+	info, err := out.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseTimeLayout 解析时间格式名称或 Go 时间布局，空字符串返回 nil 表示使用默认格式
+func parseTimeLayout(layout string) zapcore.TimeEncoder {
+	switch strings.ToLower(strings.TrimSpace(layout)) {
+	case "":
+		return nil
+	case "epoch", "epochmillis":
+		return zapcore.EpochMillisTimeEncoder
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return zapcore.RFC3339NanoTimeEncoder
+	default:
+		return zapcore.TimeEncoderOfLayout(layout)
+	}
+}
+
 func parseLevelOrDefault(level string, fallback zapcore.Level) (zapcore.Level, error) {
 	if strings.TrimSpace(level) == "" {
 		return fallback, nil
@@ -126,32 +515,96 @@ func parseLevelOrDefault(level string, fallback zapcore.Level) (zapcore.Level, e
 }
 
 func newMultiHandler(cfg *Config, resolved resolvedConfig) (*MultiHandler, error) {
-	adaptorEncoder := zapcore.NewJSONEncoder(jsonEncoderConfig())
-	consoleEncoder := newConsoleEncoder(resolved.format)
+	adaptorConfig := jsonEncoderConfig()
+	if resolved.encodeCaller != nil {
+		adaptorConfig.EncodeCaller = resolved.encodeCaller
+	}
+	adaptorConfig.EncodeTime = inLocation(adaptorConfig.EncodeTime, resolved.location)
+	adaptorEncoder := zapcore.NewJSONEncoder(adaptorConfig)
 
-	handler := &MultiHandler{
-		cores: []zapcore.Core{
-			zapcore.NewCore(consoleEncoder, zapcore.Lock(os.Stdout), resolved.consoleLevel),
-		},
+	handler := &MultiHandler{}
+	if cfg.consoleEnabled() {
+		consoleEncoder := newConsoleEncoder(resolved.format, resolved.console)
+		var consoleCore zapcore.Core = zapcore.NewCore(consoleEncoder, zapcore.Lock(resolved.console.output), resolved.consoleLevel)
+		if resolved.redactor != nil {
+			consoleCore = newRedactCore(consoleCore, resolved.redactor)
+		}
+		handler.cores = append(handler.cores, consoleCore)
 	}
 
+	var cores []zapcore.Core
 	for _, adaptorDSN := range cfg.Adaptors {
-		core, closer, err := createAdaptorCore(adaptorDSN, adaptorEncoder, resolved.level)
+		a, err := createAdaptor(cfg, resolved, adaptorDSN, adaptorEncoder)
 		if err != nil {
-			if closer != nil {
-				_ = closer.Close()
-			}
+			resolved.diag.report(redactDSN(adaptorDSN), fmt.Errorf("adaptor disabled: %w", err))
 			continue
 		}
-		handler.cores = append(handler.cores, core)
-		if closer != nil {
-			handler.closers = append(handler.closers, closer)
+		cores = append(cores, a.core)
+		if a.closer != nil {
+			handler.closers = append(handler.closers, a.closer)
 		}
+		handler.adaptors = append(handler.adaptors, a)
+	}
+	probeAdaptors(cfg, handler.adaptors)
+	if len(cfg.Routes) == 0 {
+		// 使用默认编码器且没有各自包装的适配器共享一次编码
+		handler.cores = append(handler.cores, mergeSinks(cores, adaptorEncoder)...)
+		handler.cores = append(handler.cores, extraCores(cfg, resolved, adaptorEncoder)...)
+		return handler, nil
 	}
 
+	// 路由只作用于适配器，控制台仍输出全部日志
+	names := make([]string, len(cfg.Adaptors))
+	for i, dsn := range cfg.Adaptors {
+		names[i] = adaptorAlias(dsn)
+	}
+	aliases := make([]string, len(handler.adaptors))
+	for i, a := range handler.adaptors {
+		aliases[i] = a.alias
+	}
+	routes, err := compileRoutes(cfg.Routes, names, aliases)
+	if err != nil {
+		_ = handler.closers.Close()
+		return nil, err
+	}
+	handler.cores = append(handler.cores, newRouterCore(cores, routes))
+	handler.cores = append(handler.cores, extraCores(cfg, resolved, adaptorEncoder)...)
 	return handler, nil
 }
 
+// extraCores 返回 Config.ExtraCores 与 Config.ExtraWriters 对应的 Core
+func extraCores(cfg *Config, resolved resolvedConfig, encoder zapcore.Encoder) []zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(cfg.ExtraCores)+len(cfg.ExtraWriters))
+	for _, core := range cfg.ExtraCores {
+		if core != nil {
+			cores = append(cores, core)
+		}
+	}
+	for _, w := range cfg.ExtraWriters {
+		if w != nil {
+			cores = append(cores, zapcore.NewCore(encoder.Clone(), zapcore.Lock(zapcore.AddSync(w)), resolved.level))
+		}
+	}
+	if resolved.redactor != nil {
+		for i, core := range cores {
+			cores[i] = newRedactCore(core, resolved.redactor)
+		}
+	}
+	return cores
+}
+
+// probeAdaptors 并发探测开启了 probe 的远端适配器，等待全部完成。
+// 失败由适配器自身记录并通过诊断输出报告，不影响创建
+func probeAdaptors(cfg *Config, adaptors []*adaptor) {
+	var wg sync.WaitGroup
+	for _, a := range adaptors {
+		if p, ok := a.closer.(prober); ok && (cfg.Probe || a.probe) {
+			wg.Go(func() { _ = p.Probe(context.Background()) })
+		}
+	}
+	wg.Wait()
+}
+
 func jsonEncoderConfig() zapcore.EncoderConfig {
 	cfg := zap.NewProductionEncoderConfig()
 	cfg.EncodeTime = zapcore.EpochMillisTimeEncoder
@@ -167,47 +620,609 @@ func consoleEncoderConfig() zapcore.EncoderConfig {
 	return cfg
 }
 
-func newConsoleEncoder(format string) zapcore.Encoder {
-	if format == "json" {
-		return zapcore.NewJSONEncoder(jsonEncoderConfig())
+func newConsoleEncoder(format string, opts consoleOptions) zapcore.Encoder {
+	var cfg zapcore.EncoderConfig
+	switch format {
+	case "json", "json-pretty":
+		cfg = jsonEncoderConfig()
+	case "logfmt":
+		cfg = logfmtEncoderConfig()
+	default:
+		cfg = consoleEncoderConfig()
+		if !opts.color {
+			cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+	}
+	if opts.encodeTime != nil {
+		cfg.EncodeTime = opts.encodeTime
+	}
+	cfg.EncodeTime = inLocation(cfg.EncodeTime, opts.location)
+	if opts.hideCaller {
+		cfg.CallerKey = zapcore.OmitKey
+	}
+	if opts.encodeCaller != nil {
+		cfg.EncodeCaller = opts.encodeCaller
+	}
+	switch format {
+	case "json":
+		return zapcore.NewJSONEncoder(cfg)
+	case "json-pretty":
+		return NewPrettyJSONEncoder(cfg, opts.color)
+	case "logfmt":
+		return NewLogfmtEncoder(cfg)
+	default:
+		return zapcore.NewConsoleEncoder(cfg)
 	}
-	return zapcore.NewConsoleEncoder(consoleEncoderConfig())
 }
 
-// createAdaptorCore 根据 DSN 创建对应的 Core
-func createAdaptorCore(dsn string, encoder zapcore.Encoder, lvl zapcore.Level) (zapcore.Core, io.Closer, error) {
-	schema, _, ok := strings.Cut(dsn, "://")
+// newAdaptorEncoder 根据通用选项创建适配器使用的编码器，默认 JSON
+func newAdaptorEncoder(opts *CoreOptions) (zapcore.Encoder, error) {
+	switch opts.Format {
+	case "logfmt":
+		return NewLogfmtEncoder(opts.encoderConfig(logfmtEncoderConfig())), nil
+	case "template":
+		return NewTemplateEncoder(opts.encoderConfig(templateEncoderConfig()), opts.Template)
+	case "rfc5424":
+		return NewRFC5424Encoder(opts.encoderConfig(jsonEncoderConfig()), opts.Syslog), nil
+	case "", "json":
+		return zapcore.NewJSONEncoder(opts.encoderConfig(jsonEncoderConfig())), nil
+	case "json-pretty":
+		return NewPrettyJSONEncoder(opts.encoderConfig(jsonEncoderConfig()), false), nil
+	}
+	build, ok := lookupEncoder(opts.Format)
 	if !ok {
-		return nil, nil, fmt.Errorf("invalid adaptor DSN: %s", dsn)
+		return nil, fmt.Errorf("unknown encoder: %s", opts.Format)
+	}
+	return build(opts.encoderConfig(jsonEncoderConfig())), nil
+}
+
+// remapKeys 按映射重命名标准键
+func remapKeys(cfg zapcore.EncoderConfig, keys map[string]string) zapcore.EncoderConfig {
+	for from, to := range keys {
+		switch from {
+		case "ts":
+			cfg.TimeKey = to
+		case "level":
+			cfg.LevelKey = to
+		case "msg":
+			cfg.MessageKey = to
+		case "caller":
+			cfg.CallerKey = to
+		case "logger":
+			cfg.NameKey = to
+		case "stacktrace":
+			cfg.StacktraceKey = to
+		}
+	}
+	return cfg
+}
+
+// encoderConfig 在基础编码配置上应用调用位置格式与键名映射
+func (o *CoreOptions) encoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	if o.encodeCaller != nil {
+		cfg.EncodeCaller = o.encodeCaller
+	}
+	cfg.EncodeTime = inLocation(cfg.EncodeTime, o.Location)
+	return remapKeys(cfg, o.Keys)
+}
+
+// createAdaptor 根据 DSN 创建对应的适配器，并套上通用包装
+func createAdaptor(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (*adaptor, error) {
+	if isTenantDSN(dsn) {
+		return createTenantAdaptor(cfg, resolved, dsn, encoder)
+	}
+	coreOpts, err := parseCoreOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	coreOpts.encodeCaller = resolved.encodeCaller
+	// 全局键名映射，DSN 中的 keys 优先
+	for from, to := range cfg.Keys {
+		if _, ok := coreOpts.Keys[from]; !ok {
+			if coreOpts.Keys == nil {
+				coreOpts.Keys = make(map[string]string)
+			}
+			coreOpts.Keys[from] = to
+		}
+	}
+	// 全局时区，DSN 中的 tz 优先
+	tzSet := coreOpts.Location != nil
+	coreOpts.Location = cmp.Or(coreOpts.Location, resolved.location)
+	coreOpts.Syslog.Location = coreOpts.Location
+	if coreOpts.Format != "" || len(coreOpts.Keys) > 0 || tzSet {
+		if encoder, err = newAdaptorEncoder(coreOpts); err != nil {
+			return nil, err
+		}
+	}
+	// 全局截断选项，DSN 中的参数优先
+	coreOpts.MaxMessage = cmp.Or(coreOpts.MaxMessage, resolved.maxMessage)
+	coreOpts.MaxField = cmp.Or(coreOpts.MaxField, resolved.maxField)
+	if coreOpts.MaxEntry = cmp.Or(coreOpts.MaxEntry, resolved.maxEntry); coreOpts.MaxEntry > 0 {
+		encoder = newLimitEncoder(encoder, coreOpts.MaxEntry)
+	}
+	a := &adaptor{name: redactDSN(dsn), alias: coreOpts.Name, probe: coreOpts.Probe}
+	coreOpts.OnDrop = adaptorDropHook(cfg, a.name)
+	core, closer, err := createSchemeCore(cfg, resolved, dsn, encoder)
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, err
+	}
+	a.core, a.closer = core, closer
+	if c, ok := closer.(dropCounter); ok {
+		a.counters = append(a.counters, c)
+	}
+	// 截断在最内层，先脱敏再截断，避免被截断的敏感内容不再匹配脱敏规则
+	if coreOpts.MaxMessage > 0 || coreOpts.MaxField > 0 {
+		a.core = newTruncateCore(a.core, coreOpts.MaxMessage, coreOpts.MaxField)
+	}
+	// 全局脱敏先于适配器自身的包装
+	if resolved.redactor != nil {
+		a.core = newRedactCore(a.core, resolved.redactor)
+	}
+	a.wrap(coreOpts)
+	return a, nil
+}
+
+// wrap 根据通用选项包装 Core
+func (a *adaptor) wrap(opts *CoreOptions) {
+	if len(opts.AllowFields) > 0 || len(opts.DenyFields) > 0 {
+		a.core = newFieldFilterCore(a.core, opts.AllowFields, opts.DenyFields)
+	}
+	if opts.SortFields {
+		a.core = newSortedCore(a.core, opts.FieldOrder)
+	}
+	if opts.redactor != nil {
+		a.core = newRedactCore(a.core, opts.redactor)
+	}
+	if opts.Dedupe > 0 {
+		a.core = newDedupeCore(a.core, opts.Dedupe)
+	}
+	if opts.RateLimit > 0 {
+		limited := newRateLimitCore(a.core, opts.RateLimit, opts.Burst, opts.OnDrop)
+		a.core = limited
+		a.counters = append(a.counters, limited)
+	}
+	// 消息筛选在最外层，被过滤的日志不进入去重与限流
+	if opts.filter != nil {
+		a.core = opts.filter.wrap(a.core)
+	}
+}
+
+// adaptorDropHook 将 Config.OnDrop 绑定到指定适配器，未设置时返回 nil
+func adaptorDropHook(cfg *Config, name string) func(n int, reason error) {
+	if cfg.OnDrop == nil {
+		return nil
+	}
+	return func(n int, reason error) { cfg.OnDrop(name, n, reason) }
+}
+
+// createSchemeCore 根据 DSN 的 scheme 创建对应的 Core
+func createSchemeCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	u, err := parseDSN(dsn)
+	if err != nil || u.Scheme == "" {
+		return nil, nil, fmt.Errorf("invalid adaptor DSN: %s", redactDSN(dsn))
+	}
+	schema := u.Scheme
+	lvl := resolved.level
+	if err := checkLevelBand(schema, u.Query(), lvl); err != nil {
+		return nil, nil, err
+	}
+	if open, ok := sinkSchemes[schema]; ok {
+		return createSinkCore(cfg, resolved, dsn, encoder, open)
 	}
 	switch schema {
 	case "file":
-		opts, err := parseFileOptions(dsn)
+		return createFileCore(cfg, resolved, dsn, encoder)
+	case "pushgateway", "pushgateway+tls":
+		opts, err := parsePushOptions(dsn)
 		if err != nil {
 			return nil, nil, err
 		}
-		writer, closer, err := newFileWriter(opts)
+		opts.OnError = resolved.diag.hook(redactDSN(dsn))
+		pusher, err := newCounterPusher(opts)
 		if err != nil {
 			return nil, nil, err
 		}
 		if opts.LevelSet {
 			lvl = opts.Level
 		}
-		return zapcore.NewCore(encoder, writer, lvl), closer, nil
-	case "http":
-		opts, err := parseHTTPOptions(dsn)
-		if err != nil {
-			return nil, nil, err
-		}
-		writer, closer, err := newHTTPWriter(opts)
-		if err != nil {
-			return nil, nil, err
+		return &counterCore{LevelEnabler: levelRange{min: lvl, max: opts.LevelMax}, pusher: pusher}, pusher, nil
+	case "chaos":
+		return createChaosCore(cfg, resolved, dsn, encoder)
+	case "failover":
+		return createFailoverCore(cfg, resolved, dsn, encoder)
+	case "mirror":
+		return createMirrorCore(cfg, resolved, dsn, encoder)
+	case "sample":
+		return createSampleCore(cfg, resolved, dsn, encoder)
+	default:
+		return nil, nil, fmt.Errorf("unsupported scheme: %s", schema)
+	}
+}
+
+// sinkHooks 创建写入器时绑定到该适配器的回调
+type sinkHooks struct {
+	onError func(err error)           // 写入或发送失败，报告到诊断输出
+	onDrop  func(n int, reason error) // 日志被丢弃，转交 Config.OnDrop
+}
+
+// schemeSink 由 DSN 创建的写入器及其级别选项
+type schemeSink struct {
+	writer     zapcore.WriteSyncer
+	closer     io.Closer
+	encode     func(zapcore.Encoder) zapcore.Encoder // 非 nil 时替换或包装 DSN 的编码器
+	level      zapcore.Level
+	levelSet   bool // level / level-min 显式设置
+	levelFloor bool // 未显式设置时 level 作为继承级别的下限
+	levelMax   zapcore.Level
+}
+
+// openSink 解析 DSN 并创建写入器，回调按 hooks 绑定
+type openSink func(dsn string, hooks sinkHooks) (*schemeSink, error)
+
+// sinkSchemes 以写入器输出的 scheme，由 createSinkCore 统一处理回调与级别
+var sinkSchemes = map[string]openSink{
+	"http":         openHTTPSink,
+	"https":        openHTTPSink,
+	"vector":       openVectorSink,
+	"vector+tls":   openVectorSink,
+	"influxdb":     openInfluxSink,
+	"influxdb+tls": openInfluxSink,
+	"grafanacloud": openGrafanaCloudSink,
+	"redis":        openRedisSink,
+	"redis+tls":    openRedisSink,
+	"consul":       openKVSink,
+	"consul+tls":   openKVSink,
+	"etcd":         openKVSink,
+	"etcd+tls":     openKVSink,
+	"record":       openRecordSink,
+	"syslog+tls":   openSyslogSink,
+	"relp":         openSyslogSink,
+	"relp+tls":     openSyslogSink,
+}
+
+// createSinkCore 按 DSN 创建写入器并包装为 Core：回调绑定到脱敏后的适配器名称，
+// 级别取 DSN 的 level / level-min，未设置时继承全局级别
+func createSinkCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder, open openSink) (zapcore.Core, io.Closer, error) {
+	name := redactDSN(dsn)
+	s, err := open(dsn, sinkHooks{onError: resolved.diag.hook(name), onDrop: adaptorDropHook(cfg, name)})
+	if err != nil {
+		return nil, nil, err
+	}
+	lvl := resolved.level
+	switch {
+	case s.levelSet:
+		lvl = s.level
+	case s.levelFloor:
+		lvl = max(lvl, s.level)
+	}
+	if s.encode != nil {
+		encoder = s.encode(encoder)
+	}
+	return newSinkCore(encoder, s.writer, levelRange{min: lvl, max: s.levelMax}), s.closer, nil
+}
+
+func openHTTPSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseHTTPOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return openHTTPWriter(opts, hooks)
+}
+
+func openVectorSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseVectorOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return openHTTPWriter(opts, hooks)
+}
+
+// openInfluxSink 使用 line protocol 编码，format、keys 等编码参数不生效
+func openInfluxSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseInfluxOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openHTTPWriter(opts.HTTP, hooks)
+	if err != nil {
+		return nil, err
+	}
+	s.encode = func(zapcore.Encoder) zapcore.Encoder { return newInfluxEncoder(opts) }
+	return s, nil
+}
+
+// openGrafanaCloudSink 在 DSN 的编码器外包装 Loki 推送格式
+func openGrafanaCloudSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseGrafanaCloudOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openHTTPWriter(opts.HTTP, hooks)
+	if err != nil {
+		return nil, err
+	}
+	s.encode = func(enc zapcore.Encoder) zapcore.Encoder { return newLokiEncoder(enc, opts.Labels) }
+	return s, nil
+}
+
+// openHTTPWriter 创建基于 HTTP 适配器的写入器
+func openHTTPWriter(opts *HTTPOptions, hooks sinkHooks) (*schemeSink, error) {
+	opts.OnDrop, opts.OnError = hooks.onDrop, hooks.onError
+	writer, closer, err := newHTTPWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &schemeSink{writer: writer, closer: closer, level: opts.Level, levelSet: opts.LevelSet, levelMax: opts.LevelMax}, nil
+}
+
+func openRedisSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseRedisOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	opts.OnError = hooks.onError
+	writer, closer, err := newRedisWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &schemeSink{writer: writer, closer: closer, level: opts.Level, levelSet: opts.LevelSet, levelMax: opts.LevelMax}, nil
+}
+
+// openKVSink 默认只记录 error 及以上，全局级别更高时取全局级别
+func openKVSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseKVOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	opts.OnError = hooks.onError
+	publisher, err := newKVPublisher(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &schemeSink{writer: publisher, closer: publisher, level: opts.Level, levelSet: opts.LevelSet, levelFloor: true, levelMax: opts.LevelMax}, nil
+}
+
+func openRecordSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseRecordOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	opts.OnError = hooks.onError
+	writer, closer, err := newRecordWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &schemeSink{writer: writer, closer: closer, level: opts.Level, levelSet: opts.LevelSet, levelMax: opts.LevelMax}, nil
+}
+
+func openSyslogSink(dsn string, hooks sinkHooks) (*schemeSink, error) {
+	opts, err := parseSyslogOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	opts.OnError = hooks.onError
+	writer, closer, err := newSyslogWriter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &schemeSink{writer: writer, closer: closer, level: opts.Level, levelSet: opts.LevelSet, levelMax: opts.LevelMax}, nil
+}
+
+// checkLevelBand 检查只设置 level-max 时继承的最低级别是否高于 level-max，此时适配器不会写入任何日志。
+// 显式设置 level / level-min 时由 parseLevelRange 检查
+func checkLevelBand(scheme string, query url.Values, level zapcore.Level) error {
+	v := query.Get("level-max")
+	if v == "" || query.Get("level") != "" || query.Get("level-min") != "" {
+		return nil
+	}
+	// consul / etcd 默认只记录 error 及以上
+	inherited := level
+	if base, _, _ := strings.Cut(scheme, "+"); base == "consul" || base == "etcd" {
+		inherited = max(level, zapcore.ErrorLevel)
+	}
+	levelMax, err := zapcore.ParseLevel(v)
+	if err != nil {
+		// 由各适配器解析时报告
+		return nil
+	}
+	if inherited > levelMax {
+		return fmt.Errorf("inherited level %s is greater than level-max %s, adaptor would never write", inherited, levelMax)
+	}
+	return nil
+}
+
+// createChaosCore 创建故障注入适配器，target 按完整的适配器 DSN 创建，没有 target 时日志编码后丢弃
+func createChaosCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	opts, err := parseChaosOptions(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	closer := &chaosCloser{}
+	var inner zapcore.Core
+	if opts.Target != "" {
+		if closer.target, err = createAdaptor(cfg, resolved, opts.Target, encoder); err != nil {
+			return nil, nil, fmt.Errorf("chaos target: %w", err)
 		}
+		inner = closer.target.core
+	} else {
+		lvl := resolved.level
 		if opts.LevelSet {
 			lvl = opts.Level
 		}
-		return zapcore.NewCore(encoder, writer, lvl), closer, nil
-	default:
-		return nil, nil, fmt.Errorf("unsupported scheme: %s", schema)
+		inner = newSinkCore(encoder, zapcore.AddSync(io.Discard), levelRange{min: lvl, max: opts.LevelMax})
+	}
+	closer.core = newChaosCore(inner, opts, adaptorDropHook(cfg, redactDSN(dsn)))
+	return closer.core, closer, nil
+}
+
+// createFailoverCore 创建故障转移适配器，primary 与 secondary 按完整的适配器 DSN 创建
+func createFailoverCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	opts, err := parseFailoverOptions(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	primary, err := createAdaptor(cfg, resolved, opts.Primary, encoder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failover primary: %w", err)
+	}
+	secondary, err := createAdaptor(cfg, resolved, opts.Secondary, encoder)
+	if err != nil {
+		if primary.closer != nil {
+			_ = primary.closer.Close()
+		}
+		return nil, nil, fmt.Errorf("failover secondary: %w", err)
+	}
+	state := newFailoverState(primary, secondary, opts.Interval, resolved.diag.hook(redactDSN(dsn)))
+	return &failoverCore{primary: primary.core, secondary: secondary.core, state: state}, &failoverCloser{state: state}, nil
+}
+
+// createMirrorCore 创建影子适配器，primary 与 shadow 按完整的适配器 DSN 创建
+func createMirrorCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	opts, err := parseMirrorOptions(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	primary, err := createAdaptor(cfg, resolved, opts.Primary, encoder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mirror primary: %w", err)
+	}
+	shadow, err := createAdaptor(cfg, resolved, opts.Shadow, encoder)
+	if err != nil {
+		if primary.closer != nil {
+			_ = primary.closer.Close()
+		}
+		return nil, nil, fmt.Errorf("mirror shadow: %w", err)
+	}
+	m := newMirror(primary, shadow, opts.Buffer)
+	return &mirrorCore{primary: primary.core, shadow: shadow.core, m: m}, m, nil
+}
+
+// createSampleCore 创建采样适配器，of 按完整的适配器 DSN 创建
+func createSampleCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	opts, err := parseSampleOptions(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := createAdaptor(cfg, resolved, opts.Of, encoder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sample of: %w", err)
+	}
+	core := newSampleCore(target.core, opts)
+	return core, &sampleCloser{core: core, target: target}, nil
+}
+
+// createFileCore 创建文件适配器 Core，split-errors 时额外输出 warn 及以上级别到 .error 文件
+func createFileCore(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (zapcore.Core, io.Closer, error) {
+	opts, err := parseFileOptions(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.OnRotate = cfg.OnRotate
+	opts.OnError = resolved.diag.hook(redactDSN(dsn))
+	opts.Location = cmp.Or(opts.Location, resolved.location)
+	// DSN 未设置 buffer 时使用 Config.FileBufferSize
+	if opts.BufferSize == 0 && resolved.fileBuffer > 0 {
+		opts.BufferSize = resolved.fileBuffer
+		opts.FlushInterval = cmp.Or(opts.FlushInterval, defaultFileFlushInterval)
+	}
+	lvl := resolved.level
+	writer, closer, err := newFileWriter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.LevelSet {
+		lvl = opts.Level
+	}
+	core := newSinkCore(encoder, writer, levelRange{min: lvl, max: opts.LevelMax})
+	if !opts.SplitErrors {
+		return core, closer, nil
+	}
+
+	errOpts := *opts
+	errOpts.Path = errorFilePath(opts.Path)
+	errOpts.Shards = 0 // 错误日志量小，不分片
+	errWriter, errCloser, err := newFileWriter(&errOpts)
+	if err != nil {
+		return nil, closer, err
+	}
+	errCore := newSinkCore(encoder, errWriter, levelRange{min: max(lvl, zapcore.WarnLevel), max: opts.LevelMax})
+	return levelTee{core, errCore}, multiCloser{closer, errCloser}, nil
+}
+
+// errorFilePath 生成错误日志文件路径: app.log -> app.error.log
+func errorFilePath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".error" + ext
+}
+
+// multiCloser 依次关闭多个资源，返回第一个错误
+type multiCloser []io.Closer
+
+// stats 合并各资源的运行状态
+func (m multiCloser) stats(st *AdaptorStats) {
+	for _, closer := range m {
+		if r, ok := closer.(statsReporter); ok {
+			r.stats(st)
+		}
+	}
+}
+
+// CloseContext 依次按 ctx 关闭多个资源，合并处理结果
+func (m multiCloser) CloseContext(ctx context.Context) (DrainResult, error) {
+	var total DrainResult
+	var firstErr error
+	for _, closer := range m {
+		result, err := closeContext(ctx, closer)
+		total.Flushed += result.Flushed
+		total.Abandoned += result.Abandoned
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return total, firstErr
+}
+
+func (m multiCloser) Close() error {
+	_, err := m.CloseContext(context.Background())
+	return err
+}
+
+// secretParams 值为凭据的 DSN 参数，header.* 的值也视为凭据
+var secretParams = []string{"auth", "token", "password", "secret", "sign-key", "api-key"}
+
+// isSecretParam DSN 参数的值是否为凭据
+func isSecretParam(key string) bool {
+	return slices.Contains(secretParams, key) || strings.HasPrefix(key, "header.")
+}
+
+// nestedDSNParams 值为完整适配器 DSN 的参数（组合适配器）
+var nestedDSNParams = []string{"primary", "secondary", "shadow", "of", "target"}
+
+// redactDSN 隐藏 DSN 中的密码、凭据参数与请求头，用于适配器名称与错误信息；
+// 组合适配器中嵌套的 DSN 同样处理
+func redactDSN(dsn string) string {
+	u, err := parseDSN(dsn)
+	if err != nil {
+		return "<unparseable DSN>"
+	}
+	query, changed := u.Query(), false
+	for key, values := range query {
+		switch {
+		case isSecretParam(key):
+			query.Set(key, "xxxxx")
+			changed = true
+		case slices.Contains(nestedDSNParams, key):
+			for i, v := range values {
+				values[i] = redactDSN(v)
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
 	}
+	return u.Redacted()
 }
@@ -1,35 +1,62 @@
 package log_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/mulan-ext/log"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func captureStdout(t *testing.T, fn func()) string {
 	t.Helper()
+	return captureFile(t, &os.Stdout, fn)
+}
+
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stderr, fn)
+}
 
-	oldStdout := os.Stdout
+// captureFile 在 fn 执行期间将 *f 替换为管道并返回写入的内容
+func captureFile(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+
+	old := *f
 	reader, writer, err := os.Pipe()
 	if err != nil {
 		t.Fatal(err)
 	}
-	os.Stdout = writer
+	*f = writer
 
 	fn()
 
 	if err := writer.Close(); err != nil {
 		t.Fatal(err)
 	}
-	os.Stdout = oldStdout
+	*f = old
 
 	out, err := io.ReadAll(reader)
 	if err != nil {
@@ -54,7 +81,7 @@ func logPrint() {
 
 func TestLocalModeConsoleOutputIsColorizedAndDebug(t *testing.T) {
 	output := captureStdout(t, func() {
-		logger, err := log.NewWithConfig(&log.Config{Mode: "local"})
+		logger, err := log.NewWithConfig(&log.Config{Mode: "local", ConsoleColor: "always"})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -104,6 +131,85 @@ func TestServerModeConsoleOutputIsJSONAndInfo(t *testing.T) {
 	}
 }
 
+func TestConsoleColorAuto(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		cfg  log.Config
+		want bool
+	}{
+		{"pipe disables color", nil, log.Config{Mode: "local"}, false},
+		{"always overrides pipe", nil, log.Config{Mode: "local", ConsoleColor: "always"}, true},
+		{"always ignores NO_COLOR", map[string]string{"NO_COLOR": "1"}, log.Config{Mode: "local", ConsoleColor: "always"}, true},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1"}, log.Config{Mode: "local", ConsoleColor: "auto"}, false},
+		{"CI", map[string]string{"CI": "true"}, log.Config{Mode: "local"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			output := captureStdout(t, func() {
+				logger, err := log.NewWithConfig(&tt.cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer logger.Close()
+				zap.L().Info("color check")
+				_ = logger.Sync()
+			})
+			if got := strings.Contains(output, "\x1b["); got != tt.want {
+				t.Errorf("colored = %v, want %v: %q", got, tt.want, output)
+			}
+		})
+	}
+}
+
+func TestConsoleOptions(t *testing.T) {
+	var stdout string
+	stderr := captureStderr(t, func() {
+		stdout = captureStdout(t, func() {
+			logger, err := log.NewWithConfig(&log.Config{
+				Mode:              "local",
+				ConsoleColor:      "never",
+				ConsoleTimeLayout: "2006-01-02",
+				ConsoleHideCaller: true,
+				ConsoleOutput:     "stderr",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logger.Close()
+
+			zap.L().Info("plain console")
+			_ = logger.Sync()
+		})
+	})
+
+	if strings.Contains(stdout, "plain console") {
+		t.Errorf("expected no stdout output, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "plain console") {
+		t.Fatalf("expected stderr output, got %q", stderr)
+	}
+	if strings.Contains(stderr, "\x1b[") {
+		t.Errorf("expected no color escape sequence, got %q", stderr)
+	}
+	if !strings.HasPrefix(stderr, time.Now().Format("2006-01-02")+"\tINFO") {
+		t.Errorf("expected date layout and plain level, got %q", stderr)
+	}
+	if strings.Contains(stderr, "log_test.go") {
+		t.Errorf("expected caller to be hidden, got %q", stderr)
+	}
+
+	for _, cfg := range []*log.Config{{ConsoleColor: "sometimes"}, {ConsoleOutput: "syslog"}} {
+		if _, err := log.NewWithConfig(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
 func TestLog(t *testing.T) {
 	logger, err := log.NewWithConfig(&log.Config{Level: "info"})
 	if err != nil {
@@ -232,6 +338,239 @@ func TestLogFileLevelOverridesGlobalLevel(t *testing.T) {
 	}
 }
 
+// TestLogLevelBandInherited 只设置 level-max 时，继承的最低级别高于 level-max 的适配器不会写入任何日志，
+// Validate 报告错误，NewWithConfig 输出诊断并跳过
+func TestLogLevelBandInherited(t *testing.T) {
+	dir := t.TempDir()
+	for _, dsn := range []string{
+		"file://" + filepath.Join(dir, "a.log") + "?level-max=debug",
+		"consul://127.0.0.1:8500/app?level-max=warn",
+	} {
+		cfg := &log.Config{NoGlobal: true, Level: "info", Adaptors: []string{dsn}}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "level-max") {
+			t.Errorf("%s: Validate() = %v", dsn, err)
+		}
+		var diag bytes.Buffer
+		cfg.DiagnosticsWriter = &diag
+		logger, err := log.NewWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = logger.Close()
+		if !strings.Contains(diag.String(), "adaptor disabled: inherited level") {
+			t.Errorf("%s: diagnostics = %q", dsn, diag.String())
+		}
+	}
+	cfg := &log.Config{NoGlobal: true, Level: "debug", Adaptors: []string{"file://" + filepath.Join(dir, "b.log") + "?level-max=debug"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestLogFileLevelBand(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "_test.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "debug",
+		Adaptors: []string{"file://" + logFile + "?level-min=warn&level-max=error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	zap.L().Info("band info")
+	zap.L().Warn("band warn")
+	zap.L().Error("band error")
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "band info") {
+		t.Fatalf("expected info to be filtered by level-min, got %q", string(content))
+	}
+	if !strings.Contains(string(content), "band warn") || !strings.Contains(string(content), "band error") {
+		t.Fatalf("expected warn and error inside band, got %q", string(content))
+	}
+}
+
+func TestLogFileRateLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "_test.log")
+
+	dsn := "file://" + logFile + "?rate-limit=1/h&burst=2"
+	var onDrop int
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{dsn},
+		OnDrop: func(adaptor string, n int, reason error) {
+			if adaptor == dsn && errors.Is(reason, log.ErrRateLimited) {
+				onDrop += n
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	for i := range 5 {
+		zap.L().Info("rate limited", zap.Int("i", i))
+	}
+	_ = logger.Sync()
+
+	if got := logger.Dropped()[dsn]; got != 3 {
+		t.Errorf("Dropped()[%q] = %d, want 3", dsn, got)
+	}
+	if onDrop != 3 {
+		t.Errorf("OnDrop count = %d, want 3", onDrop)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(content), "rate limited"); n != 2 {
+		t.Fatalf("expected 2 entries within burst, got %d: %q", n, string(content))
+	}
+}
+
+func TestLogFileDedupe(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "_test.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?dedupe=1h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	for range 3 {
+		zap.L().Info("connection refused", zap.String("host", "db"))
+	}
+	zap.L().Info("connection refused", zap.String("host", "cache"))
+	_ = logger.Sync()
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines (db, cache, summary), got %d: %q", len(lines), string(content))
+	}
+	var summary map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["repeated"] != float64(2) || summary["host"] != "db" {
+		t.Fatalf("expected summary with repeated=2 for host=db, got %v", summary)
+	}
+}
+
+func TestLogFileBuffered(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "_test.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?buffer=256k&flush-interval=1h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	zap.L().Info("buffered entry")
+
+	content, _ := os.ReadFile(logFile)
+	if strings.Contains(string(content), "buffered entry") {
+		t.Fatalf("expected entry to stay in buffer before Sync, got %q", string(content))
+	}
+
+	_ = logger.Sync()
+	content, err = os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "buffered entry") {
+		t.Fatalf("expected Sync to flush buffer, got %q", string(content))
+	}
+}
+
+// TestLogFileBufferDefaultFlush DSN 只设置 buffer 时与 Config.FileBufferSize 相同，每秒刷新一次
+func TestLogFileBufferDefaultFlush(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Adaptors: []string{"file://" + logFile + "?buffer=256k"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Info("buffered entry")
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		content, _ := os.ReadFile(logFile)
+		if strings.Contains(string(content), "buffered entry") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected buffer to be flushed within the default 1s interval")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestLogFileSplitErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "app.log")
+	errFile := filepath.Join(tmpDir, "app.error.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?split-errors=true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	zap.L().Info("split info")
+	zap.L().Warn("split warn")
+	zap.L().Error("split error")
+	_ = logger.Sync()
+
+	all, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"split info", "split warn", "split error"} {
+		if !strings.Contains(string(all), msg) {
+			t.Fatalf("expected %q in main file, got %q", msg, string(all))
+		}
+	}
+
+	errs, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(errs), "split info") {
+		t.Fatalf("expected info to be excluded from error file, got %q", string(errs))
+	}
+	if !strings.Contains(string(errs), "split warn") || !strings.Contains(string(errs), "split error") {
+		t.Fatalf("expected warn and error in error file, got %q", string(errs))
+	}
+}
+
 func TestLogFileDSN(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "_test.log")
@@ -257,6 +596,130 @@ func TestLogFileDSN(t *testing.T) {
 	}
 }
 
+func TestLogFileLogfmt(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "_test.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?format=logfmt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.L().Info("user login", zap.String("user", "bob"), zap.Int("attempt", 2))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(content)
+	for _, want := range []string{"ts=", "level=info", `msg="user login"`, "user=bob", "attempt=2"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in logfmt line %q", want, line)
+		}
+	}
+}
+
+func TestLogFileKeys(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "_test.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Keys:     map[string]string{"ts": "@timestamp", "msg": "message"},
+		Adaptors: []string{"file://" + logFile + "?keys=msg:text,caller:"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.L().Info("remapped")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(content, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entry["@timestamp"]; !ok {
+		t.Errorf("expected @timestamp from Config.Keys, got %v", entry)
+	}
+	if entry["text"] != "remapped" {
+		t.Errorf("expected DSN keys to override msg, got %v", entry)
+	}
+	if _, ok := entry["caller"]; ok {
+		t.Errorf("expected caller to be omitted, got %v", entry)
+	}
+}
+
+// logVia 模拟业务封装的日志函数，配合 Config.Skip 使用
+func logVia(msg string) { zap.L().Warn(msg) }
+
+func TestLogCallerAndStacktrace(t *testing.T) {
+	var skipLine int
+	tests := []struct {
+		name      string
+		cfg       log.Config
+		log       func()
+		caller    string // 调用位置的正则，空表示不应输出
+		wantStack bool
+	}{
+		{"default", log.Config{}, func() { zap.L().Warn("msg") }, `/log_test\.go:\d+$`, false},
+		{"stacktrace warn", log.Config{StacktraceLevel: "warn"}, func() { zap.L().Warn("msg") }, `/log_test\.go:\d+$`, true},
+		{"stacktrace none", log.Config{StacktraceLevel: "none"}, func() { zap.L().Error("msg") }, `/log_test\.go:\d+$`, false},
+		{"disable caller", log.Config{DisableCaller: true}, func() { zap.L().Warn("msg") }, "", false},
+		{"module path", log.Config{CallerPath: "module"}, func() { zap.L().Warn("msg") }, `^log_test\.go:\d+$`, false},
+		{"full path", log.Config{CallerPath: "full"}, func() { zap.L().Warn("msg") }, `^/.*/log_test\.go:\d+$`, false},
+		{"skip wrapper", log.Config{Skip: 1}, func() { _, _, skipLine, _ = runtime.Caller(0); logVia("msg") }, "skip", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "caller.log")
+			cfg := tt.cfg
+			cfg.Level = "info"
+			cfg.ConsoleOutput = "stderr"
+			cfg.Adaptors = []string{"file://" + logFile}
+			logger, err := log.NewWithConfig(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.log()
+			if err := logger.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(content, &entry); err != nil {
+				t.Fatal(err)
+			}
+			caller, _ := entry["caller"].(string)
+			pattern := tt.caller
+			if pattern == "skip" {
+				// Skip 生效时调用位置指向调用封装函数的一行
+				pattern = fmt.Sprintf(`/log_test\.go:%d$`, skipLine)
+			}
+			if pattern == "" && caller != "" || pattern != "" && !regexp.MustCompile(pattern).MatchString(caller) {
+				t.Errorf("caller = %q, want match %q", caller, pattern)
+			}
+			if _, ok := entry["stacktrace"]; ok != tt.wantStack {
+				t.Errorf("stacktrace present = %v, want %v", ok, tt.wantStack)
+			}
+		})
+	}
+
+	if _, err := log.NewWithConfig(&log.Config{StacktraceLevel: "loud"}); err == nil {
+		t.Error("expected error for invalid stacktrace level")
+	}
+}
+
 func TestLogFileRotation(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "test-rotation.log")
@@ -281,6 +744,39 @@ func TestLogFileRotation(t *testing.T) {
 	t.Log("log file created:", logFile)
 }
 
+func TestLogFileOnRotate(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "test-rotation.log")
+
+	rotated := make(chan string, 8)
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?max-size=1m"},
+		OnRotate: func(path string) { rotated <- path },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	payload := strings.Repeat("x", 1024)
+	for i := range 2000 {
+		zap.L().Info("test log rotation", zap.Int("iteration", i), zap.String("payload", payload))
+	}
+
+	select {
+	case path := <-rotated:
+		if filepath.Dir(path) != tmpDir || path == logFile {
+			t.Fatalf("unexpected rotated path %q", path)
+		}
+		if !strings.HasPrefix(filepath.Base(path), "test-rotation-") {
+			t.Fatalf("expected lumberjack backup name, got %q", path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected rotate hook to be called")
+	}
+}
+
 func TestLogHTTP(t *testing.T) {
 	t.Skip("需要实际的 HTTP 服务端")
 
@@ -301,47 +797,1627 @@ func TestLogHTTP(t *testing.T) {
 	time.Sleep(2 * time.Second)
 }
 
-func TestLogWithName(t *testing.T) {
-	logger, err := log.New("my-service")
+func TestLogHTTPHeaders(t *testing.T) {
+	received := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- r.Header.Clone():
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{srv.URL + "/logs?max-retries=0&flush-interval=50ms&header.X-API-Key=abc&auth=bearer:TOKEN"},
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer logger.Close()
 
-	logPrint()
-}
+	zap.L().Info("authenticated entry")
 
-func TestLogInvalidLevel(t *testing.T) {
-	_, err := log.NewWithConfig(&log.Config{Level: "invalid"})
-	if err == nil {
-		t.Error("expected error for invalid log level")
+	select {
+	case header := <-received:
+		if got := header.Get("X-API-Key"); got != "abc" {
+			t.Errorf("X-API-Key = %q, want abc", got)
+		}
+		if got := header.Get("Authorization"); got != "Bearer TOKEN" {
+			t.Errorf("Authorization = %q, want Bearer TOKEN", got)
+		}
+		if got := header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected batch to be sent")
 	}
 }
 
-func TestLogMultipleAdaptors(t *testing.T) {
-	tmpDir := t.TempDir()
-	logFile1 := filepath.Join(tmpDir, "test1.log")
-	logFile2 := filepath.Join(tmpDir, "test2.log")
+func TestLogHTTPMethodAndContentType(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- r.Clone(r.Context()):
+		default:
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
 
 	logger, err := log.NewWithConfig(&log.Config{
 		Level: "info",
-		Adaptors: []string{
-			"file://" + logFile1,
-			"file://" + logFile2,
-		},
+		Adaptors: []string{srv.URL + "/logs?max-retries=0&flush-interval=50ms" +
+			"&method=put&content-type=application/vnd.logs%2Bjson&expect-status=200,202"},
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer logger.Close()
 
-	logPrint()
+	zap.L().Info("put entry")
 
-	// 验证两个文件都被创建
-	if _, err := os.Stat(logFile1); os.IsNotExist(err) {
-		t.Error("log file 1 not created")
-	}
+	select {
+	case r := <-received:
+		if r.Method != http.MethodPut {
+			t.Errorf("Method = %q, want PUT", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/vnd.logs+json" {
+			t.Errorf("Content-Type = %q, want application/vnd.logs+json", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected batch to be sent")
+	}
+	if dropped := logger.Dropped(); len(dropped) != 1 {
+		t.Errorf("Dropped() = %v, want one adaptor", dropped)
+	} else {
+		for _, n := range dropped {
+			if n != 0 {
+				t.Errorf("dropped %d entries, want 0 for 202 response", n)
+			}
+		}
+	}
+}
+
+func TestLogStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/bad") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	logFile := filepath.Join(t.TempDir(), "app.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Adaptors: []string{
+			"file://" + logFile + "?split-errors=true",
+			srv.URL + "/logs?max-retries=0&flush-interval=20ms",
+			srv.URL + "/bad?max-retries=0&flush-interval=20ms",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	logger.Info("first")
+	logger.Error("second")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := logger.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("got %d adaptors, want 3", len(stats))
+	}
+	file, ok, bad := stats[0], stats[1], stats[2]
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// split-errors 合并主文件与错误文件的写入
+	if file.Written <= uint64(info.Size()) || file.LastSuccess.Before(start) || file.LastError != "" {
+		t.Errorf("file stats = %+v, main file %d bytes", file, info.Size())
+	}
+	if ok.Written == 0 || ok.Dropped != 0 || ok.Queued != 0 || ok.LastSuccess.Before(start) || ok.LastError != "" {
+		t.Errorf("http stats = %+v", ok)
+	}
+	if bad.Written != 0 || bad.Dropped != 2 || !strings.Contains(bad.LastError, "400") || bad.LastErrorAt.Before(start) {
+		t.Errorf("failing http stats = %+v", bad)
+	}
+	if !strings.Contains(ok.Name, "/logs") || !bad.LastSuccess.IsZero() {
+		t.Errorf("name = %q, last success = %v", ok.Name, bad.LastSuccess)
+	}
+}
+
+func TestLogDiagnostics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		ConsoleOutput:     "stderr",
+		DiagnosticsWriter: &out,
+		Adaptors:          []string{"ftp://example.com/logs", srv.URL + "/logs?max-retries=0&flush-interval=20ms"},
+		Hooks:             []func(zapcore.Entry) error{func(zapcore.Entry) error { return errors.New("hook failed") }},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("first")
+	logger.Info("second")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"ftp://example.com/logs: adaptor disabled: unsupported scheme", "flush-interval=20ms: failed to send logs", "403", "log: zap:", "hook failed"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diagnostics missing %q:\n%s", want, got)
+		}
+	}
+	// 每个来源只输出一次
+	if n := strings.Count(got, "hook failed"); n != 1 {
+		t.Errorf("hook error reported %d times, want 1", n)
+	}
+}
+
+func TestLogCloseContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Adaptors:      []string{"file://" + filepath.Join(t.TempDir(), "app.log"), srv.URL + "/logs?flush-interval=1h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		logger.Info("pending")
+	}
+	result, err := logger.CloseContext(context.Background())
+	if err != nil || result != (log.DrainResult{Flushed: 3}) {
+		t.Errorf("CloseContext() = %+v, %v, want 3 flushed", result, err)
+	}
+}
+
+// TestLogCloseContextComposite 组合适配器将 ctx 转交给其中的 HTTP 适配器，并合并其排空结果
+func TestLogCloseContextComposite(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	sink := srv.URL + "/logs?flush-interval=1h"
+	file := "file://" + filepath.Join(t.TempDir(), "app.log")
+	for name, dsn := range map[string]string{
+		"failover": "failover://?primary=" + url.QueryEscape(sink) + "&secondary=" + url.QueryEscape(file),
+		"mirror":   "mirror://?primary=" + url.QueryEscape(file) + "&shadow=" + url.QueryEscape(sink),
+		"sample":   "sample://rate=1&of=" + url.QueryEscape(sink),
+		"chaos":    "chaos://?target=" + url.QueryEscape(sink),
+		"tenant":   sink + "&header.X-Scope-OrgID={tenant}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger, err := log.NewWithConfig(&log.Config{
+				NoGlobal:      true,
+				ConsoleOutput: "stderr",
+				Adaptors:      []string{dsn},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 3 {
+				logger.Info("pending")
+			}
+			result, err := logger.CloseContext(context.Background())
+			if err != nil || result != (log.DrainResult{Flushed: 3}) {
+				t.Errorf("CloseContext() = %+v, %v, want 3 flushed", result, err)
+			}
+		})
+	}
+}
+
+func TestLogHTTPBasicAuth(t *testing.T) {
+	type credentials struct{ user, pass string }
+	received := make(chan credentials, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		select {
+		case received <- credentials{user, pass}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://writer:s3cret@", 1) + "/logs?max-retries=0&flush-interval=50ms"
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{dsn},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	zap.L().Info("basic auth entry")
+
+	select {
+	case got := <-received:
+		if got.user != "writer" || got.pass != "s3cret" {
+			t.Errorf("basic auth = %s:%s, want writer:s3cret", got.user, got.pass)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected batch to be sent")
+	}
+}
+
+func TestLogHTTPSWithCA(t *testing.T) {
+	received := make(chan struct{}, 1)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{srv.URL + "/logs?max-retries=0&flush-interval=50ms&tls-min-version=1.2&tls-ca=" + caFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	zap.L().Info("tls entry")
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected batch to be sent over verified TLS")
+	}
+}
+
+func TestLogHTTPCompress(t *testing.T) {
+	for _, format := range []string{"gzip", "zstd"} {
+		t.Run(format, func(t *testing.T) {
+			received := make(chan []byte, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Content-Encoding"); got != format {
+					t.Errorf("Content-Encoding = %q, want %q", got, format)
+				}
+				var body io.Reader = r.Body
+				switch format {
+				case "gzip":
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					body = zr
+				case "zstd":
+					zr, err := zstd.NewReader(r.Body)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					defer zr.Close()
+					body = zr
+				}
+				data, err := io.ReadAll(body)
+				if err != nil {
+					t.Error(err)
+				}
+				select {
+				case received <- data:
+				default:
+				}
+			}))
+			defer srv.Close()
+
+			logger, err := log.NewWithConfig(&log.Config{
+				Level:    "info",
+				Adaptors: []string{srv.URL + "/logs?max-retries=0&flush-interval=50ms&compress=" + format},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer logger.Close()
+
+			zap.L().Info("compressed entry")
+
+			select {
+			case data := <-received:
+				var entries []map[string]any
+				if err := json.Unmarshal(data, &entries); err != nil {
+					t.Fatalf("expected JSON array after decompression, got %q: %v", data, err)
+				}
+				if len(entries) != 1 || entries[0]["msg"] != "compressed entry" {
+					t.Fatalf("unexpected entries %v", entries)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected batch to be sent")
+			}
+		})
+	}
+}
+
+func TestLogWithName(t *testing.T) {
+	logger, err := log.New("my-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logPrint()
+}
+
+func TestLogWith(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "with.log")
+	logger, err := log.NewWithConfig(&log.Config{Level: "info", Adaptors: []string{"file://" + logFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := logger.With(zap.String("request_id", "r-1")).WithValues("user", "bob", "attempt", 2)
+	child.Info("enriched")
+	// 子 Logger 共享适配器资源，关闭后日志写出
+	if err := child.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("unmarshal %q: %v", data, err)
+	}
+	if entry["request_id"] != "r-1" || entry["user"] != "bob" || entry["attempt"] != float64(2) {
+		t.Errorf("entry = %v", entry)
+	}
+	if dropped := child.Dropped(); len(dropped) != 1 {
+		t.Errorf("Dropped() = %v, want the file adaptor", dropped)
+	}
+}
+
+func TestNewCore(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "core.log")
+	core, closer, err := log.NewCore(&log.Config{
+		Level:         "info",
+		ConsoleOutput: "stderr",
+		Adaptors:      []string{"file://" + logFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	global := zap.L()
+	custom := zap.New(core, zap.Fields(zap.String("app", "custom")))
+	custom.Info("from custom logger")
+	if zap.L() != global {
+		t.Error("NewCore should not replace the global logger")
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `"msg":"from custom logger"`) || !strings.Contains(string(content), `"app":"custom"`) {
+		t.Errorf("log file = %s", content)
+	}
+
+	if _, _, err := log.NewCore(&log.Config{Level: "invalid"}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestLogExtraCoresAndWriters(t *testing.T) {
+	observed, logs := observer.New(zapcore.WarnLevel)
+	var buf bytes.Buffer
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:        "info",
+		NoGlobal:     true,
+		Console:      new(bool),
+		RedactKeys:   "password",
+		Adaptors:     []string{"file://" + logFile},
+		Routes:       []log.Route{{Level: "info"}}, // 丢弃适配器的全部日志
+		ExtraCores:   []zapcore.Core{observed},
+		ExtraWriters: []io.Writer{&buf},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("debug")
+	logger.Info("info", zap.String("password", "hunter2"))
+	logger.Warn("warn")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 自定义 Core 使用自身的级别，writer 使用全局级别，均不受路由影响，全局脱敏生效
+	if logs.Len() != 1 || logs.All()[0].Message != "warn" {
+		t.Errorf("extra core entries = %v", logs.AllUntimed())
+	}
+	out := buf.String()
+	if strings.Count(out, "\n") != 2 || !strings.Contains(out, `"msg":"info","password":"***"`) || strings.Contains(out, "debug") {
+		t.Errorf("extra writer = %s", out)
+	}
+	if content, _ := os.ReadFile(logFile); len(content) != 0 {
+		t.Errorf("file = %q", content)
+	}
+}
+
+func TestLogExpandErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{NoGlobal: true, Console: new(bool), ExpandErrors: true, ExtraWriters: []io.Writer{&buf}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("query failed", zap.Error(fmt.Errorf("query users: %w", context.DeadlineExceeded)))
+	_ = logger.Close()
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["error"] != "query users: context deadline exceeded" || entry["error_root"] != "context deadline exceeded" ||
+		entry["error_root_type"] != "context.deadlineExceededError" || len(entry["error_chain"].([]any)) != 2 {
+		t.Errorf("entry = %v", entry)
+	}
+}
+
+func TestLogNoGlobal(t *testing.T) {
+	// 命名 logger 的注册表是全局的，每次运行使用不同的名称，使测试可以重复运行
+	name := fmt.Sprintf("test-no-global-%d", time.Now().UnixNano())
+	global := zap.L()
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:          "info",
+		NoGlobal:       true,
+		LevelOverrides: map[string]string{name: "error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	if zap.L() != global {
+		t.Fatal("NoGlobal should keep the zap global logger")
+	}
+	if _, ok := log.NamedLevels()[name]; ok {
+		t.Error("LevelOverrides should only apply on MakeGlobal")
+	}
+
+	restore := logger.MakeGlobal()
+	if zap.L() != logger.Logger {
+		t.Error("MakeGlobal should replace the zap global logger")
+	}
+	if got := log.NamedLevels()[name]; got != "error" {
+		t.Errorf("named level = %q, want error", got)
+	}
+	restore()
+	if zap.L() != global {
+		t.Error("restore should bring back the previous global logger")
+	}
+}
+
+func TestLogHooks(t *testing.T) {
+	var fromConfig, fromAdd []string
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:         "info",
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Hooks: []func(zapcore.Entry) error{func(ent zapcore.Entry) error {
+			fromConfig = append(fromConfig, ent.Message)
+			return nil
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	hooked := logger.AddHook(func(ent zapcore.Entry) error {
+		if ent.Level >= zapcore.WarnLevel {
+			fromAdd = append(fromAdd, ent.Message)
+		}
+		return nil
+	})
+	logger.Info("plain")
+	hooked.Debug("below level")
+	hooked.Warn("warned")
+
+	if want := []string{"plain", "warned"}; !slices.Equal(fromConfig, want) {
+		t.Errorf("Config.Hooks saw %q, want %q", fromConfig, want)
+	}
+	if want := []string{"warned"}; !slices.Equal(fromAdd, want) {
+		t.Errorf("AddHook saw %q, want %q", fromAdd, want)
+	}
+}
+
+func TestLogRedact(t *testing.T) {
+	dir := t.TempDir()
+	allFile := filepath.Join(dir, "all.log")
+	httpFile := filepath.Join(dir, "http.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:         "info",
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		RedactKeys:    "(?i)password",
+		Adaptors: []string{
+			"file://" + allFile,
+			"file://" + httpFile + "?redact-values=email",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("signup", zap.String("password", "hunter2"), zap.String("email", "bob@example.com"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, want := range map[string]string{
+		allFile:  `"email":"bob@example.com"`,
+		httpFile: `"email":"***"`,
+	} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), `"password":"***"`) || !strings.Contains(string(content), want) {
+			t.Errorf("%s = %s, want masked password and %s", filepath.Base(file), content, want)
+		}
+	}
+}
+
+func TestLogFileFilterAndSplitErrors(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:         "info",
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Adaptors:      []string{"file://" + logFile + "?split-errors=true&exclude=healthcheck&deny-fields=payload"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("healthcheck ok")
+	logger.Info("order placed", zap.String("payload", "{}"), zap.Int("order", 1))
+	logger.Error("order failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "healthcheck") || strings.Contains(string(content), "payload") {
+		t.Errorf("filtered content leaked: %s", content)
+	}
+	if !strings.Contains(string(content), `"order":1`) {
+		t.Errorf("expected order field: %s", content)
+	}
+	// 包装 Core 直接调用 Write 时，错误文件仍只接收 warn 及以上
+	errContent, err := os.ReadFile(strings.TrimSuffix(logFile, ".log") + ".error.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(errContent), "\n") != 1 || !strings.Contains(string(errContent), "order failed") {
+		t.Errorf("error file = %s", errContent)
+	}
+}
+
+func TestLogRoutes(t *testing.T) {
+	dir := t.TempDir()
+	appFile, errFile := filepath.Join(dir, "app.log"), filepath.Join(dir, "errors.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:         "info",
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Adaptors:      []string{"file://" + appFile, "file://" + errFile + "?name=errors"},
+		Routes: []log.Route{
+			{Level: "error", To: []string{"errors", "file"}},
+			{To: []string{"file"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("started")
+	logger.Error("failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	app, _ := os.ReadFile(appFile)
+	errs, _ := os.ReadFile(errFile)
+	if !strings.Contains(string(app), "started") || !strings.Contains(string(app), "failed") {
+		t.Errorf("app.log = %s", app)
+	}
+	if strings.Contains(string(errs), "started") || !strings.Contains(string(errs), "failed") {
+		t.Errorf("errors.log = %s", errs)
+	}
+
+	_, err = log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Adaptors: []string{"file://" + appFile},
+		Routes:   []log.Route{{To: []string{"sentry"}}},
+	})
+	if err == nil {
+		t.Error("expected error for unknown adaptor in route")
+	}
+}
+
+func TestLogTruncate(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		MaxFieldSize:  "16b",
+		Adaptors:      []string{"file://" + logFile + "?max-entry=64k"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("upload", zap.String("body", strings.Repeat("x", 100)))
+	logger.Info("huge", zap.Strings("parts", slices.Repeat([]string{"y"}, 100000)))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	if !strings.Contains(lines[0], `"body":"xxxxxxxxxxxxxxxx...truncated","body_length":100`) {
+		t.Errorf("field not truncated: %s", lines[0])
+	}
+	if len(lines[1]) > 64<<10 || !strings.Contains(lines[1], `"truncated":true`) {
+		t.Errorf("entry not limited: %.200s", lines[1])
+	}
+
+	if _, err := log.NewWithConfig(&log.Config{NoGlobal: true, MaxEntrySize: "big"}); err == nil {
+		t.Error("expected error for invalid max entry size")
+	}
+}
+
+func TestLogPresets(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	cfg := log.ProductionConfig("file://" + logFile)
+	cfg.NoGlobal, cfg.ConsoleLevel = true, "fatal"
+	logger, err := log.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一消息超过 100 条后每 100 条输出一条
+	for range 300 {
+		logger.Info("repeated")
+	}
+	logger.Debug("hidden")
+	// 写缓冲中的日志在 Close 时写出
+	if content, _ := os.ReadFile(logFile); len(content) > 0 {
+		t.Errorf("expected buffered output before Close, got %d bytes", len(content))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(content), "\n"); n != 102 {
+		t.Errorf("got %d lines, want 102 after sampling", n)
+	}
+
+	dev := log.DevelopmentConfig()
+	if dev.Level != "debug" || dev.Sampling != nil || dev.FileBufferSize != "" {
+		t.Errorf("unexpected development preset: %+v", dev)
+	}
+	if _, err := log.NewWithConfig(&log.Config{NoGlobal: true, FileBufferSize: "big"}); err == nil {
+		t.Error("expected error for invalid file buffer size")
+	}
+}
+
+func TestLogFlightRecorder(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:       true,
+		ConsoleOutput:  "stderr",
+		Level:          "info",
+		FlightRecorder: 10,
+		Adaptors:       []string{"file://" + logFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("connecting", zap.String("host", "db1"))
+	logger.Info("serving")
+	logger.Error("query failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines: %s", len(lines), content)
+	}
+	var replayed, failed map[string]any
+	_ = json.Unmarshal([]byte(lines[1]), &replayed)
+	_ = json.Unmarshal([]byte(lines[2]), &failed)
+	if replayed["msg"] != "connecting" || replayed["flight_level"] != "debug" || replayed["host"] != "db1" {
+		t.Errorf("replayed entry = %v", replayed)
+	}
+	if failed["msg"] != "query failed" || failed["flight_id"] == nil || failed["flight_id"] != replayed["flight_id"] {
+		t.Errorf("error entry = %v", failed)
+	}
+}
+
+func TestRecoverAndFlush(t *testing.T) {
+	received := make(chan []map[string]any, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		received <- entries
+	}))
+	defer srv.Close()
+
+	for _, global := range []bool{false, true} {
+		logger, err := log.NewWithConfig(&log.Config{
+			NoGlobal:      true,
+			ConsoleOutput: "stderr",
+			Adaptors:      []string{srv.URL + "/logs?flush-interval=1h"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if global {
+			defer logger.MakeGlobal()()
+		}
+
+		recovered := func() (r any) {
+			defer func() { r = recover() }()
+			if global {
+				defer log.RecoverAndFlush()
+			} else {
+				defer logger.RecoverAndFlush()
+			}
+			logger.Info("before panic")
+			panic("boom")
+		}()
+		if recovered != "boom" {
+			t.Fatalf("recovered %v, want re-panic with boom", recovered)
+		}
+
+		// 缓冲中的日志与 panic 日志在重新 panic 前已发送
+		select {
+		case entries := <-received:
+			if len(entries) != 2 || entries[0]["msg"] != "before panic" || entries[1]["panic"] != "boom" {
+				t.Fatalf("received %v", entries)
+			}
+			if stack, _ := entries[1]["stacktrace"].(string); !strings.Contains(stack, "TestRecoverAndFlush") {
+				t.Errorf("stacktrace does not include the panic site: %q", stack)
+			}
+		default:
+			t.Fatal("buffered entries were not flushed")
+		}
+	}
+}
+
+func TestLogTimeZone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	dir := t.TempDir()
+	utcFile, localFile := filepath.Join(dir, "utc.log"), filepath.Join(dir, "shanghai.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		TimeZone:      "UTC",
+		Adaptors: []string{
+			"file://" + utcFile + "?format=logfmt",
+			"file://" + localFile + "?format=logfmt&tz=Asia/Shanghai",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("tick")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, loc := range map[string]*time.Location{utcFile: time.UTC, localFile: shanghai} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts, _, _ := strings.Cut(strings.TrimPrefix(string(content), "ts="), " ")
+		parsed, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if got, want := parsed.Format("-07:00"), time.Now().In(loc).Format("-07:00"); got != want {
+			t.Errorf("%s: timestamp %s not in %s", path, ts, loc)
+		}
+	}
+
+	if _, err := log.NewWithConfig(&log.Config{NoGlobal: true, TimeZone: "Mars/Olympus"}); err == nil {
+		t.Error("expected error for invalid time zone")
+	}
+}
+
+func TestLogInvalidLevel(t *testing.T) {
+	_, err := log.NewWithConfig(&log.Config{Level: "invalid"})
+	if err == nil {
+		t.Error("expected error for invalid log level")
+	}
+}
+
+func TestLogMultipleAdaptors(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile1 := filepath.Join(tmpDir, "test1.log")
+	logFile2 := filepath.Join(tmpDir, "test2.log")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level: "info",
+		Adaptors: []string{
+			"file://" + logFile1,
+			"file://" + logFile2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logPrint()
+
+	// 验证两个文件都被创建
+	if _, err := os.Stat(logFile1); os.IsNotExist(err) {
+		t.Error("log file 1 not created")
+	}
 	if _, err := os.Stat(logFile2); os.IsNotExist(err) {
 		t.Error("log file 2 not created")
 	}
 }
+
+func TestLogFileEncrypted(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "secret.log")
+	t.Setenv("TEST_LOG_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+	logger, err := log.NewWithConfig(&log.Config{
+		Level:    "info",
+		Adaptors: []string{"file://" + logFile + "?encrypt=aes-gcm&key-env=TEST_LOG_KEY"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.L().Info("card number", zap.String("pan", "4111111111111111"))
+	zap.L().Info("second entry")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "4111111111111111") || strings.Contains(string(raw), "card number") {
+		t.Fatalf("expected file content to be encrypted, got %q", string(raw))
+	}
+
+	key, err := log.ParseEncryptionKey(os.Getenv("TEST_LOG_KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plain strings.Builder
+	if err := log.DecryptFile(logFile, key, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain.String(), "4111111111111111") || !strings.Contains(plain.String(), "second entry") {
+		t.Fatalf("expected decrypted entries, got %q", plain.String())
+	}
+
+	wrongKey := make([]byte, 32)
+	if err := log.DecryptFile(logFile, wrongKey, io.Discard); err == nil {
+		t.Fatal("expected decrypt with wrong key to fail")
+	}
+}
+
+func TestLogEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile, envFile := filepath.Join(dir, "cfg.log"), filepath.Join(dir, "env.log")
+	cfg := &log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Level:         "info",
+		Adaptors:      []string{"file://" + cfgFile},
+	}
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_ADAPTORS", "file://"+envFile+"?format=logfmt")
+	logger, err := log.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("verbose")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "info" || len(cfg.Adaptors) != 1 || cfg.Adaptors[0] != "file://"+cfgFile {
+		t.Errorf("config modified: %+v", cfg)
+	}
+	if _, err := os.Stat(cfgFile); !os.IsNotExist(err) {
+		t.Errorf("configured adaptor should be replaced: %v", err)
+	}
+	if content, err := os.ReadFile(envFile); err != nil || !strings.Contains(string(content), "msg=verbose") {
+		t.Errorf("env adaptor = %q, %v", content, err)
+	}
+
+	t.Run("prefix", func(t *testing.T) {
+		t.Setenv("APP_LOG_LEVEL", "bogus")
+		if _, err := log.NewWithConfig(&log.Config{NoGlobal: true, EnvPrefix: "APP_LOG_"}); err == nil || !strings.Contains(err.Error(), "APP_LOG_LEVEL") {
+			t.Errorf("expected error naming APP_LOG_LEVEL, got %v", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "bogus")
+		logger, err := log.NewWithConfig(&log.Config{NoGlobal: true, ConsoleOutput: "stderr", EnvPrefix: "-"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = logger.Close()
+	})
+}
+
+func TestLogProbe(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	var out bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		ConsoleOutput:     "stderr",
+		DiagnosticsWriter: &out,
+		Adaptors:          []string{srv.URL + "/logs?probe=true&timeout=1s", srv.URL + "/other?timeout=1s"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 探测在 NewWithConfig 返回前完成
+	got := out.String()
+	stats := logger.Stats()
+	_ = logger.Close()
+	if !strings.Contains(got, "/logs?probe=true&timeout=1s: probe failed") || strings.Contains(got, "/other") {
+		t.Errorf("diagnostics:\n%s", got)
+	}
+	if len(stats) != 2 || !strings.Contains(stats[0].LastError, "probe failed") || stats[1].LastError != "" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestLogRelativeFilePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:      true,
+		ConsoleOutput: "stderr",
+		Adaptors:      []string{"file://./logs/app.log", "file:plain.log"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("relative")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"logs/app.log", "plain.log"} {
+		if content, err := os.ReadFile(filepath.Join(dir, path)); err != nil || !strings.Contains(string(content), "relative") {
+			t.Errorf("%s = %q, %v", path, content, err)
+		}
+	}
+}
+
+func TestLogConsoleDisabled(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	console := false
+	output := captureStdout(t, func() {
+		logger, err := log.NewWithConfig(&log.Config{
+			NoGlobal: true,
+			Console:  &console,
+			Adaptors: []string{"file://" + logFile},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("file only")
+		_ = logger.Close()
+	})
+	if output != "" {
+		t.Errorf("console output = %q, want empty", output)
+	}
+	if content, err := os.ReadFile(logFile); err != nil || !strings.Contains(string(content), "file only") {
+		t.Errorf("file = %q, %v", content, err)
+	}
+}
+
+func TestLogMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{srv.URL + "/logs?batch-size=2", "file://" + filepath.Join(t.TempDir(), "app.log")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		logger.Info("hello")
+	}
+	// batch-size=2 时两个批次都会立即发送
+	for deadline := time.Now().Add(2 * time.Second); logger.Stats()[0].QueueLatency.Count < 4 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	logger.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	_ = logger.Close()
+	got := rec.Body.String()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for _, want := range []string{
+		"# TYPE log_adaptor_queue_latency_seconds histogram\n",
+		`log_adaptor_queue_capacity{adaptor="` + srv.URL + `/logs?batch-size=2"} 1024`,
+		`log_adaptor_queue_latency_seconds_bucket{adaptor="` + srv.URL + `/logs?batch-size=2",le="+Inf"} 4`,
+		`log_adaptor_queue_latency_seconds_count{adaptor="` + srv.URL + `/logs?batch-size=2"} 4`,
+		`log_adaptor_written_bytes_total{adaptor="file://`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `log_adaptor_queued{adaptor="file://`) {
+		t.Errorf("unexpected metrics:\n%s", got)
+	}
+}
+
+func TestLogSyslogTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"syslog+tls://" + ln.Addr().String() + "?tls-ca=" + caFile + "&facility=local0&app-name=billing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("audit event", zap.String("user", "alice"))
+	_ = logger.Close()
+
+	select {
+	case got := <-received:
+		// 默认 RFC5424 格式，local0.warning = 16*8+4
+		n, msg, _ := strings.Cut(got, " ")
+		if n != fmt.Sprint(len(msg)) || !strings.HasPrefix(msg, "<132>1 ") || !strings.Contains(msg, " billing ") ||
+			!strings.Contains(msg, `user="alice"`) || !strings.HasSuffix(msg, "audit event") {
+			t.Errorf("received %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected syslog message")
+	}
+}
+
+func TestLogInfluxDB(t *testing.T) {
+	type request struct {
+		query, auth, contentType, body string
+	}
+	requests := make(chan request, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.URL.Path + "?" + r.URL.RawQuery, r.Header.Get("Authorization"), r.Header.Get("Content-Type"), string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"influxdb://" + host + "/app?org=acme&token=s3cret&tags=route&fields=status,latency"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("request", zap.String("route", "/users"), zap.Int("status", 200), zap.Duration("latency", time.Millisecond), zap.String("user", "bob"))
+	logger.Error("db down")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-requests
+	lines := strings.Split(strings.TrimSpace(req.body), "\n")
+	if req.query != "/api/v2/write?bucket=app&org=acme&precision=ns" || req.auth != "Token s3cret" || !strings.HasPrefix(req.contentType, "text/plain") || len(lines) != 2 {
+		t.Fatalf("request = %+v", req)
+	}
+	if !regexp.MustCompile(`^logs,level=info,route=/users message="request",count=1i,status=200i,latency=1000000i \d+$`).MatchString(lines[0]) ||
+		!strings.HasPrefix(lines[1], `logs,level=error message="db down",count=1i `) {
+		t.Errorf("lines = %q", lines)
+	}
+	if dropped := logger.Dropped(); len(dropped) != 1 || strings.Contains(fmt.Sprint(dropped), "s3cret") {
+		t.Errorf("Dropped() = %v", dropped)
+	}
+}
+
+func TestLogGrafanaCloud(t *testing.T) {
+	type push struct {
+		user, password string
+		body           string
+	}
+	pushes := make(chan push, 4)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		pushes <- push{user, password, string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"grafanacloud://123456:glc_token@" + strings.TrimPrefix(srv.URL, "https://") + "?insecure=true&labels=service:api&format=logfmt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello", zap.String("user", "bob"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := <-pushes
+	var body struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(got.body), &body); err != nil {
+		t.Fatalf("%s: %v", got.body, err)
+	}
+	if got.user != "123456" || got.password != "glc_token" || len(body.Streams) != 1 ||
+		body.Streams[0].Stream["service"] != "api" || body.Streams[0].Stream["level"] != "info" ||
+		!strings.Contains(body.Streams[0].Values[0][1], `msg=hello user=bob`) {
+		t.Errorf("push = %+v", got)
+	}
+}
+
+func TestLogVector(t *testing.T) {
+	bodies := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- r.Header.Get("Content-Type") + "\n" + string(body)
+	}))
+	defer srv.Close()
+
+	// Vector API 不健康时探测失败并记录到运行状态，日志照常发送
+	host := strings.TrimPrefix(srv.URL, "http://")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Probe:    true,
+		Adaptors: []string{"vector://" + host + "?health=" + url.QueryEscape(srv.URL+"/health")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("a")
+	logger.Info("b")
+	stats := logger.Stats()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || !strings.Contains(stats[0].LastError, "503") {
+		t.Errorf("stats = %+v", stats)
+	}
+	got := <-bodies
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if lines[0] != "application/x-ndjson" || len(lines) != 3 || !strings.Contains(lines[1], `"msg":"a"`) || !strings.Contains(lines[2], `"msg":"b"`) {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestLogPushgateway(t *testing.T) {
+	bodies := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- r.URL.Path + "\n" + string(body)
+	}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"pushgateway://" + strings.TrimPrefix(srv.URL, "http://") + "/app?instance=i-1&level=warn"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("ignored")
+	logger.Error("failed", zap.String("detail", "not stored"))
+	logger.Named("worker").Warn("slow")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := <-bodies
+	if !strings.HasPrefix(got, "/metrics/job/app/instance/i-1\n") || strings.Contains(got, "info") || strings.Contains(got, "not stored") ||
+		!strings.Contains(got, `log_entries_total{level="error",logger=""} 1`) || !strings.Contains(got, `log_entries_total{level="warn",logger="worker"} 1`) {
+		t.Errorf("pushed:\n%s", got)
+	}
+}
+
+func TestLogConsulLastError(t *testing.T) {
+	values := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		values <- r.URL.Path + "\n" + string(body)
+		_, _ = io.WriteString(w, "true")
+	}))
+	defer srv.Close()
+
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"consul://" + strings.TrimPrefix(srv.URL, "http://") + "/app/last-error?interval=1h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("ignored")
+	logger.Error("db down", zap.String("db", "orders"))
+	logger.Error("db still down", zap.String("db", "orders"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := <-values
+	if !strings.HasPrefix(got, "/v1/kv/app/last-error\n") || !strings.Contains(got, `"count":2,"total":2`) ||
+		!strings.Contains(got, `"msg":"db still down"`) || !strings.Contains(got, `"db":"orders"`) {
+		t.Errorf("published:\n%s", got)
+	}
+}
+
+func TestLogFailover(t *testing.T) {
+	var healthy atomic.Bool
+	received := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			received <- string(body)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "fallback.log")
+	var diag bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		Console:           new(bool),
+		DiagnosticsWriter: &diag,
+		Adaptors: []string{"failover://?interval=20ms&primary=" + url.QueryEscape(srv.URL+"?max-retries=0&flush-interval=5ms&header.X-API-Key=APIKEY123") +
+			"&secondary=" + url.QueryEscape("file://"+path)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitActive := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for logger.Stats()[0].Active != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("stats = %+v", logger.Stats()[0])
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 主适配器发送失败后切换到备用文件
+	logger.Info("lost")
+	waitActive("secondary")
+	logger.Info("to file")
+	// 主适配器恢复后切回
+	healthy.Store(true)
+	waitActive("primary")
+	logger.Info("to collector")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"msg":"to file"`) || strings.Contains(string(data), "to collector") {
+		t.Errorf("fallback file:\n%s", data)
+	}
+	if got := <-received; !strings.Contains(got, `"msg":"to collector"`) {
+		t.Errorf("collector received %s", got)
+	}
+	if st := logger.Stats()[0]; st.Failovers != 1 || strings.Contains(st.Name, "APIKEY123") {
+		t.Errorf("stats = %+v", st)
+	}
+	if !strings.Contains(diag.String(), "primary unhealthy, switched to secondary") {
+		t.Errorf("diagnostics:\n%s", diag.String())
+	}
+}
+
+func TestLogMirror(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		Console:           new(bool),
+		DiagnosticsWriter: io.Discard,
+		Adaptors: []string{"mirror://?primary=" + url.QueryEscape("file://"+path) +
+			"&shadow=" + url.QueryEscape(srv.URL+"?max-retries=0&flush-interval=5ms&auth=bearer:SECRETTOKEN")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello")
+	// 影子适配器发送失败不影响主适配器
+	deadline := time.Now().Add(3 * time.Second)
+	for logger.Stats()[0].Mirror.Shadow.LastError == "" {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v", logger.Stats()[0].Mirror)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	st := logger.Stats()[0]
+	if st.Mirror.Entries != 1 || st.Mirror.PrimaryErrors != 0 || st.Mirror.PrimaryLatency.Count != 1 || !strings.Contains(st.Mirror.Shadow.LastError, "503") {
+		t.Errorf("mirror stats = %+v", st.Mirror)
+	}
+	// 嵌套 DSN 中的凭据不出现在适配器名称中
+	for name := range logger.Dropped() {
+		if strings.Contains(name, "SECRETTOKEN") || strings.Contains(st.Name, "SECRETTOKEN") || !strings.Contains(name, "shadow=") {
+			t.Errorf("adaptor name = %s", name)
+		}
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "hello") {
+		t.Errorf("primary file = %q", data)
+	}
+}
+
+func TestLogChaos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var diag bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		Console:           new(bool),
+		DiagnosticsWriter: &diag,
+		Adaptors:          []string{"chaos://?drop-rate=0.3&error-rate=0.1&seed=7&target=" + url.QueryEscape("file://"+path)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 200 {
+		logger.Info("chaos")
+	}
+	stats := logger.Stats()
+	_ = logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped := stats[0].Dropped
+	written := bytes.Count(data, []byte("\n"))
+	// 其余日志写入失败，诊断输出限速只报告第一次
+	if failed := 200 - written - int(dropped); failed < 5 || dropped < 40 || !strings.Contains(diag.String(), "chaos: injected write failure") {
+		t.Errorf("written = %d, dropped = %d, failed = %d, diagnostics: %s", written, dropped, failed, diag.String())
+	}
+	if len(stats) != 1 || stats[0].Written != uint64(len(data)) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	err = (&log.Config{Adaptors: []string{"chaos://?drop-rate=2&target=" + url.QueryEscape("file:///tmp/app.log?max-sise=1m")}}).Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid drop-rate") || !strings.Contains(err.Error(), "target: unknown parameter max-sise") {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestLogSample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{"sample://rate=0.25&key=user&of=" + url.QueryEscape("file://"+path)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 400 {
+		logger.Info("visit", zap.Int("user", i))
+	}
+	logger.Warn("slow")
+	stats := logger.Stats()
+	_ = logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	visits := bytes.Count(data, []byte(`"msg":"visit"`))
+	if visits < 60 || visits > 140 || !bytes.Contains(data, []byte(`"msg":"slow"`)) {
+		t.Errorf("visits = %d:\n%s", visits, data)
+	}
+	if st := stats[0]; st.SampledOut != uint64(400-visits) || st.Dropped != 0 || st.Written != uint64(len(data)) {
+		t.Errorf("stats = %+v", st)
+	}
+
+	err = (&log.Config{Adaptors: []string{"sample://rate=0&of=" + url.QueryEscape("file:///tmp/app.log?max-sise=1m")}}).Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid rate") || !strings.Contains(err.Error(), "of: unknown parameter max-sise") {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestLogRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal: true,
+		Console:  new(bool),
+		Adaptors: []string{
+			"record://" + filepath.Join(dir, "app.wal") + "?format=logfmt",
+			"file://" + filepath.Join(dir, "app.log") + "?format=logfmt",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("recorded", zap.String("path", "/api"), zap.Int("status", 500))
+	logger.Warn("second")
+	_ = logger.Close()
+
+	if err := log.Replay(filepath.Join(dir, "app.wal"), "file://"+filepath.Join(dir, "replayed.log")); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	got, _ := os.ReadFile(filepath.Join(dir, "replayed.log"))
+	if len(want) == 0 || !bytes.Equal(got, want) {
+		t.Errorf("replayed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLogTenants(t *testing.T) {
+	dir := t.TempDir()
+	var diag bytes.Buffer
+	logger, err := log.NewWithConfig(&log.Config{
+		NoGlobal:          true,
+		Console:           new(bool),
+		DiagnosticsWriter: &diag,
+		Adaptors: []string{
+			"file://" + dir + "/{tenant}/app.log?max-tenants=4&name=tenants",
+			"file://" + dir + "/acme-audit.log?name=audit",
+		},
+		Routes: []log.Route{
+			{Tenant: "acme", Level: "warn", To: []string{"tenants", "audit"}},
+			{To: []string{"tenants"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := log.NewContext(context.Background(), logger.Logger)
+	acme := log.ForTenant(ctx, "acme")
+	if got := log.TenantFromContext(acme); got != "acme" {
+		t.Errorf("TenantFromContext = %q", got)
+	}
+	log.FromContext(acme).Info("acme info")
+	log.FromContext(acme).Warn("acme warn")
+	log.FromContext(log.ForTenant(ctx, "globex")).Info("globex info")
+	log.FromContext(ctx).Info("no tenant")
+	log.FromContext(ctx).Info("per entry", zap.String(log.TenantKey, "initech"))
+	log.FromContext(log.ForTenant(ctx, "../etc")).Info("invalid tenant")
+	log.FromContext(log.ForTenant(ctx, "umbrella")).Info("over limit")
+	stats := logger.Stats()
+	_ = logger.Close()
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return string(data)
+	}
+	for name, want := range map[string][]string{
+		"acme/app.log":    {"acme info", "acme warn"},
+		"globex/app.log":  {"globex info"},
+		"default/app.log": {"no tenant", "invalid tenant", "over limit"},
+		"acme-audit.log":  {"acme warn"},
+	} {
+		got := read(name)
+		if strings.Count(got, "\n") != len(want) {
+			t.Errorf("%s:\n%s", name, got)
+		}
+		for _, msg := range want {
+			if !strings.Contains(got, msg) {
+				t.Errorf("%s missing %q:\n%s", name, msg, got)
+			}
+		}
+	}
+	// 默认租户、acme、globex、initech 之后达到 max-tenants
+	if got := read("initech/app.log"); !strings.Contains(got, "per entry") {
+		t.Errorf("initech/app.log:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "umbrella")); !os.IsNotExist(err) {
+		t.Errorf("umbrella tenant should not be created: %v", err)
+	}
+	if !strings.Contains(diag.String(), "more than 4 tenants") {
+		t.Errorf("diagnostics: %s", diag.String())
+	}
+	if len(stats) != 2 || stats[0].Written == 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,81 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// metric Prometheus 文本格式中的一个指标
+type metric struct {
+	name, typ, help string
+	value           func(st *AdaptorStats) (float64, bool) // 返回 false 表示该适配器没有此指标
+}
+
+var adaptorMetrics = []metric{
+	{"log_adaptor_queued", "gauge", "Log entries waiting in the adaptor buffer.", queueMetric(func(st *AdaptorStats) int { return st.Queued })},
+	{"log_adaptor_queue_capacity", "gauge", "Capacity of the adaptor buffer in log entries.", queueMetric(func(st *AdaptorStats) int { return st.QueueSize })},
+	{"log_adaptor_queue_peak", "gauge", "Highest number of log entries seen in the adaptor buffer.", queueMetric(func(st *AdaptorStats) int { return st.QueuePeak })},
+	{"log_adaptor_spooled_batches", "gauge", "Batches waiting in the disk spool.", func(st *AdaptorStats) (float64, bool) { return float64(st.Spooled), st.QueueSize > 0 }},
+	{"log_adaptor_spool_bytes", "gauge", "Bytes used by the disk spool.", func(st *AdaptorStats) (float64, bool) { return float64(st.SpoolBytes), st.QueueSize > 0 }},
+	{"log_adaptor_dropped_total", "counter", "Log entries dropped by the adaptor.", func(st *AdaptorStats) (float64, bool) { return float64(st.Dropped), true }},
+	{"log_adaptor_written_bytes_total", "counter", "Bytes written or sent by the adaptor.", func(st *AdaptorStats) (float64, bool) { return float64(st.Written), true }},
+}
+
+// queueMetric 只有带缓冲区的适配器 (QueueSize > 0) 才有的指标
+func queueMetric(f func(st *AdaptorStats) int) func(st *AdaptorStats) (float64, bool) {
+	return func(st *AdaptorStats) (float64, bool) { return float64(f(st)), st.QueueSize > 0 }
+}
+
+// WriteMetrics 以 Prometheus 文本格式写出各适配器的运行状态（见 Stats），适配器以脱敏后的 DSN 作为 adaptor 标签：
+// 缓冲区深度、容量与最高值，入队到发送完成的延迟直方图 log_adaptor_queue_latency_seconds，
+// 磁盘队列、丢弃条数与写入字节数。不依赖 Prometheus 客户端库，可直接挂到现有的 /metrics 接口
+func (l *Logger) WriteMetrics(w io.Writer) error {
+	stats := l.Stats()
+	bw := bufio.NewWriter(w)
+	for _, m := range adaptorMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		for i := range stats {
+			if v, ok := m.value(&stats[i]); ok {
+				fmt.Fprintf(bw, "%s{adaptor=%s} %s\n", m.name, metricLabel(stats[i].Name), formatMetric(v))
+			}
+		}
+	}
+
+	const name = "log_adaptor_queue_latency_seconds"
+	fmt.Fprintf(bw, "# HELP %s Time from entering the adaptor buffer until the batch was sent.\n# TYPE %s histogram\n", name, name)
+	for _, st := range stats {
+		if st.QueueSize == 0 {
+			continue
+		}
+		label, h := metricLabel(st.Name), st.QueueLatency
+		for i, bound := range latencyBuckets {
+			var n uint64
+			if i < len(h.Buckets) {
+				n = h.Buckets[i]
+			}
+			fmt.Fprintf(bw, "%s_bucket{adaptor=%s,le=\"%s\"} %d\n", name, label, formatMetric(bound.Seconds()), n)
+		}
+		fmt.Fprintf(bw, "%s_bucket{adaptor=%s,le=\"+Inf\"} %d\n", name, label, h.Count)
+		fmt.Fprintf(bw, "%s_sum{adaptor=%s} %s\n", name, label, formatMetric(h.Sum.Seconds()))
+		fmt.Fprintf(bw, "%s_count{adaptor=%s} %d\n", name, label, h.Count)
+	}
+	return bw.Flush()
+}
+
+// MetricsHandler 返回输出 WriteMetrics 的 http.Handler
+func (l *Logger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = l.WriteMetrics(w)
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func metricLabel(v string) string { return `"` + labelEscaper.Replace(v) + `"` }
+
+func formatMetric(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
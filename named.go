@@ -0,0 +1,103 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// namedRegistry 命名子 logger 的缓存与各自的级别
+var namedRegistry = struct {
+	sync.Mutex
+	entries map[string]*namedEntry
+}{entries: make(map[string]*namedEntry)}
+
+// namedEntry 单个命名 logger 的状态，base 变化（重新创建全局 logger）时重建 logger
+type namedEntry struct {
+	level  zap.AtomicLevel
+	set    bool
+	base   *zap.Logger
+	logger *zap.Logger
+}
+
+// Named 返回全局 logger 上名为 name 的子 logger，相同名称返回同一实例。
+// 子 logger 的级别可通过 SetNamedLevel 或 Config.LevelOverrides 调整，
+// 只能在各输出自身级别的基础上进一步收紧
+func Named(name string) *zap.Logger {
+	namedRegistry.Lock()
+	defer namedRegistry.Unlock()
+	e := namedEntryLocked(name)
+	if base := zap.L(); e.logger == nil || e.base != base {
+		e.base = base
+		e.logger = base.Named(name).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &namedCore{Core: core, level: e.level}
+		}))
+	}
+	return e.logger
+}
+
+// SetNamedLevel 设置命名 logger 的级别，名称尚未使用时先记录，在之后的 Named 中生效
+func SetNamedLevel(name, level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid level for logger %q: %w", name, err)
+	}
+	setNamedLevel(name, lvl)
+	return nil
+}
+
+func setNamedLevel(name string, lvl zapcore.Level) {
+	namedRegistry.Lock()
+	defer namedRegistry.Unlock()
+	e := namedEntryLocked(name)
+	e.level.SetLevel(lvl)
+	e.set = true
+}
+
+// NamedLevels 返回已注册的命名 logger 及其级别，未单独设置级别的为空字符串
+func NamedLevels() map[string]string {
+	namedRegistry.Lock()
+	defer namedRegistry.Unlock()
+	levels := make(map[string]string, len(namedRegistry.entries))
+	for name, e := range namedRegistry.entries {
+		if e.set {
+			levels[name] = e.level.String()
+		} else {
+			levels[name] = ""
+		}
+	}
+	return levels
+}
+
+func namedEntryLocked(name string) *namedEntry {
+	e, ok := namedRegistry.entries[name]
+	if !ok {
+		// 未设置级别时不额外过滤
+		e = &namedEntry{level: zap.NewAtomicLevelAt(zapcore.DebugLevel)}
+		namedRegistry.entries[name] = e
+	}
+	return e
+}
+
+// namedCore 在原 Core 的级别之上叠加命名 logger 的级别
+type namedCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *namedCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *namedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *namedCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// resetNamed 测试结束时移除测试注册的命名 logger，使测试可以重复运行
+func resetNamed(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		namedRegistry.Lock()
+		defer namedRegistry.Unlock()
+		for _, name := range names {
+			delete(namedRegistry.entries, name)
+		}
+	})
+}
+
+func TestNamed(t *testing.T) {
+	resetNamed(t, "test-db", "test-cache")
+	core, logs := observer.New(zap.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	defer restore()
+
+	db := Named("test-db")
+	if Named("test-db") != db {
+		t.Error("Named should return the cached logger")
+	}
+	db.Debug("query")
+	if err := SetNamedLevel("test-db", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	db.Info("filtered")
+	db.With(zap.Int("conn", 1)).Info("filtered too")
+	db.Warn("slow query")
+
+	var got []string
+	for _, ent := range logs.AllUntimed() {
+		if ent.LoggerName != "test-db" {
+			t.Errorf("logger name = %q, want test-db", ent.LoggerName)
+		}
+		got = append(got, ent.Message)
+	}
+	if len(got) != 2 || got[0] != "query" || got[1] != "slow query" {
+		t.Errorf("got %q, want [query slow query]", got)
+	}
+
+	// 级别可以在首次使用前设置，替换全局 logger 后重建子 logger
+	if err := SetNamedLevel("test-cache", "error"); err != nil {
+		t.Fatal(err)
+	}
+	core2, logs2 := observer.New(zap.DebugLevel)
+	zap.ReplaceGlobals(zap.New(core2))
+	Named("test-cache").Warn("filtered")
+	Named("test-db").Warn("rebuilt")
+	if logs2.Len() != 1 || logs.Len() != 2 {
+		t.Errorf("entries after replace: old=%d new=%d, want 2 1", logs.Len(), logs2.Len())
+	}
+
+	levels := NamedLevels()
+	if levels["test-db"] != "warn" || levels["test-cache"] != "error" {
+		t.Errorf("levels = %v", levels)
+	}
+	if err := SetNamedLevel("test-db", "loud"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
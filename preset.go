@@ -0,0 +1,36 @@
+package log
+
+import "go.uber.org/zap"
+
+// ProductionConfig 生产环境预设：JSON 输出、info 级别、error 及以上记录调用栈，
+// 每秒同一消息超过 100 条后每 100 条输出一条，文件适配器默认 256k 写缓冲、每秒刷新
+func ProductionConfig(adaptors ...string) *Config {
+	return &Config{
+		Mode:            "server",
+		Level:           "info",
+		Adaptors:        adaptors,
+		StacktraceLevel: "error",
+		FileBufferSize:  "256k",
+		Sampling:        &zap.SamplingConfig{Initial: 100, Thereafter: 100},
+	}
+}
+
+// DevelopmentConfig 开发环境预设：控制台可读格式、debug 级别、warn 及以上记录调用栈，不采样也不缓冲
+func DevelopmentConfig(adaptors ...string) *Config {
+	return &Config{
+		Mode:            "local",
+		Level:           "debug",
+		Adaptors:        adaptors,
+		StacktraceLevel: "warn",
+	}
+}
+
+// NewProduction 使用 ProductionConfig 创建日志实例
+func NewProduction(adaptors ...string) (*Logger, error) {
+	return NewWithConfig(ProductionConfig(adaptors...))
+}
+
+// NewDevelopment 使用 DevelopmentConfig 创建日志实例
+func NewDevelopment(adaptors ...string) (*Logger, error) {
+	return NewWithConfig(DevelopmentConfig(adaptors...))
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"slices"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry 处理器看到的日志：条目本身与全部字段（包括 With 添加的上下文字段）
+type Entry struct {
+	zapcore.Entry
+	Fields []zapcore.Field
+}
+
+// Processor 在日志进入各输出之前处理日志：返回 false 丢弃该条日志，
+// 否则返回的 Entry（可以是修改后的原 Entry 或新的 Entry）交给下一个处理器
+type Processor interface {
+	Process(*Entry) (*Entry, bool)
+}
+
+// ProcessorFunc 函数形式的 Processor
+type ProcessorFunc func(*Entry) (*Entry, bool)
+
+func (f ProcessorFunc) Process(e *Entry) (*Entry, bool) { return f(e) }
+
+// WithProcessors 返回在各输出之前依次执行处理器的 Logger。
+// 处理器只能看到之后通过 With 添加的字段，需要处理全部字段时使用 Config.Processors
+func (l *Logger) WithProcessors(processors ...Processor) *Logger {
+	child := *l
+	child.Logger = l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newProcessorCore(core, processors)
+	}))
+	return &child
+}
+
+// processorCore 保存上下文字段，写入时将完整的日志交给处理器链，
+// 再经由内层 Core 的 Check 写出，使修改后的级别、消息对内层的级别、筛选与限流生效
+type processorCore struct {
+	zapcore.Core
+	processors []Processor
+	context    []zapcore.Field
+}
+
+func newProcessorCore(core zapcore.Core, processors []Processor) *processorCore {
+	return &processorCore{Core: core, processors: slices.Clip(processors)}
+}
+
+func (c *processorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &processorCore{
+		Core:       c.Core,
+		processors: c.processors,
+		context:    append(slices.Clip(c.context), fields...),
+	}
+}
+
+func (c *processorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *processorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	e := &Entry{Entry: ent, Fields: make([]zapcore.Field, 0, len(c.context)+len(fields))}
+	e.Fields = append(e.Fields, c.context...)
+	e.Fields = append(e.Fields, fields...)
+	for _, p := range c.processors {
+		var ok bool
+		if e, ok = p.Process(e); !ok || e == nil {
+			return nil
+		}
+	}
+	return writeChecked(c.Core.Check(e.Entry, nil), e.Fields)
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestProcessorCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	processors := []Processor{
+		// 丢弃健康检查
+		ProcessorFunc(func(e *Entry) (*Entry, bool) {
+			return e, !strings.HasPrefix(e.Message, "healthcheck")
+		}),
+		// 补充字段并把超时提升为 warn
+		ProcessorFunc(func(e *Entry) (*Entry, bool) {
+			e.Fields = append(e.Fields, zap.String("region", "cn-north"))
+			for _, f := range e.Fields {
+				if f.Key == "timeout" && f.Type == zapcore.BoolType && f.Integer == 1 {
+					e.Level = zapcore.WarnLevel
+				}
+			}
+			return e, true
+		}),
+	}
+	logger := zap.New(newProcessorCore(core, processors)).With(zap.Bool("timeout", true))
+
+	logger.Info("healthcheck ok")
+	logger.Info("upstream call")
+	logger.Debug("below level")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	ent := entries[0]
+	if ent.Message != "upstream call" || ent.Level != zapcore.WarnLevel {
+		t.Errorf("entry = %s %q", ent.Level, ent.Message)
+	}
+	if fields := ent.ContextMap(); fields["region"] != "cn-north" || fields["timeout"] != true {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestLoggerWithProcessors(t *testing.T) {
+	logger, logs := NewTestLogger(t)
+	masked := logger.WithProcessors(ProcessorFunc(func(e *Entry) (*Entry, bool) {
+		return &Entry{Entry: e.Entry, Fields: []zapcore.Field{zap.String("replaced", "yes")}}, true
+	}))
+	masked.Info("processed", zap.String("secret", "s"))
+	logger.Info("untouched", zap.String("secret", "s"))
+
+	logs.AssertField("processed", zap.String("replaced", "yes"))
+	if fields := logs.FilterMessage("processed").All()[0].ContextMap(); len(fields) != 1 {
+		t.Errorf("fields = %v, want only replaced", fields)
+	}
+	logs.AssertField("untouched", zap.String("secret", "s"))
+}
+
+func TestProcessorCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	var errOut bytes.Buffer
+	logger := zap.New(newProcessorCore(newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel), nil), zap.ErrorOutput(zapcore.AddSync(&errOut)))
+	logger.Info("lost")
+	// 被包装的 Core 的写入错误仍然报告到 ErrorOutput
+	if !strings.Contains(errOut.String(), "disk full") {
+		t.Errorf("ErrorOutput = %q", errOut.String())
+	}
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// global MakeGlobal 设置的 Logger，供 RecoverAndFlush 使用
+var global atomic.Pointer[Logger]
+
+// RecoverAndFlush 在 defer 中使用：发生 panic 时通过全局 Logger (MakeGlobal) 记录 panic 与调用栈，
+// 关闭全部适配器（HTTP 缓冲中的日志会被发送）后重新 panic。没有全局 Logger 时只重新 panic
+//
+//	func main() {
+//		logger, _ := log.New("my-app")
+//		defer log.RecoverAndFlush()
+//		...
+//	}
+func RecoverAndFlush() {
+	if r := recover(); r != nil {
+		if l := global.Load(); l != nil {
+			l.flushPanic(r)
+		}
+		panic(r)
+	}
+}
+
+// RecoverAndFlush 与包级 RecoverAndFlush 相同，使用当前 Logger。
+// 适配器关闭后不再输出日志，适合放在 main 或独立协程的最外层
+func (l *Logger) RecoverAndFlush() {
+	if r := recover(); r != nil {
+		l.flushPanic(r)
+		panic(r)
+	}
+}
+
+// flushPanic 以 error 级别记录 panic，然后关闭适配器。此时仍在 panic 的协程栈上，
+// 调用栈包含 panic 的位置，因此始终输出调用栈，不记录调用位置
+func (l *Logger) flushPanic(r any) {
+	l.Logger.WithOptions(zap.AddStacktrace(zapcore.ErrorLevel), zap.WithCaller(false)).
+		Error("panic recovered", zap.Any("panic", r))
+	_ = l.Close()
+}
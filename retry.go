@@ -0,0 +1,105 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpStatusError 采集端返回的错误状态码
+type httpStatusError struct {
+	retryAfter time.Duration
+	code       int
+}
+
+func (e *httpStatusError) Error() string { return fmt.Sprintf("HTTP error: %d", e.code) }
+
+// newHTTPStatusError 根据响应构建错误，解析 429/503 的 Retry-After
+func newHTTPStatusError(resp *http.Response, now time.Time) *httpStatusError {
+	err := &httpStatusError{code: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		err.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	}
+	return err
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数与 HTTP 日期两种格式
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// isRetryable 判断错误是否值得重试：网络错误、408、429 与 5xx 重试，其它 4xx 不重试
+func isRetryable(err error) bool {
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	switch code := statusErr.code; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	case code >= 500 && code != http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff 指数退避 + 全抖动：在 [0, min(max, base*2^attempt)) 内随机取值，
+// 服务端给出 Retry-After 时优先采用（不超过 max）
+func backoff(attempt int, base, maxDelay time.Duration, err error) time.Duration {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.retryAfter > 0 {
+		return min(statusErr.retryAfter, maxDelay)
+	}
+	delay := maxDelay
+	if attempt < 32 {
+		delay = min(base<<attempt, maxDelay)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay) + 1
+}
+
+// ErrReconnectBackoff 连接失败后的退避期内写入直接失败，不再等待连接超时
+var ErrReconnectBackoff = errors.New("reconnect backoff, dropping log")
+
+// dialBackoff 同步写入的 writer 连接失败后的重连退避：退避期内写入立即返回错误，
+// 避免不可达的服务端让每一次写入都阻塞到连接超时。退避从 100ms 起倍增，最长 30s，连接成功后清零
+type dialBackoff struct {
+	failures int
+	until    time.Time
+	lastErr  error
+}
+
+// check 退避期内返回错误，调用方需持有锁
+func (b *dialBackoff) check(now time.Time) error {
+	if now.Before(b.until) {
+		return fmt.Errorf("%w: %v", ErrReconnectBackoff, b.lastErr)
+	}
+	return nil
+}
+
+// failed 记录一次连接失败并开始退避，调用方需持有锁
+func (b *dialBackoff) failed(now time.Time, err error) {
+	b.until = now.Add(min(100*time.Millisecond<<min(b.failures, 16), 30*time.Second))
+	b.failures++
+	b.lastErr = err
+}
+
+// reset 连接成功后清零，调用方需持有锁
+func (b *dialBackoff) reset() {
+	b.failures, b.until, b.lastErr = 0, time.Time{}, nil
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{&httpStatusError{code: http.StatusBadRequest}, false},
+		{&httpStatusError{code: http.StatusUnauthorized}, false},
+		{&httpStatusError{code: http.StatusRequestEntityTooLarge}, false},
+		{&httpStatusError{code: http.StatusRequestTimeout}, true},
+		{&httpStatusError{code: http.StatusTooManyRequests}, true},
+		{&httpStatusError{code: http.StatusInternalServerError}, true},
+		{&httpStatusError{code: http.StatusNotImplemented}, false},
+		{&httpStatusError{code: http.StatusServiceUnavailable}, true},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.input, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, time.Second
+	for attempt := range 10 {
+		limit := min(base<<attempt, maxDelay)
+		for range 100 {
+			d := backoff(attempt, base, maxDelay, errors.New("network"))
+			if d <= 0 || d > limit {
+				t.Fatalf("backoff(%d) = %v, want (0, %v]", attempt, d, limit)
+			}
+		}
+	}
+
+	retryAfter := &httpStatusError{code: http.StatusTooManyRequests, retryAfter: 300 * time.Millisecond}
+	if d := backoff(0, base, maxDelay, retryAfter); d != 300*time.Millisecond {
+		t.Errorf("backoff with Retry-After = %v, want 300ms", d)
+	}
+	retryAfter.retryAfter = time.Hour
+	if d := backoff(0, base, maxDelay, retryAfter); d != maxDelay {
+		t.Errorf("backoff with long Retry-After = %v, want capped at %v", d, maxDelay)
+	}
+}
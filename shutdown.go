@@ -0,0 +1,95 @@
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// raise 恢复信号的默认处理后重新发送给自身，测试中替换
+var raise = func(sig os.Signal) {
+	signal.Reset(sig)
+	if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+		exit(1)
+	}
+}
+
+// DrainResult 关闭时缓冲日志的处理结果
+type DrainResult struct {
+	Flushed   int // 关闭期间发送成功或写入磁盘队列的条数
+	Abandoned int // 未能在期限内处理而放弃的条数
+}
+
+// contextCloser 关闭时可按 ctx 限定排空时间的资源
+type contextCloser interface {
+	CloseContext(ctx context.Context) (DrainResult, error)
+}
+
+// closeContext 优先按 ctx 关闭资源，不支持时调用 Close
+func closeContext(ctx context.Context, c io.Closer) (DrainResult, error) {
+	if cc, ok := c.(contextCloser); ok {
+		return cc.CloseContext(ctx)
+	}
+	return DrainResult{}, c.Close()
+}
+
+// CloseContext 与 Close 相同，但 ctx 结束时立即停止排空 HTTP 缓冲区（未发送的日志写入磁盘队列或丢弃），
+// 返回关闭期间发送与放弃的日志条数。文件适配器的写缓冲在本地同步写出，不受 ctx 影响
+func (l *Logger) CloseContext(ctx context.Context) (DrainResult, error) {
+	if l.stopSignal != nil {
+		l.stopSignal()
+	}
+	// 先同步，确保包装 Core 中缓存的日志写出
+	_ = l.Logger.Sync()
+	return multiCloser(l.closers).CloseContext(ctx)
+}
+
+// CloseOnSignal 收到 signals（默认 SIGINT、SIGTERM）时在 timeout 内关闭全部适配器（0 表示只受 drain-timeout 限制），
+// 然后恢复该信号的默认处理并重新发送给自身，使进程按原有方式退出，避免进程被终止时丢失最后的日志。
+// 返回取消监听的函数，可重复调用。程序自行处理退出信号时改用 CloseWhenDone 或在退出前调用 CloseContext
+func (l *Logger) CloseOnSignal(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			l.closeWithin(timeout)
+			raise(sig)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// CloseWhenDone ctx 结束时（如 signal.NotifyContext 返回的 ctx）在 timeout 内关闭全部适配器，返回取消监听的函数。
+// 关闭后写入的日志只输出到控制台
+func (l *Logger) CloseWhenDone(ctx context.Context, timeout time.Duration) (stop func()) {
+	cancel := context.AfterFunc(ctx, func() { l.closeWithin(timeout) })
+	return func() { cancel() }
+}
+
+// closeWithin 在 timeout 内关闭全部适配器，0 表示不额外限制
+func (l *Logger) closeWithin(timeout time.Duration) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	_, _ = l.CloseContext(ctx)
+}
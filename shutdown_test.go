@@ -0,0 +1,44 @@
+package log
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// closeRecorder 记录是否被关闭的适配器资源
+type closeRecorder chan struct{}
+
+func (c closeRecorder) Close() error {
+	close(c)
+	return nil
+}
+
+func TestCloseWhenDone(t *testing.T) {
+	closed := make(closeRecorder)
+	l := &Logger{Logger: zap.NewNop(), closers: []io.Closer{closed}}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.CloseWhenDone(ctx, time.Second)
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("adaptors not closed after ctx done")
+	}
+
+	// 取消监听后不再关闭
+	notClosed := make(closeRecorder)
+	l = &Logger{Logger: zap.NewNop(), closers: []io.Closer{notClosed}}
+	ctx, cancel = context.WithCancel(context.Background())
+	l.CloseWhenDone(ctx, time.Second)()
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-notClosed:
+		t.Error("closed after stop")
+	default:
+	}
+}
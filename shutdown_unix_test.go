@@ -0,0 +1,72 @@
+//go:build unix
+
+package log
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCloseOnSignal(t *testing.T) {
+	raised := make(chan os.Signal, 1)
+	prev := raise
+	raise = func(sig os.Signal) { raised <- sig }
+	defer func() { raise = prev }()
+
+	closed := make(closeRecorder)
+	l := &Logger{Logger: zap.NewNop(), closers: []io.Closer{closed}}
+	stop := l.CloseOnSignal(time.Second, syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sig := <-raised:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("raised %v, want SIGUSR1", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not handled")
+	}
+	select {
+	case <-closed:
+	default:
+		t.Error("adaptors not closed before re-raising the signal")
+	}
+}
+
+// TestCloseStopsSignal 关闭 Logger 时取消 CloseOnSignal 的监听，之后的信号不再处理
+func TestCloseStopsSignal(t *testing.T) {
+	raised := make(chan os.Signal, 1)
+	prev := raise
+	raise = func(sig os.Signal) { raised <- sig }
+	defer func() { raise = prev }()
+	// 保持对信号的监听，避免进程按默认处理退出
+	keep := make(chan os.Signal, 1)
+	signal.Notify(keep, syscall.SIGUSR2)
+	defer signal.Stop(keep)
+
+	l := &Logger{Logger: zap.NewNop()}
+	l.stopSignal = l.CloseOnSignal(time.Second, syscall.SIGUSR2)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	<-keep
+	select {
+	case sig := <-raised:
+		t.Errorf("signal %v handled after Close", sig)
+	case <-time.After(50 * time.Millisecond):
+	}
+	// 重复取消不会 panic
+	l.stopSignal()
+}
@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler 将 log/slog 的记录转发到 zap Core，复用 Logger 的适配器、级别与滚动配置。
+// 调用位置取自 slog 记录，不经过 zap.Logger，Logger 的调用栈与 DisableCaller 设置在这里应用
+type slogHandler struct {
+	core          zapcore.Core
+	name          string
+	stacktrace    zapcore.LevelEnabler
+	disableCaller bool
+}
+
+// NewSlogHandler 创建基于 Logger 的 slog.Handler
+func NewSlogHandler(logger *Logger) slog.Handler {
+	return &slogHandler{core: logger.Core(), name: logger.Name(), stacktrace: logger.stacktrace, disableCaller: logger.disableCaller}
+}
+
+// SetSlogDefault 将 slog 默认 logger 设置为转发到当前 Logger
+func (l *Logger) SetSlogDefault() {
+	slog.SetDefault(slog.New(NewSlogHandler(l)))
+}
+
+// slogLevel 将 slog 级别映射为 zap 级别，介于两级之间的向下取整
+func slogLevel(lvl slog.Level) zapcore.Level {
+	switch {
+	case lvl >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case lvl >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case lvl >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return h.core.Enabled(slogLevel(lvl))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:      slogLevel(r.Level),
+		Time:       r.Time,
+		LoggerName: h.name,
+		Message:    r.Message,
+	}
+	if r.PC != 0 && !h.disableCaller {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	if h.stacktrace != nil && h.stacktrace.Enabled(ent.Level) {
+		ce.Stack = slogStack()
+	}
+	fields := make([]zapcore.Field, 0, r.NumAttrs()+3)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String(TraceIDKey, sc.TraceID().String()),
+			zap.String(SpanIDKey, sc.SpanID().String()),
+			zap.String(TraceFlagsKey, sc.TraceFlags().String()),
+		)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, attr)
+		return true
+	})
+	return writeChecked(ce, fields)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zapcore.Field
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, attr)
+	}
+	clone := *h
+	clone.core = h.core.With(fields)
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.core = h.core.With([]zapcore.Field{zap.Namespace(name)})
+	return &clone
+}
+
+// slogStack 返回从 slog 调用方开始的调用栈，跳过 slog 与本处理器的栈帧，格式与 zap 的 Entry.Stack 相同
+func slogStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var b strings.Builder
+	skipping := true
+	for {
+		frame, more := frames.Next()
+		if skipping && (strings.HasPrefix(frame.Function, "log/slog.") || strings.Contains(frame.Function, ".(*slogHandler).")) {
+			if !more {
+				break
+			}
+			continue
+		}
+		skipping = false
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// appendSlogAttr 将 slog 属性转换为 zap 字段，按 slog 约定忽略空属性、展开无名分组
+func appendSlogAttr(fields []zapcore.Field, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			for _, a := range group {
+				fields = appendSlogAttr(fields, a)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+// slogGroup 将 slog 分组编码为嵌套对象
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendSlogAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		f.AddTo(enc)
+	}
+	return nil
+}
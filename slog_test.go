@@ -0,0 +1,97 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandler(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := slog.New(NewSlogHandler(&Logger{Logger: zap.New(core).Named("app")}))
+
+	logger.Debug("dropped")
+	logger.With("service", "api").WithGroup("req").Info("handled",
+		"status", 200,
+		slog.Group("user", "id", 7, "name", "bob"),
+		slog.Group("empty"),
+		slog.Attr{},
+	)
+	logger.Error("failed", "err", errors.New("boom"))
+	logger.Log(context.Background(), slog.LevelWarn+2, "between levels")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	handled := entries[0]
+	if handled.LoggerName != "app" || handled.Level != zapcore.InfoLevel || !handled.Caller.Defined {
+		t.Errorf("entry = %+v", handled.Entry)
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range handled.Context {
+		f.AddTo(enc)
+	}
+	req, _ := enc.Fields["req"].(map[string]any)
+	user, _ := req["user"].(map[string]any)
+	if enc.Fields["service"] != "api" || req["status"] != int64(200) || user["name"] != "bob" {
+		t.Errorf("fields = %v", enc.Fields)
+	}
+	if _, ok := req["empty"]; ok {
+		t.Error("empty group should be omitted")
+	}
+
+	if got := entries[1].ContextMap()["err"]; got != "boom" {
+		t.Errorf("err = %v, want boom", got)
+	}
+	if entries[2].Level != zapcore.WarnLevel {
+		t.Errorf("level = %s, want warn", entries[2].Level)
+	}
+}
+
+// TestSlogHandlerStacktrace error 级别的 slog 记录与 Logger.Error 一样带调用栈，从 slog 的调用方开始
+func TestSlogHandlerStacktrace(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	logger, err := NewWithConfig(&Config{NoGlobal: true, Console: new(bool), Adaptors: []string{"file://" + logFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slogger := slog.New(NewSlogHandler(logger))
+	slogger.Warn("no stack")
+	slogger.Error("failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	var warn, failed map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &warn); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := warn["stacktrace"]; ok {
+		t.Errorf("warn entry has stacktrace: %v", warn)
+	}
+	stack, _ := failed["stacktrace"].(string)
+	if !strings.HasPrefix(stack, "github.com/mulan-ext/log.TestSlogHandlerStacktrace\n") {
+		t.Errorf("stacktrace = %q", stack)
+	}
+}
@@ -0,0 +1,181 @@
+package log
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AdaptorStats 单个适配器的运行状态快照，供健康检查与调试页面展示
+type AdaptorStats struct {
+	Name        string       // 脱敏后的 DSN
+	Queued      int          // 缓冲区中等待发送的日志条数 (HTTP)
+	QueueSize   int          // 缓冲区容量 (HTTP)
+	QueuePeak   int          // 缓冲区中日志条数的历史最高值，用于确定 buffer-size (HTTP)
+	Spooled     int          // 磁盘队列中等待重放的批次数 (HTTP)
+	SpoolBytes  int64        // 磁盘队列占用的字节数 (HTTP)
+	SpoolMax    int64        // 磁盘队列容量上限，0 表示不限制 (HTTP)
+	Dropped     uint64       // 丢弃的日志条数，与 Logger.Dropped 相同
+	Written     uint64       // 成功写入文件或发送的字节数
+	LastError   string       // 最近一次写入或发送失败的原因
+	LastErrorAt time.Time    // 最近一次失败的时间
+	LastSuccess time.Time    // 最近一次成功写入或发送的时间
+	Active      string       // failover 适配器当前写入的目标: primary 或 secondary
+	Failovers   uint64       // failover 适配器切换到 secondary 的次数
+	Mirror      *MirrorStats // mirror 适配器的对比统计，其余字段为主适配器的状态
+	SampledOut  uint64       // sample 适配器按采样比例略过的条数，不计入 Dropped
+
+	// QueueLatency 日志从写入缓冲区到所在批次发送完成（含重试、写入磁盘队列或丢弃）的延迟分布，
+	// 用于确定 batch-size 与 flush-interval (HTTP)
+	QueueLatency Histogram
+}
+
+// statsReporter 可报告运行状态的适配器资源，stats 将自身状态合并到 st 中
+type statsReporter interface {
+	stats(st *AdaptorStats)
+}
+
+// Stats 返回各适配器的运行状态，顺序与 Config.Adaptors 中创建成功的适配器一致
+func (l *Logger) Stats() []AdaptorStats {
+	stats := make([]AdaptorStats, 0, len(l.adaptors))
+	for _, a := range l.adaptors {
+		st := AdaptorStats{Name: a.name}
+		for _, c := range a.counters {
+			st.Dropped += c.Dropped()
+		}
+		if r, ok := a.closer.(statsReporter); ok {
+			r.stats(&st)
+		}
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// MirrorStats mirror 适配器主适配器与影子适配器的对比统计
+type MirrorStats struct {
+	Shadow         AdaptorStats // 影子适配器自身的运行状态（发送失败、发送延迟等）
+	Entries        uint64       // 交给影子适配器的日志条数
+	ShadowDropped  uint64       // 影子队列满而未交给影子适配器的条数
+	PrimaryErrors  uint64       // 主适配器写入失败的条数
+	ShadowErrors   uint64       // 影子适配器写入失败的条数
+	PrimaryLatency Histogram    // 主适配器写入调用的耗时
+	ShadowLatency  Histogram    // 影子适配器写入调用的耗时
+}
+
+// writerStats 记录写入字节数、最近一次成功与失败，可并发使用
+type writerStats struct {
+	written     atomic.Uint64
+	lastSuccess atomic.Int64 // UnixNano，0 表示还没有成功过
+	mu          sync.Mutex
+	lastErr     error
+	lastErrAt   time.Time
+	onError     func(err error)
+}
+
+func (s *writerStats) success(n int) {
+	s.written.Add(uint64(n))
+	s.lastSuccess.Store(time.Now().UnixNano())
+}
+
+func (s *writerStats) failure(err error) {
+	s.mu.Lock()
+	s.lastErr, s.lastErrAt = err, time.Now()
+	s.mu.Unlock()
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// stats 合并到 st：字节数累加，时间取最近的一次
+func (s *writerStats) stats(st *AdaptorStats) {
+	st.Written += s.written.Load()
+	if ns := s.lastSuccess.Load(); ns > 0 {
+		if t := time.Unix(0, ns); t.After(st.LastSuccess) {
+			st.LastSuccess = t
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr != nil && s.lastErrAt.After(st.LastErrorAt) {
+		st.LastError, st.LastErrorAt = s.lastErr.Error(), s.lastErrAt
+	}
+}
+
+// statsWriter 记录底层写入结果的 WriteSyncer
+type statsWriter struct {
+	zapcore.WriteSyncer
+	*writerStats
+}
+
+func (w statsWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	if err != nil {
+		w.failure(err)
+	} else {
+		w.success(n)
+	}
+	return n, err
+}
+
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+}
+
+// LatencyBuckets AdaptorStats.QueueLatency 的桶上限（副本，修改不影响统计）
+var LatencyBuckets = slices.Clone(latencyBuckets[:])
+
+// Histogram 延迟分布快照，与 Prometheus histogram 相同：Buckets[i] 为不超过 LatencyBuckets[i] 的累计条数，
+// 超过最大桶的只计入 Count
+type Histogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets []uint64
+}
+
+// Quantile 估算分位数 q (0~1)：返回第一个累计条数达到 q 的桶上限，超过最大桶时返回 0 表示未知
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	for i, n := range h.Buckets {
+		if n >= rank {
+			return latencyBuckets[i]
+		}
+	}
+	return 0
+}
+
+// latencyHistogram 可并发记录的延迟直方图，counts 最后一项为超过最大桶的条数
+type latencyHistogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Uint64
+	sum    atomic.Int64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(latencyBuckets[:], d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// stats 累加到 st，Buckets 转为累计条数
+func (h *latencyHistogram) stats(st *Histogram) {
+	if st.Buckets == nil {
+		st.Buckets = make([]uint64, len(latencyBuckets))
+	}
+	var total uint64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		if i < len(st.Buckets) {
+			st.Buckets[i] += total
+		}
+	}
+	st.Count += total
+	st.Sum += time.Duration(h.sum.Load())
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StdLogger 返回标准库 *log.Logger，写入的每一行以指定级别重新输出到当前 Logger，
+// 调用位置指向调用标准库 logger 的代码，供遗留代码与第三方库使用
+func (l *Logger) StdLogger(level zapcore.Level) (*stdlog.Logger, error) {
+	return zap.NewStdLogAt(l.Logger, level)
+}
+
+// WriterAt 返回按行切分的 io.WriteCloser，每行以指定级别输出为一条日志，
+// 适用于 exec.Cmd.Stderr 等场景。Close 时输出末尾不完整的行
+func (l *Logger) WriterAt(level zapcore.Level) io.WriteCloser {
+	return &lineWriter{logger: l.Logger.WithOptions(zap.WithCaller(false)), level: level}
+}
+
+// lineWriter 缓存未以换行结尾的数据，直到读到换行或 Close
+type lineWriter struct {
+	mu     sync.Mutex
+	logger *zap.Logger
+	level  zapcore.Level
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	// 剩余数据移到缓冲区头部，避免底层数组持续增长
+	w.buf = append(w.buf[:0:0], w.buf...)
+	return len(p), nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit(w.buf)
+	w.buf = nil
+	return nil
+}
+
+// emit 输出一行，忽略空行
+func (w *lineWriter) emit(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+	if ce := w.logger.Check(w.level, string(line)); ce != nil {
+		ce.Write()
+	}
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStdLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &Logger{Logger: zap.New(core, zap.AddCaller())}
+
+	std, err := logger.StdLogger(zapcore.WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std.Printf("disk %d%% full\n", 91)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	ent := entries[0]
+	if ent.Level != zapcore.WarnLevel || ent.Message != "disk 91% full" {
+		t.Errorf("entry = %s %q", ent.Level, ent.Message)
+	}
+	if file := filepath.Base(ent.Caller.File); file != "stdlog_test.go" {
+		t.Errorf("caller = %s, want stdlog_test.go", ent.Caller)
+	}
+
+	if _, err := logger.StdLogger(zapcore.Level(42)); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestWriterAt(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := &Logger{Logger: zap.New(core, zap.AddCaller())}
+
+	w := logger.WriterAt(zapcore.ErrorLevel)
+	for _, chunk := range []string{"first li", "ne\r\nsecond line\n\n", "partial"} {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := logs.Len(); n != 2 {
+		t.Fatalf("got %d entries before Close, want 2", n)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, ent := range logs.AllUntimed() {
+		if ent.Level != zapcore.ErrorLevel {
+			t.Errorf("level = %s, want error", ent.Level)
+		}
+		got = append(got, ent.Message)
+	}
+	if want := []string{"first line", "second line", "partial"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
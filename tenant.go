@@ -0,0 +1,239 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TenantKey ForTenant 添加的租户字段名
+const TenantKey = "tenant"
+
+// tenantPlaceholder 适配器 DSN 中的租户占位符，见 tenantCore
+const tenantPlaceholder = "{tenant}"
+
+// reTenant 可用于占位符替换的租户 ID，其余的租户写入默认租户的适配器，避免路径穿越与 URL 注入
+var reTenant = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+type tenantCtxKey struct{}
+
+// ForTenant 在 context 中的 logger 上添加租户字段 (TenantKey) 并记录租户，
+// 之后通过 FromContext 取出的 logger 写出的日志都带有该字段，可按 Route.Tenant 路由，
+// 或写入 DSN 中带 {tenant} 占位符的按租户隔离的适配器
+func ForTenant(ctx context.Context, tenant string) context.Context {
+	ctx = With(ctx, zap.String(TenantKey, tenant))
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// TenantFromContext 返回 ForTenant 记录的租户，没有时返回空字符串
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantCtxKey{}).(string)
+	return tenant
+}
+
+// isTenantDSN DSN 中是否有租户占位符
+func isTenantDSN(dsn string) bool {
+	return strings.Contains(dsn, tenantPlaceholder)
+}
+
+// tenantDSN 将占位符替换为租户 ID
+func tenantDSN(dsn, tenant string) string {
+	return strings.ReplaceAll(dsn, tenantPlaceholder, tenant)
+}
+
+// tenantSet 按租户延迟创建的适配器，同一 DSN 模板的每个租户一个适配器
+type tenantSet struct {
+	create   func(tenant string) (*adaptor, error)
+	fallback string // 没有租户字段、租户 ID 无效或超出上限时使用的租户
+	max      int
+	onError  func(err error)
+
+	def      *adaptor // 默认租户的适配器
+	mu       sync.Mutex
+	adaptors map[string]*adaptor
+	order    []string
+	warned   bool // 已报告超出上限
+}
+
+// newTenantSet 创建默认租户的适配器，DSN 有误时立即返回错误
+func newTenantSet(fallback string, max int, onError func(err error), create func(tenant string) (*adaptor, error)) (*tenantSet, error) {
+	s := &tenantSet{create: create, fallback: fallback, max: max, onError: onError, adaptors: make(map[string]*adaptor)}
+	a, err := create(fallback)
+	if err != nil {
+		return nil, err
+	}
+	s.def, s.order = a, []string{fallback}
+	s.adaptors[fallback] = a
+	return s, nil
+}
+
+// createTenantAdaptor 创建 DSN 带 {tenant} 占位符的适配器：每个租户使用替换占位符后的 DSN 创建独立的适配器
+func createTenantAdaptor(cfg *Config, resolved resolvedConfig, dsn string, encoder zapcore.Encoder) (*adaptor, error) {
+	opts, err := parseCoreOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+	name := redactDSN(dsn)
+	set, err := newTenantSet(opts.TenantDefault, opts.MaxTenants, resolved.diag.hook(name), func(tenant string) (*adaptor, error) {
+		return createAdaptor(cfg, resolved, tenantDSN(dsn, tenant), encoder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &adaptor{name: name, alias: opts.Name, probe: opts.Probe, core: newTenantCore(set), closer: set, counters: []dropCounter{set}}, nil
+}
+
+// get 返回租户的适配器，首次出现时创建，创建失败时使用默认租户
+func (s *tenantSet) get(tenant string) *adaptor {
+	if !reTenant.MatchString(tenant) {
+		tenant = s.fallback
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.adaptors[tenant]; ok {
+		return a
+	}
+	if len(s.adaptors) >= s.max {
+		if !s.warned && s.onError != nil {
+			s.onError(fmt.Errorf("more than %d tenants, logging new tenants to %s", s.max, s.fallback))
+		}
+		s.warned = true
+		return s.def
+	}
+	a, err := s.create(tenant)
+	if err != nil {
+		if s.onError != nil {
+			s.onError(fmt.Errorf("tenant %s: %w", tenant, err))
+		}
+		return s.def
+	}
+	s.adaptors[tenant], s.order = a, append(s.order, tenant)
+	return a
+}
+
+// each 按创建顺序遍历已创建的适配器
+func (s *tenantSet) each(fn func(a *adaptor)) {
+	s.mu.Lock()
+	adaptors := make([]*adaptor, 0, len(s.order))
+	for _, tenant := range s.order {
+		adaptors = append(adaptors, s.adaptors[tenant])
+	}
+	s.mu.Unlock()
+	for _, a := range adaptors {
+		fn(a)
+	}
+}
+
+func (s *tenantSet) Close() error {
+	_, err := s.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 按 ctx 关闭各租户的适配器，合并其排空结果
+func (s *tenantSet) CloseContext(ctx context.Context) (DrainResult, error) {
+	var total DrainResult
+	var errs []error
+	s.each(func(a *adaptor) {
+		if a.closer == nil {
+			return
+		}
+		result, err := closeContext(ctx, a.closer)
+		total.Flushed += result.Flushed
+		total.Abandoned += result.Abandoned
+		errs = append(errs, err)
+	})
+	return total, errors.Join(errs...)
+}
+
+// Dropped 合并各租户适配器的丢弃条数
+func (s *tenantSet) Dropped() uint64 {
+	var n uint64
+	s.each(func(a *adaptor) {
+		for _, c := range a.counters {
+			n += c.Dropped()
+		}
+	})
+	return n
+}
+
+// stats 合并各租户适配器的运行状态
+func (s *tenantSet) stats(st *AdaptorStats) {
+	s.each(func(a *adaptor) {
+		if r, ok := a.closer.(statsReporter); ok {
+			r.stats(st)
+		}
+	})
+}
+
+// tenantCore 按日志的租户字段写入对应租户的适配器。
+// With 添加的字段中有租户时直接绑定该租户的 Core，否则保存上下文字段，写入时再确定租户
+type tenantCore struct {
+	set     *tenantSet
+	tenant  string
+	core    zapcore.Core // tenant 对应的 Core，已应用 context
+	context []zapcore.Field
+}
+
+func newTenantCore(set *tenantSet) *tenantCore {
+	return &tenantCore{set: set}
+}
+
+// Enabled 各租户的适配器由同一 DSN 创建，级别相同
+func (c *tenantCore) Enabled(lvl zapcore.Level) bool {
+	return c.set.def.core.Enabled(lvl)
+}
+
+func (c *tenantCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &tenantCore{set: c.set, tenant: c.tenant, context: append(slices.Clip(c.context), fields...)}
+	if tenant, ok := tenantField(fields); ok {
+		clone.tenant = tenant
+	}
+	if clone.tenant != "" {
+		clone.core = c.set.get(clone.tenant).core.With(clone.context)
+	}
+	return clone
+}
+
+func (c *tenantCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 经由租户适配器的 Check 写出，使适配器自身的级别、筛选与限流生效
+func (c *tenantCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	core := c.core
+	if tenant, ok := tenantField(fields); ok && tenant != c.tenant {
+		core = c.set.get(tenant).core.With(c.context)
+	} else if core == nil {
+		core = c.set.def.core.With(c.context)
+	}
+	return writeChecked(core.Check(ent, nil), fields)
+}
+
+func (c *tenantCore) Sync() error {
+	var errs []error
+	c.set.each(func(a *adaptor) { errs = append(errs, a.core.Sync()) })
+	return errors.Join(errs...)
+}
+
+// tenantField 查找字符串类型的租户字段，同名字段以最后一个为准
+func tenantField(fields []zapcore.Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if f := fields[i]; f.Key == TenantKey && f.Type == zapcore.StringType {
+			return f.String, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTenantCoreWriteError(t *testing.T) {
+	sink := &fakeSink{writeErr: errors.New("disk full")}
+	set, err := newTenantSet("default", 4, nil, func(string) (*adaptor, error) {
+		return &adaptor{core: newSinkCore(zapcore.NewJSONEncoder(jsonEncoderConfig()), sink, zapcore.InfoLevel)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 租户适配器的写入错误返回给调用方
+	err = newTenantCore(set).Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "lost"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Write() error = %v", err)
+	}
+}
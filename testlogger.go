@@ -0,0 +1,59 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestLogs 记录测试 logger 输出的日志，可用 ObservedLogs 的 Filter 方法筛选
+type TestLogs struct {
+	*observer.ObservedLogs
+	t testing.TB
+}
+
+// NewTestLogger 创建记录全部级别日志到内存的 Logger，不修改全局 logger，用于在单元测试中断言日志输出
+func NewTestLogger(t testing.TB) (*Logger, *TestLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &Logger{Logger: zap.New(core, zap.AddCaller())}, &TestLogs{ObservedLogs: logs, t: t}
+}
+
+// AssertLogged 断言以指定级别记录过消息为 msg 的日志
+func (l *TestLogs) AssertLogged(level zapcore.Level, msg string) {
+	l.t.Helper()
+	if l.FilterLevelExact(level).FilterMessage(msg).Len() == 0 {
+		l.t.Errorf("expected %s log %q, got:\n%s", level, msg, l.summary())
+	}
+}
+
+// AssertNotLogged 断言没有以指定级别记录过消息为 msg 的日志
+func (l *TestLogs) AssertNotLogged(level zapcore.Level, msg string) {
+	l.t.Helper()
+	if n := l.FilterLevelExact(level).FilterMessage(msg).Len(); n > 0 {
+		l.t.Errorf("unexpected %s log %q logged %d times", level, msg, n)
+	}
+}
+
+// AssertField 断言存在消息为 msg 且带有指定字段的日志，字段比较规则同 ObservedLogs.FilterField
+func (l *TestLogs) AssertField(msg string, field zap.Field) {
+	l.t.Helper()
+	if l.FilterMessage(msg).FilterField(field).Len() == 0 {
+		l.t.Errorf("expected log %q with field %s, got:\n%s", msg, field.Key, l.summary())
+	}
+}
+
+// summary 列出已记录的日志，用于断言失败时输出
+func (l *TestLogs) summary() string {
+	var b strings.Builder
+	for _, ent := range l.AllUntimed() {
+		fmt.Fprintf(&b, "  %s %s %v\n", ent.Level.CapitalString(), ent.Message, ent.ContextMap())
+	}
+	if b.Len() == 0 {
+		return "  (no logs)\n"
+	}
+	return b.String()
+}
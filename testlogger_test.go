@@ -0,0 +1,38 @@
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewTestLogger(t *testing.T) {
+	logger, logs := NewTestLogger(t)
+	logger.With(zap.String("user", "bob")).Warn("login failed")
+	logger.Debug("details")
+
+	logs.AssertLogged(zapcore.WarnLevel, "login failed")
+	logs.AssertLogged(zapcore.DebugLevel, "details")
+	logs.AssertNotLogged(zapcore.ErrorLevel, "login failed")
+	logs.AssertField("login failed", zap.String("user", "bob"))
+	if n := logs.FilterMessage("details").Len(); n != 1 {
+		t.Errorf("FilterMessage len = %d, want 1", n)
+	}
+
+	// 断言失败时报告到传入的 testing.TB
+	inner := &failRecorder{TB: t}
+	_, empty := NewTestLogger(inner)
+	empty.AssertLogged(zapcore.InfoLevel, "missing")
+	if !inner.failed {
+		t.Error("AssertLogged should fail when the log is missing")
+	}
+}
+
+// failRecorder 记录 Errorf 调用而不让外层测试失败
+type failRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failRecorder) Errorf(string, ...any) { r.failed = true }
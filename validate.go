@@ -0,0 +1,187 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// dsnParams 各适配器支持的 DSN 参数，"" 为所有适配器通用的参数，
+// Validate 据此发现拼写错误和用在其他适配器上的参数
+var dsnParams = map[string][]string{
+	"": {
+		"name", "probe", "level", "level-min", "level-max", "format", "template", "tz", "keys",
+		"facility", "hostname", "app-name", "sd-id",
+		"rate-limit", "burst", "dedupe", "sort-fields", "field-order",
+		"redact-keys", "redact-values", "include", "exclude", "allow-fields", "deny-fields",
+		"max-message", "max-field", "max-entry", "tenant-default", "max-tenants",
+	},
+	"file": {
+		"max-size", "max-backups", "max-age", "max-total-size", "compress", "buffer", "flush-interval",
+		"split-errors", "shards", "rotate-on-start", "sync", "fallback", "fallback-retry", "post-rotate-cmd",
+		"encrypt", "key-env", "audit", "audit-key-env",
+	},
+	"http": {
+		"method", "timeout", "auth", "insecure", "content-type", "expect-status",
+		"buffer-size", "batch-size", "max-batch-bytes", "flush-interval", "workers", "compress",
+		"max-retries", "retry-min", "retry-max", "drain-timeout",
+		"overflow", "queue", "block-timeout", "spill-path", "spool", "spool-max", "spool-rate", "spool-max-age",
+		"payload", "envelope-key", "sign", "sign-key-env",
+		"tls-ca", "tls-cert", "tls-key", "tls-min-version",
+	},
+	"influxdb":     {"org", "token", "token-env", "measurement", "fields", "tags"},
+	"grafanacloud": {"labels", "token-env"},
+	"pushgateway":  {"instance", "metric", "interval", "timeout", "insecure", "tls-ca", "tls-cert", "tls-key", "tls-min-version"},
+	"redis":        {"key", "mode", "maxlen", "field", "timeout", "insecure", "tls-ca", "tls-cert", "tls-key", "tls-min-version"},
+	"kv":           {"token", "token-env", "interval", "window", "timeout", "insecure", "tls-ca", "tls-cert", "tls-key", "tls-min-version"},
+	"failover":     {"primary", "secondary", "interval"},
+	"mirror":       {"primary", "shadow", "buffer"},
+	"sample":       {"rate", "of", "key"},
+	"chaos":        {"target", "delay", "delay-rate", "error-rate", "drop-rate", "seed"},
+	"syslog":       {"timeout", "insecure", "tls-ca", "tls-cert", "tls-key", "tls-min-version"},
+}
+
+// httpBasedSchemes 基于 HTTP 适配器、同时接受 HTTP 适配器参数的 scheme
+var httpBasedSchemes = []string{"http", "influxdb", "grafanacloud", "vector"}
+
+// Validate 检查配置能否完整生效，一次返回全部问题：模式、格式、级别等全局选项，
+// 每个适配器 DSN 的参数（包括未知参数和不适用于该适配器的参数）以及路由规则。
+// 与 NewWithConfig 一样先应用环境变量覆盖，但不创建任何输出。
+// NewWithConfig 遇到无法创建的适配器只输出诊断并跳过，启动时先调用 Validate 可以在配置错误时立即失败
+func (c *Config) Validate() error {
+	cfg, err := applyEnv(c)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	resolved, err := resolveConfig(cfg)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	names := make([]string, len(cfg.Adaptors))
+	for i, dsn := range cfg.Adaptors {
+		names[i] = adaptorAlias(dsn)
+		for _, err := range validateAdaptor(dsn, resolved.level) {
+			errs = append(errs, fmt.Errorf("adaptor %s: %w", redactDSN(dsn), err))
+		}
+	}
+	if _, err := compileRoutes(cfg.Routes, names, names); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validateAdaptor 解析适配器 DSN 并检查参数名，返回全部问题；level 为全局级别，用于检查继承级别的适配器
+func validateAdaptor(dsn string, level zapcore.Level) []error {
+	dsn = tenantDSN(dsn, "tenant")
+	u, err := parseDSN(dsn)
+	if err != nil {
+		return []error{fmt.Errorf("invalid adaptor DSN: %w", err)}
+	}
+	var errs []error
+	if _, err := parseCoreOptions(dsn); err != nil {
+		errs = append(errs, err)
+	}
+	scheme := u.Scheme
+	switch scheme {
+	case "file":
+		_, err = parseFileOptions(dsn)
+	case "http", "https":
+		scheme = "http"
+		_, err = parseHTTPOptions(dsn)
+	case "syslog+tls", "relp", "relp+tls":
+		scheme = "syslog"
+		_, err = parseSyslogOptions(dsn)
+	case "vector", "vector+tls":
+		scheme = "vector"
+		_, err = parseVectorOptions(dsn)
+	case "influxdb", "influxdb+tls":
+		scheme = "influxdb"
+		_, err = parseInfluxOptions(dsn)
+	case "grafanacloud":
+		_, err = parseGrafanaCloudOptions(dsn)
+	case "pushgateway", "pushgateway+tls":
+		scheme = "pushgateway"
+		_, err = parsePushOptions(dsn)
+	case "redis", "redis+tls":
+		scheme = "redis"
+		_, err = parseRedisOptions(dsn)
+	case "consul", "consul+tls", "etcd", "etcd+tls":
+		scheme = "kv"
+		_, err = parseKVOptions(dsn)
+	case "record":
+		_, err = parseRecordOptions(dsn)
+	case "failover", "mirror":
+		names := []string{"primary", "secondary"}
+		if scheme == "mirror" {
+			_, err = parseMirrorOptions(dsn)
+			names = []string{"primary", "shadow"}
+		} else {
+			_, err = parseFailoverOptions(dsn)
+		}
+		for _, name := range names {
+			if target := u.Query().Get(name); target != "" {
+				for _, terr := range validateAdaptor(target, level) {
+					errs = append(errs, fmt.Errorf("%s: %w", name, terr))
+				}
+			}
+		}
+	case "sample":
+		_, err = parseSampleOptions(dsn)
+		if target := u.Query().Get("of"); target != "" {
+			for _, terr := range validateAdaptor(target, level) {
+				errs = append(errs, fmt.Errorf("of: %w", terr))
+			}
+		}
+	case "chaos":
+		_, err = parseChaosOptions(dsn)
+		if target := u.Query().Get("target"); target != "" {
+			for _, terr := range validateAdaptor(target, level) {
+				errs = append(errs, fmt.Errorf("target: %w", terr))
+			}
+		}
+	default:
+		return append(errs, fmt.Errorf("unsupported scheme: %s", scheme))
+	}
+	if err := checkLevelBand(u.Scheme, u.Query(), level); err != nil {
+		errs = append(errs, err)
+	}
+	// tz 等参数由通用选项与适配器选项各解析一次，相同的错误只报告一次
+	if err != nil && !slices.ContainsFunc(errs, func(e error) bool { return e.Error() == err.Error() }) {
+		errs = append(errs, err)
+	}
+	keys := make([]string, 0, len(u.Query()))
+	for key := range u.Query() {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if slices.Contains(dsnParams[""], key) || slices.Contains(dsnParams[scheme], key) {
+			continue
+		}
+		if slices.Contains(httpBasedSchemes, scheme) && (slices.Contains(dsnParams["http"], key) || strings.HasPrefix(key, "header.")) {
+			continue
+		}
+		if other := paramScheme(key); other != "" {
+			errs = append(errs, fmt.Errorf("parameter %s is only supported by %s adaptors", key, other))
+		} else {
+			errs = append(errs, fmt.Errorf("unknown parameter %s", key))
+		}
+	}
+	return errs
+}
+
+// paramScheme 返回支持参数 key 的适配器，多个时以逗号分隔
+func paramScheme(key string) string {
+	var schemes []string
+	for scheme, params := range dsnParams {
+		if scheme != "" && slices.Contains(params, key) {
+			schemes = append(schemes, scheme)
+		}
+	}
+	slices.Sort(schemes)
+	return strings.Join(schemes, ", ")
+}
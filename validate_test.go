@@ -0,0 +1,71 @@
+package log
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := &Config{
+		Level: "debug",
+		Adaptors: []string{
+			"file:///var/log/app.log?max-size=100m&compress=gzip&split-errors=true&level=info&rate-limit=100/s",
+			"https://collector:8443/logs?batch-size=100&spool=/var/spool/app&header.X-API-Key=abc&tls-ca=/etc/ca.pem&name=remote",
+			"http://localhost:3000/logs?payload=ndjson&format=logfmt&tz=UTC",
+		},
+		Routes: []Route{{Level: "error", To: []string{"remote"}}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	cfg := &Config{
+		Level:     "verbose",
+		Format:    "xml",
+		TimeZone:  "Mars/Olympus",
+		Aggregate: &AggregateConfig{Interval: "often"},
+		Adaptors: []string{
+			"file:///var/log/app.log?spool=/tmp/spool&max-szie=10m",
+			"http://localhost:3000/logs?batch-size=many&password=secret",
+			"kafka://broker:9092/logs",
+			"file:///var/log/tz.log?tz=Nowhere",
+			"failover://?primary=" + url.QueryEscape("https://collector/logs?workers=few") + "&secondary=" + url.QueryEscape("file:///var/log/app.log?workers=2"),
+			"mirror://?primary=" + url.QueryEscape("file:///var/log/app.log") + "&shadow=" + url.QueryEscape("vector://vector:9000/?batch-size=lots") + "&buffer=big",
+		},
+		Routes: []Route{{To: []string{"sentry"}}},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`invalid log format "xml"`,
+		`invalid log level "verbose"`,
+		"Mars/Olympus",
+		`invalid aggregate interval "often"`,
+		"parameter spool is only supported by http adaptors",
+		"unknown parameter max-szie",
+		"invalid batch-size",
+		"unknown parameter password",
+		"unsupported scheme: kafka",
+		"primary: invalid workers",
+		"secondary: parameter workers is only supported by http adaptors",
+		"shadow: invalid batch-size",
+		"invalid buffer: big",
+		"sentry",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("missing %q in:\n%s", want, msg)
+		}
+	}
+	if n := strings.Count(msg, `invalid time zone "Nowhere"`); n != 1 {
+		t.Errorf("tz error reported %d times:\n%s", n, msg)
+	}
+
+	t.Setenv("LOG_LEVEL", "loud")
+	if err := valid.Validate(); err == nil || !strings.Contains(err.Error(), "LOG_LEVEL") {
+		t.Errorf("expected LOG_LEVEL error, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// ErrAuditNotSealed 审计文件哈希链完整但没有封存摘要，通常表示仍在写入的文件，
+// 对已滚动的文件而言则意味着尾部可能被截断
+var ErrAuditNotSealed = errors.New("audit file is not sealed")
+
+const (
+	auditMacKey     = "_mac"
+	auditSealKey    = "_seal"
+	auditSealMargin = 128 // 为封存行预留的空间
+)
+
+// rotatingWriter 支持手动滚动的写入器
+type rotatingWriter interface {
+	io.Writer
+	Rotate() error
+}
+
+// auditWriter 为每行日志追加与上一行串联的 HMAC，滚动前写入封存摘要
+// 为保证封存行写入旧文件，由 auditWriter 在文件写满前主动触发滚动
+type auditWriter struct {
+	out     rotatingWriter
+	key     []byte
+	prev    []byte
+	pending []byte
+	size    int64
+	max     int64
+	entries int
+	mu      sync.Mutex
+}
+
+func newAuditWriter(out rotatingWriter, path string, key []byte, maxSize int64) (*auditWriter, error) {
+	w := &auditWriter{out: out, key: key, max: maxSize}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return w, nil
+		}
+		return nil, err
+	}
+	w.size = info.Size()
+	// 续接已有文件的哈希链
+	prev, entries, sealed, err := scanAuditChain(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("existing audit file %s is invalid: %w", path, err)
+	}
+	// 已封存但尚未滚动（例如滚动时进程退出），直接滚动
+	if sealed {
+		if err := out.Rotate(); err != nil {
+			return nil, err
+		}
+		w.size = 0
+		return w, nil
+	}
+	w.prev, w.entries = prev, entries
+	return w, nil
+}
+
+func (w *auditWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		line := w.pending[:idx]
+		if len(line) > 0 {
+			if err := w.writeLine(line); err != nil {
+				w.pending = w.pending[idx+1:]
+				return 0, err
+			}
+		}
+		w.pending = w.pending[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Rotate 封存当前文件并滚动
+func (w *auditWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// writeLine 写入一行并在需要时先封存滚动，调用方需持有锁
+func (w *auditWriter) writeLine(line []byte) error {
+	signed := signAuditLine(w.key, w.prev, line)
+	if w.size > 0 && w.size+int64(len(signed))+auditSealMargin > w.max {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+		signed = signAuditLine(w.key, w.prev, line)
+	}
+	if _, err := w.out.Write(signed); err != nil {
+		return err
+	}
+	w.prev = auditMac(w.key, w.prev, line)
+	w.size += int64(len(signed))
+	w.entries++
+	return nil
+}
+
+// rotate 写入封存行后滚动，调用方需持有锁
+func (w *auditWriter) rotate() error {
+	if w.size > 0 {
+		seal := fmt.Appendf(nil, `{"%s":true,"entries":%d}`, auditSealKey, w.entries)
+		if _, err := w.out.Write(signAuditLine(w.key, w.prev, seal)); err != nil {
+			return err
+		}
+	}
+	if err := w.out.Rotate(); err != nil {
+		return err
+	}
+	w.prev, w.size, w.entries = nil, 0, 0
+	return nil
+}
+
+// auditMac 计算与上一行串联的 HMAC-SHA256
+func auditMac(key, prev, line []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(prev)
+	h.Write(line)
+	return h.Sum(nil)
+}
+
+// signAuditLine 将 MAC 写入行内：JSON 对象追加 _mac 字段，其它格式追加 " _mac=<hex>"
+func signAuditLine(key, prev, line []byte) []byte {
+	mac := hex.EncodeToString(auditMac(key, prev, line))
+	out := make([]byte, 0, len(line)+len(mac)+12)
+	if n := len(line); n > 0 && line[n-1] == '}' {
+		out = append(out, line[:n-1]...)
+		if n > 2 {
+			out = append(out, ',')
+		}
+		out = append(out, `"`+auditMacKey+`":"`+mac+`"}`...)
+	} else {
+		out = append(out, line...)
+		out = append(out, " "+auditMacKey+"="+mac...)
+	}
+	return append(out, '\n')
+}
+
+// splitAuditLine 拆分出原始行与 MAC
+func splitAuditLine(signed []byte) (line, mac []byte, ok bool) {
+	const hexLen = sha256.Size * 2
+	jsonSuffix := len(`,"` + auditMacKey + `":""}`)
+	if n := len(signed); n >= hexLen+jsonSuffix-1 && signed[n-1] == '}' {
+		macStart := n - 2 - hexLen
+		prefix := signed[:macStart]
+		if bytes.HasSuffix(prefix, []byte(`"`+auditMacKey+`":"`)) {
+			body := bytes.TrimSuffix(prefix, []byte(`"`+auditMacKey+`":"`))
+			if bytes.HasSuffix(body, []byte(",")) {
+				body = body[:len(body)-1]
+			}
+			line = append(append([]byte{}, body...), '}')
+			return line, signed[macStart : n-2], true
+		}
+	}
+	marker := []byte(" " + auditMacKey + "=")
+	if idx := bytes.LastIndex(signed, marker); idx >= 0 && len(signed)-idx-len(marker) == hexLen {
+		return signed[:idx], signed[idx+len(marker):], true
+	}
+	return nil, nil, false
+}
+
+// scanAuditChain 校验整个文件的哈希链，返回最后一个 MAC、条目数以及是否已封存
+func scanAuditChain(path string, key []byte) (prev []byte, entries int, sealed bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if sealed {
+			return nil, 0, false, fmt.Errorf("line %d: entry after seal", lineNo)
+		}
+		line, macHex, ok := splitAuditLine(scanner.Bytes())
+		if !ok {
+			return nil, 0, false, fmt.Errorf("line %d: missing %s", lineNo, auditMacKey)
+		}
+		mac, err := hex.DecodeString(string(macHex))
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("line %d: invalid %s: %w", lineNo, auditMacKey, err)
+		}
+		expected := auditMac(key, prev, line)
+		if !hmac.Equal(mac, expected) {
+			return nil, 0, false, fmt.Errorf("line %d: %s mismatch, entry modified, inserted or removed", lineNo, auditMacKey)
+		}
+		prev = expected
+		if count, isSeal := parseAuditSeal(line); isSeal {
+			if count != entries {
+				return nil, 0, false, fmt.Errorf("line %d: seal reports %d entries, found %d", lineNo, count, entries)
+			}
+			sealed = true
+			continue
+		}
+		entries++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, false, err
+	}
+	return prev, entries, sealed, nil
+}
+
+// parseAuditSeal 判断是否为封存行并返回其记录的条目数
+func parseAuditSeal(line []byte) (int, bool) {
+	if !bytes.HasPrefix(line, []byte(`{"`+auditSealKey+`":true`)) {
+		return 0, false
+	}
+	var seal struct {
+		Entries json.Number `json:"entries"`
+	}
+	if err := json.Unmarshal(line, &seal); err != nil {
+		return -1, true
+	}
+	n, err := strconv.Atoi(seal.Entries.String())
+	if err != nil {
+		return -1, true
+	}
+	return n, true
+}
+
+// VerifyAuditFile 校验审计日志文件的哈希链。
+// 文件被篡改时返回描述具体行号的错误；链完整但未封存时返回 ErrAuditNotSealed
+func VerifyAuditFile(path string, key []byte) error {
+	_, _, sealed, err := scanAuditChain(path, key)
+	if err != nil {
+		return err
+	}
+	if !sealed {
+		return ErrAuditNotSealed
+	}
+	return nil
+}
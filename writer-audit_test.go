@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestAuditWriterChainAndSeal(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "audit.log")
+	key := []byte("secret")
+
+	lj := &lumberjack.Logger{Filename: logFile, MaxSize: 1}
+	w, err := newAuditWriter(lj, logFile, key, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("{\"msg\":\"one\"}\n{\"msg\":\"two\"}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("plain text line\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyAuditFile(logFile, key); !errors.Is(err, ErrAuditNotSealed) {
+		t.Fatalf("expected active file to be unsealed, got %v", err)
+	}
+
+	// 重新打开时续接哈希链
+	w, err = newAuditWriter(lj, logFile, key, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("{\"msg\":\"three\"}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = lj.Close()
+
+	backup := latestBackup(logFile)
+	if backup == "" {
+		t.Fatal("expected rotated backup")
+	}
+	if err := VerifyAuditFile(backup, key); err != nil {
+		t.Fatalf("expected sealed backup to verify, got %v", err)
+	}
+	if err := VerifyAuditFile(backup, []byte("wrong")); err == nil {
+		t.Fatal("expected verification with wrong key to fail")
+	}
+
+	content, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Replace(content, []byte(`"two"`), []byte(`"2wo"`), 1)
+	if err := os.WriteFile(backup, tampered, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditFile(backup, key); err == nil {
+		t.Fatal("expected tampered file to fail verification")
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	truncated := bytes.Join(append(lines[:2:2], lines[3:]...), []byte("\n"))
+	if err := os.WriteFile(backup, append(truncated, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditFile(backup, key); err == nil {
+		t.Fatal("expected removed line to fail verification")
+	}
+}
+
+func TestParseAuditCompressConflict(t *testing.T) {
+	if _, err := parseFileOptions("file:///var/log/audit.log?audit=true&compress=gzip"); err == nil {
+		t.Fatal("expected audit with compress to be rejected")
+	}
+}
+
+// TestAuditRotateOnStart 启动时滚动的旧审计文件先封存，可以独立校验
+func TestAuditRotateOnStart(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	t.Setenv("TEST_AUDIT_KEY", "secret")
+	for range 2 {
+		w, closer, err := newFileWriter(&FileOptions{Path: logFile, MaxSize: 1, RotateOnStart: true, Audit: true, AuditKeyEnv: "TEST_AUDIT_KEY"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("{\"msg\":\"run\"}\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := closer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups := listBackups(logFile)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1", backups)
+	}
+	if err := VerifyAuditFile(backups[0], []byte("secret")); err != nil {
+		t.Errorf("rotated audit file: %v", err)
+	}
+	if err := VerifyAuditFile(logFile, []byte("secret")); !errors.Is(err, ErrAuditNotSealed) {
+		t.Errorf("active audit file: %v", err)
+	}
+}
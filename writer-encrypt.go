@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxEncryptedRecord 单条加密记录的最大长度，防止损坏文件导致超大内存分配
+const maxEncryptedRecord = 256 << 20
+
+// encryptWriter 将每次写入的数据作为一条独立的 AES-GCM 记录写入底层文件
+// 记录格式: [4 字节大端长度][12 字节 nonce][密文 + 16 字节 tag]
+type encryptWriter struct {
+	out  rotatingWriter
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+func newEncryptWriter(out rotatingWriter, key []byte) (*encryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{out: out, aead: aead}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	nonceSize := w.aead.NonceSize()
+	record := make([]byte, 4+nonceSize, 4+nonceSize+len(p)+w.aead.Overhead())
+	if _, err := rand.Read(record[4 : 4+nonceSize]); err != nil {
+		return 0, fmt.Errorf("generate nonce failed: %w", err)
+	}
+	record = w.aead.Seal(record, record[4:4+nonceSize], p, nil)
+	binary.BigEndian.PutUint32(record[:4], uint32(len(record)-4))
+	if _, err := w.out.Write(record); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *encryptWriter) Rotate() error { return w.out.Rotate() }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseEncryptionKey 解析 hex 或 base64 编码的 AES 密钥（解码后 16/24/32 字节）
+func ParseEncryptionKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("encryption key is empty")
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		if key, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, errors.New("encryption key must be hex or base64 encoded")
+		}
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+}
+
+// DecryptFile 解密 encrypt=aes-gcm 写入的日志文件并输出明文到 w
+func DecryptFile(path string, key []byte, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Decrypt(f, key, w)
+}
+
+// Decrypt 从 r 中逐条读取加密记录并输出明文到 w
+func Decrypt(r io.Reader, key []byte, w io.Writer) error {
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	var header [4]byte
+	for record := 1; ; record++ {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("record %d: truncated header: %w", record, err)
+		}
+		size := binary.BigEndian.Uint32(header[:])
+		if size < uint32(aead.NonceSize()+aead.Overhead()) || size > maxEncryptedRecord {
+			return fmt.Errorf("record %d: invalid length %d", record, size)
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return fmt.Errorf("record %d: truncated body: %w", record, err)
+		}
+		nonce, ciphertext := buf[:aead.NonceSize()], buf[aead.NonceSize():]
+		plain, err := aead.Open(ciphertext[:0], nonce, ciphertext, nil)
+		if err != nil {
+			return fmt.Errorf("record %d: decrypt failed: %w", record, err)
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fallbackWriter 主写入器失败时降级写入备用输出，并定期重试主写入器
+type fallbackWriter struct {
+	nextRetry  time.Time
+	lastNotice time.Time
+	primary    zapcore.WriteSyncer
+	fallback   zapcore.WriteSyncer
+	name       string
+	retry      time.Duration
+	failed     bool
+	mu         sync.Mutex
+}
+
+func newFallbackWriter(name string, primary, fallback zapcore.WriteSyncer, retry time.Duration) *fallbackWriter {
+	return &fallbackWriter{
+		name:     name,
+		primary:  primary,
+		fallback: fallback,
+		retry:    retry,
+	}
+}
+
+func (w *fallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if w.failed && now.Before(w.nextRetry) {
+		return w.fallback.Write(p)
+	}
+	n, err := w.primary.Write(p)
+	if err == nil {
+		if w.failed {
+			w.failed = false
+			w.notice(now, "log: %s recovered, leaving fallback\n", w.name)
+		}
+		return n, nil
+	}
+	w.failed = true
+	w.nextRetry = now.Add(w.retry)
+	w.notice(now, "log: write to %s failed (%v), falling back\n", w.name, err)
+	// 主写入器可能已写入部分内容，备用输出写入完整条目
+	return w.fallback.Write(p)
+}
+
+func (w *fallbackWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed {
+		return w.fallback.Sync()
+	}
+	return w.primary.Sync()
+}
+
+// notice 向备用输出写入状态提示，同一重试周期内最多一次，调用方需持有锁
+func (w *fallbackWriter) notice(now time.Time, format string, args ...any) {
+	if now.Sub(w.lastNotice) < w.retry {
+		return
+	}
+	w.lastNotice = now
+	_, _ = io.WriteString(w.fallback, fmt.Sprintf(format, args...))
+}
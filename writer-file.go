@@ -1,10 +1,15 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -13,14 +18,31 @@ import (
 // fileWriterCloser 文件写入器的包装，支持滚动和关闭
 type fileWriterCloser struct {
 	zapcore.WriteSyncer
-	closer io.Closer
+	closer   io.Closer
+	buffered *zapcore.BufferedWriteSyncer
+	fsync    *fsyncWriter
+	written  writerStats
 }
 
+func (f *fileWriterCloser) stats(st *AdaptorStats) { f.written.stats(st) }
+
 func (f *fileWriterCloser) Close() error {
+	var firstErr error
+	// 先停止缓冲写入器，确保缓冲区内容写入文件
+	if f.buffered != nil {
+		firstErr = f.buffered.Stop()
+	}
+	if f.fsync != nil {
+		if err := f.fsync.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	if f.closer != nil {
-		return f.closer.Close()
+		if err := f.closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
 }
 
 // newFileWriter 创建带滚动功能的文件写入器
@@ -28,6 +50,9 @@ func newFileWriter(opts *FileOptions) (zapcore.WriteSyncer, io.Closer, error) {
 	if opts.Path == "" {
 		return nil, nil, fmt.Errorf("file path is empty")
 	}
+	if opts.Shards > 1 {
+		return newShardedWriter(opts)
+	}
 	// 确保目录存在
 	dir := filepath.Dir(opts.Path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -36,15 +61,136 @@ func newFileWriter(opts *FileOptions) (zapcore.WriteSyncer, io.Closer, error) {
 	// 使用 lumberjack 实现日志滚动
 	logger := &lumberjack.Logger{
 		Filename:   opts.Path,
-		MaxSize:    opts.MaxSize,            // MB
-		MaxBackups: opts.MaxBackups,         // 保留的旧文件数量
-		MaxAge:     opts.MaxAge,             // 天
-		Compress:   opts.Compress == "gzip", // 是否压缩
-		LocalTime:  true,                    // 使用本地时间
+		MaxSize:    opts.MaxSize,              // MB
+		MaxBackups: opts.MaxBackups,           // 保留的旧文件数量
+		MaxAge:     opts.MaxAge,               // 天
+		Compress:   opts.Compress == "gzip",   // 是否压缩
+		LocalTime:  opts.Location != time.UTC, // 默认使用本地时间
 	}
 	wrapper := &fileWriterCloser{
 		WriteSyncer: zapcore.AddSync(logger),
 		closer:      logger,
+		written:     writerStats{onError: opts.OnError},
+	}
+	// 注册滚动回调
+	var hook func(path string)
+	if opts.PostRotateCmd != "" {
+		hook = commandHook(opts.PostRotateCmd, opts.OnError)
+	}
+	// 按总大小清理备份，启动时先清理一次
+	var prune func(path string)
+	if opts.MaxTotalSize > 0 {
+		pruneBackups(opts.Path, opts.MaxTotalSize)
+		prune = pruneHook(opts.Path, opts.MaxTotalSize)
+	}
+	var out rotatingWriter = logger
+	if hook = chainHooks(prune, opts.OnRotate, hook); hook != nil {
+		out = newRotateNotifier(logger, hook)
+	}
+	// 先于加密滚动，新文件从头开始记录；审计模式由 auditWriter 封存后滚动
+	if opts.RotateOnStart && !opts.Audit {
+		if info, err := os.Stat(opts.Path); err == nil && info.Size() > 0 {
+			if err := out.Rotate(); err != nil {
+				return nil, nil, fmt.Errorf("failed to rotate log file on start: %w", err)
+			}
+		}
+	}
+	// 加密存储：每次写入作为独立的 AES-GCM 记录
+	if opts.Encrypt == "aes-gcm" {
+		key, err := ParseEncryptionKey(os.Getenv(opts.KeyEnv))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid key in env %s: %w", opts.KeyEnv, err)
+		}
+		enc, err := newEncryptWriter(out, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = enc
+	}
+	// 审计模式：逐行串联 HMAC，滚动前封存
+	if opts.Audit {
+		key := os.Getenv(opts.AuditKeyEnv)
+		if key == "" {
+			return nil, nil, fmt.Errorf("audit key env %s is empty", opts.AuditKeyEnv)
+		}
+		audit, err := newAuditWriter(out, opts.Path, []byte(key), int64(opts.MaxSize)*mb)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 续接已有文件的哈希链后封存并滚动，旧文件可以独立校验
+		if opts.RotateOnStart && audit.size > 0 {
+			if err := audit.Rotate(); err != nil {
+				return nil, nil, fmt.Errorf("failed to rotate log file on start: %w", err)
+			}
+		}
+		out = audit
+	}
+	wrapper.WriteSyncer = zapcore.AddSync(out)
+	// 按 sync 选项 fsync，每次写入时 fsync 的错误计入写入失败
+	if opts.Sync == "every" || opts.Sync == "interval" {
+		wrapper.fsync = newFsyncWriter(wrapper.WriteSyncer, opts.Path, opts.Sync, opts.SyncInterval, wrapper.written.failure)
+		wrapper.WriteSyncer = wrapper.fsync
+	}
+	// 在降级与缓冲之前统计，记录实际写入文件的结果
+	wrapper.WriteSyncer = statsWriter{WriteSyncer: wrapper.WriteSyncer, writerStats: &wrapper.written}
+	// 写入失败时降级到 stderr
+	if opts.Fallback == "stderr" {
+		wrapper.WriteSyncer = newFallbackWriter(opts.Path, wrapper.WriteSyncer, zapcore.Lock(os.Stderr), opts.FallbackRetry)
+	}
+	// 启用写缓冲，减少 write 系统调用
+	if opts.BufferSize > 0 || opts.FlushInterval > 0 {
+		wrapper.buffered = &zapcore.BufferedWriteSyncer{
+			WS:            wrapper.WriteSyncer,
+			Size:          opts.BufferSize,
+			FlushInterval: opts.FlushInterval,
+		}
+		wrapper.WriteSyncer = wrapper.buffered
 	}
 	return wrapper, wrapper, nil
 }
+
+// shardedWriter 轮流将每条日志写入一个分片文件。每个分片有独立的锁、缓冲与滚动，
+// 并发写入时不再争用同一个 lumberjack 的锁；分片之间不保证顺序，由采集端按时间合并
+type shardedWriter struct {
+	shards []zapcore.WriteSyncer
+	next   atomic.Uint64
+}
+
+// newShardedWriter 创建 opts.Shards 个分片文件，max-total-size 按分片数平分
+func newShardedWriter(opts *FileOptions) (zapcore.WriteSyncer, io.Closer, error) {
+	w := &shardedWriter{}
+	closers := make(multiCloser, 0, opts.Shards)
+	for i := range opts.Shards {
+		shardOpts := *opts
+		shardOpts.Path = shardFilePath(opts.Path, i)
+		shardOpts.Shards = 0
+		shardOpts.MaxTotalSize = opts.MaxTotalSize / int64(opts.Shards)
+		shard, closer, err := newFileWriter(&shardOpts)
+		if err != nil {
+			_ = closers.Close()
+			return nil, nil, err
+		}
+		w.shards = append(w.shards, shard)
+		closers = append(closers, closer)
+	}
+	return w, closers, nil
+}
+
+func (w *shardedWriter) Write(p []byte) (int, error) {
+	i := (w.next.Add(1) - 1) % uint64(len(w.shards))
+	return w.shards[i].Write(p)
+}
+
+func (w *shardedWriter) Sync() error {
+	var errs []error
+	for _, shard := range w.shards {
+		errs = append(errs, shard.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// shardFilePath 生成分片文件路径: app.log -> app.0.log
+func shardFilePath(path string, i int) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + strconv.Itoa(i) + ext
+}
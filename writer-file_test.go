@@ -0,0 +1,273 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// flakyWriter 可切换是否写入失败的测试写入器
+type flakyWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *flakyWriter) Sync() error { return nil }
+
+func TestPruneBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logFile := filepath.Join(tmpDir, "app.log")
+	backups := []string{
+		"app-2026-01-01T00-00-00.000.log",
+		"app-2026-01-02T00-00-00.000.log.gz",
+		"app-2026-01-03T00-00-00.000.log",
+	}
+	for _, name := range append(backups, "app.log", "other-2026-01-01T00-00-00.000.log") {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), make([]byte, 100), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruneBackups(logFile, 150)
+
+	for i, name := range backups {
+		_, err := os.Stat(filepath.Join(tmpDir, name))
+		if exists := err == nil; exists != (i == 2) {
+			t.Errorf("%s exists = %v, want %v", name, exists, i == 2)
+		}
+	}
+	for _, name := range []string{"app.log", "other-2026-01-01T00-00-00.000.log"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+}
+
+func TestShardedWriter(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	w, closer, err := newFileWriter(&FileOptions{Path: logFile, MaxSize: 100, Shards: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 9 {
+		if _, err := w.Write([]byte("{\"msg\":\"sharded\"}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var st AdaptorStats
+	closer.(statsReporter).stats(&st)
+	if want := uint64(9 * len("{\"msg\":\"sharded\"}\n")); st.Written != want {
+		t.Errorf("Written = %d, want %d", st.Written, want)
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮流写入，每个分片 3 行，不写入未分片的路径
+	for i := range 3 {
+		data, err := os.ReadFile(shardFilePath(logFile, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(data), "\n"); n != 3 {
+			t.Errorf("shard %d has %d lines, want 3", i, n)
+		}
+	}
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Errorf("expected %s not to be created: %v", logFile, err)
+	}
+}
+
+func TestRotateOnStart(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	rotated := make(chan string, 1)
+	open := func() {
+		t.Helper()
+		w, closer, err := newFileWriter(&FileOptions{
+			Path:          logFile,
+			MaxSize:       100,
+			RotateOnStart: true,
+			OnRotate:      func(path string) { rotated <- path },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("run\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := closer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一次运行没有旧文件，不产生备份
+	open()
+	if backups := listBackups(logFile); len(backups) != 0 {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+	open()
+	backups := listBackups(logFile)
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want 1", backups)
+	}
+	select {
+	case path := <-rotated:
+		if path != backups[0] {
+			t.Errorf("OnRotate path = %s, want %s", path, backups[0])
+		}
+	case <-time.After(time.Second):
+		t.Error("OnRotate not called")
+	}
+	for _, path := range []string{logFile, backups[0]} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != "run\n" {
+			t.Errorf("%s = %q, %v", path, data, err)
+		}
+	}
+}
+
+// TestRotateHookCompressed 开启压缩时滚动回调收到压缩完成后的 .gz 路径
+func TestRotateHookCompressed(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logFile, []byte("previous run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rotated := make(chan string, 1)
+	_, closer, err := newFileWriter(&FileOptions{
+		Path:          logFile,
+		MaxSize:       100,
+		Compress:      "gzip",
+		RotateOnStart: true,
+		OnRotate:      func(path string) { rotated <- path },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	select {
+	case path := <-rotated:
+		if !strings.HasSuffix(path, ".gz") {
+			t.Errorf("OnRotate path = %s, want compressed backup", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("compressed backup: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnRotate not called")
+	}
+}
+
+func TestPostRotateCmdError(t *testing.T) {
+	errs := make(chan error, 1)
+	commandHook("false", func(err error) { errs <- err })("app-2026-01-01T00-00-00.000.log")
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "post-rotate-cmd false") {
+			t.Errorf("error = %v", err)
+		}
+	default:
+		t.Fatal("expected hook failure to be reported")
+	}
+	// 执行成功时不报告
+	commandHook("true", func(err error) { t.Errorf("unexpected error: %v", err) })("app.log")
+}
+
+func TestFsyncWriter(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	for _, policy := range []string{"every", "interval"} {
+		t.Run(policy, func(t *testing.T) {
+			ws, closer, err := newFileWriter(&FileOptions{Path: logFile, MaxSize: 100, Sync: policy, SyncInterval: 10 * time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw := closer.(*fileWriterCloser).fsync
+			if _, err := ws.Write([]byte("synced\n")); err != nil {
+				t.Fatal(err)
+			}
+			if policy == "interval" {
+				deadline := time.Now().Add(time.Second)
+				for fw.dirty.Load() && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+			}
+			fw.mu.Lock()
+			opened := fw.file != nil
+			fw.mu.Unlock()
+			if !opened || fw.dirty.Load() {
+				t.Errorf("file not synced: opened %v, dirty %v", opened, fw.dirty.Load())
+			}
+
+			// 滚动后切换到新文件
+			if err := closer.(*fileWriterCloser).closer.(*lumberjack.Logger).Rotate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := ws.Sync(); err != nil {
+				t.Fatal(err)
+			}
+			cur, _ := os.Stat(logFile)
+			fw.mu.Lock()
+			info, _ := fw.file.Stat()
+			fw.mu.Unlock()
+			if !os.SameFile(cur, info) {
+				t.Error("fsync handle still points to the rotated file")
+			}
+			if err := closer.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestFallbackWriter(t *testing.T) {
+	primary := &flakyWriter{err: syscall.ENOSPC}
+	var fallback bytes.Buffer
+	w := newFallbackWriter("app.log", primary, zapcore.AddSync(&fallback), 20*time.Millisecond)
+
+	for range 3 {
+		if _, err := w.Write([]byte("entry\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(fallback.String(), "entry"); n != 3 {
+		t.Fatalf("expected 3 entries on fallback, got %d: %q", n, fallback.String())
+	}
+	if n := strings.Count(fallback.String(), "falling back"); n != 1 {
+		t.Fatalf("expected one throttled notice, got %d: %q", n, fallback.String())
+	}
+
+	// 重试间隔内不会尝试主写入器
+	primary.err = nil
+	_, _ = w.Write([]byte("still fallback\n"))
+	if primary.Len() != 0 {
+		t.Fatalf("expected primary to be skipped before retry, got %q", primary.String())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = w.Write([]byte("recovered entry\n"))
+	if !strings.Contains(primary.String(), "recovered entry") {
+		t.Fatalf("expected primary to be retried, got %q", primary.String())
+	}
+	if !strings.Contains(fallback.String(), "recovered") {
+		t.Fatalf("expected recovery notice, got %q", fallback.String())
+	}
+
+	primary.err = errors.New("EIO")
+	_, _ = w.Write([]byte("again\n"))
+	if !strings.Contains(fallback.String(), "again") {
+		t.Fatalf("expected entry on fallback after second failure, got %q", fallback.String())
+	}
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fsyncWriter 按 sync 选项把已写入的日志刷到磁盘。
+// lumberjack 不暴露文件句柄，这里另外打开同一文件调用 fsync（fsync 作用于文件而不是句柄），
+// 滚动后文件被替换时重新打开
+type fsyncWriter struct {
+	zapcore.WriteSyncer
+	path  string
+	every bool
+	dirty atomic.Bool // 上次 fsync 之后是否有写入 (interval)
+	mu    sync.Mutex
+	file  *os.File
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// newFsyncWriter policy 为 every 时每次写入后 fsync，为 interval 时每隔 interval 对有新写入的文件 fsync，
+// onError 接收定时 fsync 的错误
+func newFsyncWriter(ws zapcore.WriteSyncer, path, policy string, interval time.Duration, onError func(error)) *fsyncWriter {
+	w := &fsyncWriter{WriteSyncer: ws, path: path, every: policy == "every"}
+	if policy == "interval" {
+		w.stop, w.done = make(chan struct{}), make(chan struct{})
+		go w.loop(interval, onError)
+	}
+	return w
+}
+
+func (w *fsyncWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteSyncer.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.every {
+		return n, w.fsync()
+	}
+	w.dirty.Store(true)
+	return n, nil
+}
+
+// Sync 显式同步（Logger.Sync、关闭）时总是 fsync
+func (w *fsyncWriter) Sync() error {
+	if err := w.WriteSyncer.Sync(); err != nil {
+		return err
+	}
+	w.dirty.Store(false)
+	return w.fsync()
+}
+
+func (w *fsyncWriter) loop(interval time.Duration, onError func(error)) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !w.dirty.Swap(false) {
+				continue
+			}
+			if err := w.fsync(); err != nil && onError != nil {
+				onError(err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// fsync 对当前文件调用 fsync，文件还不存在时忽略
+func (w *fsyncWriter) fsync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		// 滚动后路径指向新文件，句柄仍指向被滚动的文件，先同步其剩余内容再切换
+		cur, err := os.Stat(w.path)
+		if info, ferr := w.file.Stat(); err != nil || ferr != nil || !os.SameFile(cur, info) {
+			_ = w.file.Sync()
+			_ = w.file.Close()
+			w.file = nil
+		}
+	}
+	if w.file == nil {
+		f, err := os.OpenFile(w.path, os.O_WRONLY, 0)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("open log file for fsync: %w", err)
+		}
+		w.file = f
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("fsync log file: %w", err)
+	}
+	return nil
+}
+
+// Close 停止定时 fsync 并做最后一次 fsync，在写缓冲停止之后、文件关闭之前调用
+func (w *fsyncWriter) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	err := w.fsync()
+	w.mu.Lock()
+	if w.file != nil {
+		_ = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	return err
+}
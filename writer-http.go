@@ -2,99 +2,611 @@ package log
 
 import (
 	"bytes"
+	"cmp"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"go.uber.org/zap/zapcore"
 )
 
+// ErrBufferFull 缓冲区已满且 overflow 策略未能保留日志时返回
+var ErrBufferFull = errors.New("log buffer full, dropping log")
+
+// ErrWriterClosed writer 已关闭后仍有日志写入
+var ErrWriterClosed = errors.New("log writer closed, dropping log")
+
+// ErrDrainTimeout 关闭时未能在 drain-timeout 内发送完缓冲区中的日志
+var ErrDrainTimeout = errors.New("log drain timeout")
+
+// ErrSpoolExpired 磁盘队列中的批次超过 spool-max-age，不再重放
+var ErrSpoolExpired = errors.New("spooled batch expired, dropping logs")
+
+// 复用的缓冲区，超过上限的不放回，避免偶发的大日志长期占用内存
+const (
+	maxPooledEntry = 64 << 10
+	maxPooledBody  = 4 << 20
+)
+
+var (
+	entryPool = sync.Pool{New: func() any { return new(queuedEntry) }}
+	bodyPool  = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+	gzipPool  = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+)
+
+// queuedEntry 缓冲区中的一条日志及其进入缓冲区的时间
+type queuedEntry struct {
+	bytes.Buffer
+	at time.Time
+}
+
+// newEntry 将日志复制到复用的缓冲区，发送、写入磁盘或丢弃后调用 freeEntry 放回
+func newEntry(p []byte) *queuedEntry {
+	b := entryPool.Get().(*queuedEntry)
+	b.Reset()
+	b.Write(p)
+	b.at = time.Now()
+	return b
+}
+
+func freeEntry(b *queuedEntry) {
+	if b.Cap() <= maxPooledEntry {
+		entryPool.Put(b)
+	}
+}
+
 // HTTPWriter 异步批量发送日志到 HTTP 端点
 type HTTPWriter struct {
-	ctx        context.Context
-	client     *http.Client
-	buffer     chan []byte
-	cancel     context.CancelFunc
-	url        string
-	wg         sync.WaitGroup
-	batchSize  int
-	maxRetries int
+	ctx           context.Context
+	client        *http.Client
+	buffer        chan *queuedEntry // queue=channel
+	ring          *ringQueue        // queue=ring
+	cancel        context.CancelFunc
+	onDrop        func(n int, reason error)
+	headers       http.Header
+	url           string
+	healthURL     string
+	method        string
+	expectStatus  []int
+	username      string
+	password      string
+	signKey       []byte
+	wg            sync.WaitGroup
+	senders       sync.WaitGroup
+	closeMu       sync.RWMutex
+	closed        bool
+	zstd          *zstd.Encoder
+	compress      string
+	payload       string
+	contentType   string
+	envelopeKey   []byte
+	spool         *spool
+	spoolRate     float64
+	spoolMaxAge   time.Duration
+	replay        chan struct{}
+	spillFile     *os.File
+	spillPath     string
+	overflow      string
+	flushInterval time.Duration
+	timeout       time.Duration
+	blockTimeout  time.Duration
+	drainTimeout  time.Duration
+	retryMin      time.Duration
+	retryMax      time.Duration
+	dropped       atomic.Uint64
+	delivered     atomic.Uint64 // 发送成功或写入磁盘队列的条数
+	sent          writerStats
+	latency       latencyHistogram
+	peak          atomic.Int64 // 缓冲区深度的最高值
+	spillMu       sync.Mutex
+	batchSize     int
+	maxBatchBytes int64
+	maxRetries    int
 }
 
 // Write 实现 io.Writer 接口
 func (w *HTTPWriter) Write(p []byte) (n int, err error) {
 	// 复制数据避免外部修改
-	data := make([]byte, len(p))
-	copy(data, p)
+	data := newEntry(p)
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		freeEntry(data)
+		w.drop(1, ErrWriterClosed)
+		return len(p), ErrWriterClosed
+	}
 	// 非阻塞写入
+	if w.enqueue(data) {
+		return len(p), nil
+	}
+	// 缓冲区满，按 overflow 策略处理
+	switch w.overflow {
+	case "block":
+		if w.enqueueWait(data) {
+			return len(p), nil
+		}
+	case "drop-oldest":
+		w.enqueueEvict(data)
+		return len(p), nil
+	case "spill":
+		if err := w.spill(data.Bytes()); err == nil {
+			freeEntry(data)
+			return len(p), nil
+		}
+	}
+	freeEntry(data)
+	w.drop(1, ErrBufferFull)
+	return len(p), ErrBufferFull
+}
+
+// enqueue 非阻塞地写入缓冲区，已满时返回 false
+func (w *HTTPWriter) enqueue(data *queuedEntry) bool {
+	if w.ring != nil {
+		return w.ring.push(data)
+	}
 	select {
 	case w.buffer <- data:
-		return len(p), nil
+		return true
 	default:
-		// 缓冲区满，直接丢弃（或者可以选择同步发送）
-		return len(p), fmt.Errorf("log buffer full, dropping log")
+		return false
+	}
+}
+
+// enqueueWait 在 block-timeout 内等待缓冲区出现空位
+func (w *HTTPWriter) enqueueWait(data *queuedEntry) bool {
+	timer := time.NewTimer(w.blockTimeout)
+	defer timer.Stop()
+	if w.ring == nil {
+		select {
+		case w.buffer <- data:
+			return true
+		case <-timer.C:
+			return false
+		}
+	}
+	for {
+		select {
+		case <-w.ring.space:
+			if w.ring.push(data) {
+				return true
+			}
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// enqueueEvict 丢弃最旧的日志腾出空位，并发写入时可能连续丢弃多条（仅 queue=ring）
+func (w *HTTPWriter) enqueueEvict(data *queuedEntry) {
+	for !w.ring.push(data) {
+		if old, ok := w.ring.pop(); ok {
+			freeEntry(old)
+			w.drop(1, ErrBufferFull)
+		}
+	}
+}
+
+// Dropped 返回因缓冲区满或重试耗尽而丢弃的日志条数
+func (w *HTTPWriter) Dropped() uint64 { return w.dropped.Load() }
+
+// drop 记录丢弃的日志条数并触发回调
+func (w *HTTPWriter) drop(n int, reason error) {
+	w.dropped.Add(uint64(n))
+	if w.onDrop != nil {
+		w.onDrop(n, reason)
+	}
+}
+
+// spill 将溢出的日志追加写入本地文件
+func (w *HTTPWriter) spill(data []byte) error {
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+	if w.spillFile == nil {
+		if err := os.MkdirAll(filepath.Dir(w.spillPath), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(w.spillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		w.spillFile = f
 	}
+	_, err := w.spillFile.Write(data)
+	return err
 }
 
 // Sync 实现 zapcore.WriteSyncer 接口
 func (w *HTTPWriter) Sync() error { return nil }
 
-// Close 关闭 writer 并等待所有日志发送完成
+// Close 停止接收日志，在 drain-timeout 内发送缓冲区中剩余的日志后再取消进行中的请求，
+// 超时后未能发送（也未写入磁盘队列）的日志条数通过 ErrDrainTimeout 报告
 func (w *HTTPWriter) Close() error {
+	_, err := w.CloseContext(context.Background())
+	return err
+}
+
+// CloseContext 与 Close 相同，但 ctx 先于 drain-timeout 结束时立即取消发送，
+// 返回关闭期间发送（或写入磁盘队列）与放弃的日志条数
+func (w *HTTPWriter) CloseContext(ctx context.Context) (DrainResult, error) {
+	w.closeMu.Lock()
+	if w.closed {
+		w.closeMu.Unlock()
+		return DrainResult{}, nil
+	}
+	w.closed = true
+	if w.ring != nil {
+		close(w.ring.done)
+	} else {
+		close(w.buffer)
+	}
+	w.closeMu.Unlock()
+
+	delivered, dropped := w.delivered.Load(), w.dropped.Load()
+	drained := make(chan struct{})
+	go func() {
+		w.senders.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(w.drainTimeout)
+	defer timer.Stop()
+	// abandoned 根据结束原因生成放弃日志时的错误
+	var abandoned func(n int) error
+	select {
+	case <-drained:
+	case <-timer.C:
+		abandoned = func(n int) error {
+			return fmt.Errorf("%w: %d log entries abandoned after %s", ErrDrainTimeout, n, w.drainTimeout)
+		}
+	case <-ctx.Done():
+		abandoned = func(n int) error {
+			return fmt.Errorf("%w: %d log entries abandoned: %w", ErrDrainTimeout, n, ctx.Err())
+		}
+	}
+	// 取消进行中的请求，worker 把剩余日志写入磁盘队列或丢弃
 	w.cancel()
-	close(w.buffer)
+	<-drained
 	w.wg.Wait()
-	return nil
+	result := DrainResult{
+		Flushed:   int(w.delivered.Load() - delivered),
+		Abandoned: int(w.dropped.Load() - dropped),
+	}
+	var firstErr error
+	if abandoned != nil && result.Abandoned > 0 {
+		firstErr = abandoned(result.Abandoned)
+	}
+	if w.zstd != nil {
+		if err := w.zstd.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+	if w.spillFile != nil {
+		if err := w.spillFile.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return result, firstErr
+}
+
+// batcher 累积日志，达到条数或字节上限时发送，发送后放回缓冲区并复用批次切片
+type batcher struct {
+	w       *HTTPWriter
+	batch   [][]byte
+	entries []*queuedEntry
+	size    int64
+}
+
+func (w *HTTPWriter) newBatcher() *batcher {
+	return &batcher{
+		w:       w,
+		batch:   make([][]byte, 0, w.batchSize),
+		entries: make([]*queuedEntry, 0, w.batchSize),
+	}
+}
+
+func (b *batcher) add(e *queuedEntry) {
+	data, limit := e.Bytes(), b.w.maxBatchBytes
+	if limit > 0 && len(b.batch) > 0 && b.size+int64(len(data)) > limit {
+		b.send()
+	}
+	b.batch, b.entries = append(b.batch, data), append(b.entries, e)
+	b.size += int64(len(data))
+	if len(b.batch) >= b.w.batchSize || (limit > 0 && b.size >= limit) {
+		b.send()
+	}
+}
+
+func (b *batcher) send() {
+	b.w.flush(b.batch)
+	now := time.Now()
+	for _, e := range b.entries {
+		b.w.latency.observe(now.Sub(e.at))
+		freeEntry(e)
+	}
+	b.batch, b.entries, b.size = b.batch[:0], b.entries[:0], 0
 }
 
-// worker 后台工作协程，批量发送日志
+// worker 从缓冲区取出日志并批量发送，多个 worker 并发时批次之间不保证顺序
 func (w *HTTPWriter) worker() {
-	defer w.wg.Done()
-	batch := make([][]byte, 0, w.batchSize)
-	ticker := time.NewTicker(1 * time.Second)
+	defer w.senders.Done()
+	b := w.newBatcher()
+	ticker := time.NewTicker(w.flushInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-w.ctx.Done():
-			// 发送剩余日志
-			if len(batch) > 0 {
-				_ = w.sendBatch(batch)
+			// 取出通道中剩余的日志，发送失败时写入磁盘队列
+			for e := range w.buffer {
+				b.add(e)
 			}
+			b.send()
 			return
-		case data, ok := <-w.buffer:
+		case e, ok := <-w.buffer:
 			if !ok {
 				// 通道关闭，发送剩余日志
-				if len(batch) > 0 {
-					_ = w.sendBatch(batch)
-				}
+				b.send()
 				return
 			}
-			batch = append(batch, data)
-			if len(batch) >= w.batchSize {
-				_ = w.sendBatch(batch)
-				batch = batch[:0]
-			}
+			w.observeDepth(len(w.buffer) + 1)
+			b.add(e)
 		case <-ticker.C:
 			// 定时发送
-			if len(batch) > 0 {
-				_ = w.sendBatch(batch)
-				batch = batch[:0]
+			if len(b.batch) > 0 {
+				b.send()
+			}
+		}
+	}
+}
+
+// ringWorker 与 worker 相同，从 ringQueue 取出日志
+func (w *HTTPWriter) ringWorker() {
+	defer w.senders.Done()
+	b := w.newBatcher()
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	// drain 取出当前所有可读的日志
+	drain := func() {
+		w.observeDepth(w.ring.len())
+		for e, ok := w.ring.pop(); ok; e, ok = w.ring.pop() {
+			b.add(e)
+		}
+	}
+	for {
+		select {
+		case <-w.ctx.Done():
+			drain()
+			b.send()
+			return
+		case <-w.ring.done:
+			drain()
+			b.send()
+			return
+		case <-w.ring.ready:
+			drain()
+		case <-ticker.C:
+			if len(b.batch) > 0 {
+				b.send()
+			}
+		}
+	}
+}
+
+// observeDepth 记录消费者取出日志时看到的缓冲区深度
+func (w *HTTPWriter) observeDepth(n int) {
+	for {
+		peak := w.peak.Load()
+		if int64(n) <= peak || w.peak.CompareAndSwap(peak, int64(n)) {
+			return
+		}
+	}
+}
+
+// flush 发送批次，失败时写入磁盘队列；发送成功后触发队列重放
+func (w *HTTPWriter) flush(batch [][]byte) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := w.send(batch); err != nil {
+		// 采集端明确拒绝的批次重放也不会成功，不写入磁盘队列
+		if w.spool != nil && isRetryable(err) && w.spool.save(batch) == nil {
+			w.delivered.Add(uint64(len(batch)))
+			return
+		}
+		w.drop(len(batch), err)
+		return
+	}
+	w.delivered.Add(uint64(len(batch)))
+	w.kickReplay()
+}
+
+// kickReplay 通知重放协程检查磁盘队列
+func (w *HTTPWriter) kickReplay() {
+	if w.spool != nil {
+		notify(w.replay)
+	}
+}
+
+// replayWorker 按写入顺序（最旧的优先）重新发送磁盘队列中的批次，发送失败时等待下次通知。
+// 超过 spool-max-age 的批次丢弃，spool-rate 限制重放速度，避免启动或采集端恢复时集中发送积压的批次
+func (w *HTTPWriter) replayWorker() {
+	defer w.wg.Done()
+	var next time.Time
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.replay:
+		}
+		for _, path := range w.spool.list() {
+			batch, err := w.spool.load(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // 已被 prune 删除
+			}
+			if err != nil {
+				// 无法读取或被截断的批次不会恢复，删除并计入丢弃，避免每次重放都重读并占用 spool-max
+				w.drop(max(len(batch), 1), fmt.Errorf("failed to load spooled batch: %w", err))
+				w.spool.remove(path)
+				continue
+			}
+			if w.spoolMaxAge > 0 {
+				if at, ok := w.spool.savedAt(path); ok && time.Since(at) > w.spoolMaxAge {
+					w.drop(len(batch), ErrSpoolExpired)
+					w.spool.remove(path)
+					continue
+				}
+			}
+			if w.spoolRate > 0 {
+				if !w.waitUntil(next) {
+					return
+				}
+				next = time.Now().Add(time.Duration(float64(time.Second) / w.spoolRate))
+			}
+			if err := w.send(batch); err != nil {
+				if isRetryable(err) {
+					break
+				}
+				w.drop(len(batch), err)
 			}
+			w.spool.remove(path)
 		}
 	}
 }
 
+// waitUntil 等待到 t，writer 关闭时返回 false
+func (w *HTTPWriter) waitUntil(t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return w.ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-w.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// send 发送批次并记录运行状态
+func (w *HTTPWriter) send(batch [][]byte) error {
+	err := w.sendBatch(batch)
+	if err != nil {
+		w.sent.failure(err)
+		return err
+	}
+	n := 0
+	for _, data := range batch {
+		n += len(data)
+	}
+	w.sent.success(n)
+	return nil
+}
+
+func (w *HTTPWriter) stats(st *AdaptorStats) {
+	w.sent.stats(st)
+	st.QueuePeak += int(w.peak.Load())
+	w.latency.stats(&st.QueueLatency)
+	if w.ring != nil {
+		st.Queued += w.ring.len()
+		st.QueueSize += w.ring.cap()
+	} else {
+		st.Queued += len(w.buffer)
+		st.QueueSize += cap(w.buffer)
+	}
+	if w.spool != nil {
+		files, size := w.spool.usage()
+		st.Spooled += files
+		st.SpoolBytes += size
+		st.SpoolMax += w.spool.max
+	}
+}
+
 // sendBatch 批量发送日志
 func (w *HTTPWriter) sendBatch(batch [][]byte) error {
 	if len(batch) == 0 {
 		return nil
 	}
-	// 合并所有日志
-	var buf bytes.Buffer
+	// 压缩后的请求体是独立的内存，编码缓冲区可以复用；未压缩时请求体直接引用编码结果，
+	// Transport 可能在请求返回后仍在读取，不能放回池中
+	raw := new(bytes.Buffer)
+	if w.compress != "none" {
+		raw = bodyPool.Get().(*bytes.Buffer)
+		raw.Reset()
+		defer func() {
+			if raw.Cap() <= maxPooledBody {
+				bodyPool.Put(raw)
+			}
+		}()
+	}
+	w.encodeBatch(raw, batch)
+	body, err := w.compressBody(raw.Bytes())
+	if err != nil {
+		return err
+	}
+	traceparent := batchTraceparent(batch)
+	// 重试发送
+	var lastErr error
+	for i := 0; i <= w.maxRetries; i++ {
+		err := w.post(body, traceparent)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		// 不可重试的状态码或最后一次尝试，直接返回
+		if !isRetryable(err) {
+			return fmt.Errorf("failed to send logs: %w", err)
+		}
+		if i == w.maxRetries {
+			break
+		}
+		// 指数退避，已关闭时不再重试
+		timer := time.NewTimer(backoff(i, w.retryMin, w.retryMax, err))
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send logs, writer closed: %w", lastErr)
+		case <-timer.C:
+		}
+	}
+	return fmt.Errorf("failed to send logs after %d retries: %w", w.maxRetries, lastErr)
+}
+
+// encodeBatch 按 payload 格式将所有日志合并写入 buf
+func (w *HTTPWriter) encodeBatch(buf *bytes.Buffer, batch [][]byte) {
+	size := len(batch) + 2 + len(w.envelopeKey)
+	for _, data := range batch {
+		size += len(data)
+	}
+	buf.Grow(size)
+	if w.payload == "ndjson" {
+		for _, data := range batch {
+			buf.Write(bytes.TrimSpace(data))
+			buf.WriteByte('\n')
+		}
+		return
+	}
+	if w.payload == "envelope" {
+		buf.WriteString(`{`)
+		buf.Write(w.envelopeKey)
+		buf.WriteString(`:`)
+	}
 	buf.WriteByte('[')
 	for i, data := range batch {
 		if i > 0 {
@@ -103,28 +615,59 @@ func (w *HTTPWriter) sendBatch(batch [][]byte) error {
 		buf.Write(bytes.TrimSpace(data))
 	}
 	buf.WriteByte(']')
-	// 重试发送
-	var lastErr error
-	for i := 0; i <= w.maxRetries; i++ {
-		if err := w.post(buf.Bytes()); err != nil {
-			lastErr = err
-			time.Sleep(time.Duration(i+1) * time.Second) // 指数退避
-			continue
+	if w.payload == "envelope" {
+		buf.WriteByte('}')
+	}
+}
+
+// compressBody 按配置压缩请求体
+func (w *HTTPWriter) compressBody(data []byte) ([]byte, error) {
+	switch w.compress {
+	case "gzip":
+		var buf bytes.Buffer
+		buf.Grow(len(data) / 4)
+		zw := gzipPool.Get().(*gzip.Writer)
+		defer gzipPool.Put(zw)
+		zw.Reset(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip batch failed: %w", err)
 		}
-		return nil
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip batch failed: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "zstd":
+		return w.zstd.EncodeAll(data, make([]byte, 0, len(data)/4)), nil
+	default:
+		return data, nil
 	}
-	return fmt.Errorf("failed to send logs after %d retries: %w", w.maxRetries, lastErr)
 }
 
-// post 发送 HTTP 请求
-func (w *HTTPWriter) post(data []byte) error {
-	ctx, cancel := context.WithTimeout(w.ctx, 10*time.Second)
+// post 发送 HTTP 请求，traceparent 非空时附加 W3C 链路头
+func (w *HTTPWriter) post(data []byte, traceparent string) error {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, w.method, w.url, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("create request failed: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	// 显式设置的 Authorization 头优先
+	for name, values := range w.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	if w.compress != "none" {
+		req.Header.Set("Content-Encoding", w.compress)
+	}
+	if traceparent != "" {
+		req.Header.Set("traceparent", traceparent)
+	}
+	if w.signKey != nil {
+		signRequest(req, w.signKey, data, time.Now())
+	}
 	resp, err := w.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("send request failed: %w", err)
@@ -132,40 +675,274 @@ func (w *HTTPWriter) post(data []byte) error {
 	defer resp.Body.Close()
 	// 读取并丢弃响应体
 	_, _ = io.Copy(io.Discard, resp.Body)
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	if !w.expected(resp.StatusCode) {
+		return newHTTPStatusError(resp, time.Now())
+	}
+	return nil
+}
+
+// expected 判断响应状态码是否表示发送成功，未配置 expect-status 时接受所有非错误状态码
+func (w *HTTPWriter) expected(code int) bool {
+	if len(w.expectStatus) == 0 {
+		return code < 400
+	}
+	return slices.Contains(w.expectStatus, code)
+}
+
+// Probe 发送 HEAD 请求探测收集端是否可达：连接失败、认证被拒绝 (401/403)、地址不存在 (404)
+// 或服务端错误 (5xx) 时返回错误，并与发送失败一样记录到运行状态、调用 OnError。
+// 收集端不支持 HEAD (405/501) 视为可达。ctx 没有截止时间时使用 timeout 选项
+func (w *HTTPWriter) Probe(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+	err := w.probe(ctx)
+	if err != nil {
+		err = fmt.Errorf("probe failed: %w", err)
+		w.sent.failure(err)
+	}
+	return err
+}
+
+func (w *HTTPWriter) probe(ctx context.Context) error {
+	if w.healthURL != "" {
+		return w.probeHealth(ctx)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, w.url, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	for name, values := range w.headers {
+		req.Header[name] = values
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+	switch code := resp.StatusCode; {
+	case code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented:
+		return nil
+	case code == http.StatusUnauthorized || code == http.StatusForbidden || code == http.StatusNotFound || code >= 500:
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// probeHealth 请求健康检查地址，只有 2xx 视为健康。健康检查通常不需要认证，不附带认证与请求头
+func (w *HTTPWriter) probeHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.healthURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check failed: %s", resp.Status)
 	}
 	return nil
 }
 
 // newHTTPWriter 创建 HTTP writer
 func newHTTPWriter(opts *HTTPOptions) (zapcore.WriteSyncer, io.Closer, error) {
+	writer, err := NewHTTPWriter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return writer, writer, nil
+}
+
+// NewHTTPWriter 根据选项创建 HTTP writer，未设置的选项使用与 DSN 相同的默认值
+func NewHTTPWriter(opts *HTTPOptions) (*HTTPWriter, error) {
 	if opts.URL == "" {
-		return nil, nil, fmt.Errorf("HTTP URL is empty")
+		return nil, fmt.Errorf("HTTP URL is empty")
+	}
+	tlsConfig, err := buildTLSConfig(&opts.TLS)
+	if err != nil {
+		return nil, err
 	}
 	tr := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:     tlsConfig,
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 10,
 		MaxConnsPerHost:     100,
 		IdleConnTimeout:     90 * time.Second,
 	}
+	timeout := cmp.Or(opts.Timeout, 10*time.Second)
 	client := &http.Client{
 		Transport: tr,
-		Timeout:   opts.Timeout,
+		Timeout:   timeout,
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	writer := &HTTPWriter{
-		url:        opts.URL,
-		client:     client,
-		buffer:     make(chan []byte, opts.BufferSize),
-		batchSize:  opts.BatchSize,
-		maxRetries: opts.MaxRetries,
-		ctx:        ctx,
-		cancel:     cancel,
+		url:           opts.URL,
+		healthURL:     opts.HealthURL,
+		method:        strings.ToUpper(cmp.Or(opts.Method, http.MethodPost)),
+		expectStatus:  slices.Clone(opts.ExpectStatus),
+		timeout:       timeout,
+		client:        client,
+		headers:       opts.Headers.Clone(),
+		username:      opts.Username,
+		password:      opts.Password,
+		batchSize:     cmp.Or(opts.BatchSize, 100),
+		maxBatchBytes: opts.MaxBatchBytes,
+		flushInterval: cmp.Or(opts.FlushInterval, time.Second),
+		maxRetries:    opts.MaxRetries,
+		retryMin:      cmp.Or(opts.RetryMin, time.Second),
+		retryMax:      cmp.Or(opts.RetryMax, 30*time.Second),
+		compress:      cmp.Or(opts.Compress, "none"),
+		payload:       cmp.Or(opts.Payload, "array"),
+		contentType:   "application/json",
+		overflow:      cmp.Or(opts.Overflow, "drop"),
+		blockTimeout:  cmp.Or(opts.BlockTimeout, time.Second),
+		drainTimeout:  cmp.Or(opts.DrainTimeout, 5*time.Second),
+		spillPath:     opts.SpillPath,
+		onDrop:        opts.OnDrop,
+		sent:          writerStats{onError: opts.OnError},
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	if opts.Spool != "" {
+		if writer.spool, err = newSpool(opts.Spool, opts.SpoolMax); err != nil {
+			cancel()
+			return nil, err
+		}
+		writer.spoolRate, writer.spoolMaxAge = opts.SpoolRate, opts.SpoolMaxAge
+	}
+	bufferSize := cmp.Or(opts.BufferSize, 1024)
+	switch cmp.Or(opts.Queue, "channel") {
+	case "channel":
+		writer.buffer = make(chan *queuedEntry, bufferSize)
+	case "ring":
+		writer.ring = newRingQueue(bufferSize, writer.overflow == "block")
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid queue: %s (supported: channel, ring)", opts.Queue)
+	}
+	switch writer.overflow {
+	case "drop", "block":
+	case "drop-oldest":
+		if writer.ring == nil {
+			cancel()
+			return nil, fmt.Errorf("overflow=drop-oldest requires queue=ring")
+		}
+	case "spill":
+		if writer.spillPath == "" {
+			cancel()
+			return nil, fmt.Errorf("overflow=spill requires spill-path")
+		}
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid overflow policy: %s (supported: drop, drop-oldest, block, spill)", writer.overflow)
+	}
+	switch cmp.Or(opts.Sign, "none") {
+	case "none":
+	case "hmac-sha256":
+		writer.signKey = opts.SignKey
+		if writer.signKey == nil {
+			env := cmp.Or(opts.SignKeyEnv, "LOG_HMAC_KEY")
+			if key := os.Getenv(env); key != "" {
+				writer.signKey = []byte(key)
+			}
+		}
+		if len(writer.signKey) == 0 {
+			cancel()
+			return nil, fmt.Errorf("sign key is empty (set SignKey or env %s)", cmp.Or(opts.SignKeyEnv, "LOG_HMAC_KEY"))
+		}
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid sign: %s (supported: hmac-sha256, none)", opts.Sign)
+	}
+	switch writer.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid method: %s (supported: POST, PUT, PATCH)", writer.method)
+	}
+	switch writer.payload {
+	case "array":
+	case "ndjson":
+		writer.contentType = "application/x-ndjson"
+	case "envelope":
+		key, _ := json.Marshal(cmp.Or(opts.EnvelopeKey, "logs"))
+		writer.envelopeKey = key
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid payload format: %s (supported: array, ndjson, envelope)", writer.payload)
+	}
+	if opts.ContentType != "" {
+		writer.contentType = opts.ContentType
+	}
+	switch writer.compress {
+	case "none", "gzip":
+	case "zstd":
+		if writer.zstd, err = zstd.NewWriter(nil); err != nil {
+			cancel()
+			return nil, fmt.Errorf("create zstd encoder failed: %w", err)
+		}
+	default:
+		cancel()
+		return nil, fmt.Errorf("invalid compress format: %s (supported: gzip, zstd, none)", writer.compress)
 	}
 	// 启动后台工作协程
-	writer.wg.Add(1)
-	go writer.worker()
-	return writer, writer, nil
+	workers := cmp.Or(opts.Workers, 1)
+	if workers < 1 {
+		cancel()
+		return nil, fmt.Errorf("invalid workers: %d", opts.Workers)
+	}
+	writer.senders.Add(workers)
+	for range workers {
+		if writer.ring != nil {
+			go writer.ringWorker()
+		} else {
+			go writer.worker()
+		}
+	}
+	// 启动磁盘队列重放协程，并立即重放上次未发送的批次
+	if opts.Spool != "" {
+		writer.replay = make(chan struct{}, 1)
+		writer.wg.Add(1)
+		go writer.replayWorker()
+		writer.kickReplay()
+	}
+	return writer, nil
+}
+
+// buildTLSConfig 根据选项构建 TLS 配置
+func buildTLSConfig(opts *TLSOptions) (*tls.Config, error) {
+	if opts.Config != nil {
+		return opts.Config.Clone(), nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         cmp.Or(opts.MinVersion, tls.VersionTLS12),
+		InsecureSkipVerify: opts.Insecure,
+	}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file failed: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates in CA file %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate failed: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
 }
@@ -0,0 +1,779 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stalledServer 返回一个在 release 关闭前阻塞所有请求的测试服务端
+func stalledServer(t *testing.T) (*httptest.Server, chan struct{}, chan struct{}) {
+	t.Helper()
+	started := make(chan struct{}, 16)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	return srv, started, release
+}
+
+func TestHTTPWriterEncodeBatch(t *testing.T) {
+	batch := [][]byte{[]byte("{\"msg\":\"a\"}\n"), []byte("{\"msg\":\"b\"}\n")}
+	tests := []struct {
+		name        string
+		opts        HTTPOptions
+		want        string
+		contentType string
+	}{
+		{"array", HTTPOptions{}, `[{"msg":"a"},{"msg":"b"}]`, "application/json"},
+		{"ndjson", HTTPOptions{Payload: "ndjson"}, "{\"msg\":\"a\"}\n{\"msg\":\"b\"}\n", "application/x-ndjson"},
+		{"envelope default key", HTTPOptions{Payload: "envelope"}, `{"logs":[{"msg":"a"},{"msg":"b"}]}`, "application/json"},
+		{"envelope custom key", HTTPOptions{Payload: "envelope", EnvelopeKey: "events"}, `{"events":[{"msg":"a"},{"msg":"b"}]}`, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.URL = "http://localhost:3000/logs"
+			w, err := NewHTTPWriter(&tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			var buf bytes.Buffer
+			w.encodeBatch(&buf, batch)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("encodeBatch() = %q, want %q", got, tt.want)
+			}
+			if w.contentType != tt.contentType {
+				t.Errorf("contentType = %q, want %q", w.contentType, tt.contentType)
+			}
+		})
+	}
+
+	if _, err := NewHTTPWriter(&HTTPOptions{URL: "http://localhost:3000/logs", Payload: "xml"}); err == nil {
+		t.Error("expected error for unknown payload")
+	}
+}
+
+func TestHTTPWriterOverflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        HTTPOptions
+		wantDropped uint64
+		wantSpilled bool
+		minBlocked  time.Duration
+	}{
+		{"drop", HTTPOptions{Overflow: "drop"}, 1, false, 0},
+		{"block", HTTPOptions{Overflow: "block", BlockTimeout: 50 * time.Millisecond}, 1, false, 50 * time.Millisecond},
+		{"spill", HTTPOptions{Overflow: "spill"}, 0, true, 0},
+		{"ring drop", HTTPOptions{Queue: "ring", Overflow: "drop"}, 1, false, 0},
+		{"ring block", HTTPOptions{Queue: "ring", Overflow: "block", BlockTimeout: 50 * time.Millisecond}, 1, false, 50 * time.Millisecond},
+		{"ring spill", HTTPOptions{Queue: "ring", Overflow: "spill"}, 0, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, started, release := stalledServer(t)
+			spillPath := filepath.Join(t.TempDir(), "overflow", "spill.log")
+			tt.opts.URL = srv.URL
+			tt.opts.BufferSize = 1
+			tt.opts.BatchSize = 1
+			tt.opts.SpillPath = spillPath
+			w, err := NewHTTPWriter(&tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 第一条被 worker 取走并阻塞在请求中，之后占满缓冲区（ring 的容量至少为 2）
+			_, _ = w.Write([]byte("{\"n\":1}\n"))
+			<-started
+			var st AdaptorStats
+			w.stats(&st)
+			for range st.QueueSize {
+				_, _ = w.Write([]byte("{\"n\":2}\n"))
+			}
+
+			start := time.Now()
+			_, err = w.Write([]byte("{\"n\":3}\n"))
+			if elapsed := time.Since(start); elapsed < tt.minBlocked {
+				t.Errorf("Write returned after %v, want at least %v", elapsed, tt.minBlocked)
+			}
+			if (err != nil) != (tt.wantDropped > 0) {
+				t.Errorf("Write() error = %v, want dropped %v", err, tt.wantDropped > 0)
+			}
+			if got := w.Dropped(); got != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", got, tt.wantDropped)
+			}
+
+			close(release)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			spilled, _ := os.ReadFile(spillPath)
+			if got := strings.Contains(string(spilled), `{"n":3}`); got != tt.wantSpilled {
+				t.Errorf("spilled = %v (%q), want %v", got, spilled, tt.wantSpilled)
+			}
+		})
+	}
+
+	if _, err := NewHTTPWriter(&HTTPOptions{URL: "http://localhost:3000/logs", Overflow: "spill"}); err == nil {
+		t.Error("expected error for spill without spill-path")
+	}
+}
+
+func TestHTTPWriterWorkers(t *testing.T) {
+	srv, started, release := stalledServer(t)
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, BatchSize: 1, Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个 worker 各自持有一个阻塞中的请求
+	for i := range 3 {
+		_, _ = w.Write([]byte(`{"n":` + strconv.Itoa(i) + `}`))
+	}
+	for i := range 3 {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d concurrent requests, want 3", i)
+		}
+	}
+	close(release)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Workers: -1}); err == nil {
+		t.Error("expected error for negative workers")
+	}
+}
+
+func TestHTTPWriterMaxBatchBytes(t *testing.T) {
+	requests := make(chan []any, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		requests <- entries
+	}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, MaxBatchBytes: 20, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 每条 9 字节，两条一批；单条超过上限时单独发送
+	for _, entry := range []string{`{"n":"1"}`, `{"n":"2"}`, `{"n":"3"}`, `{"n":"4"}`, `{"n":"12345678901234567890"}`} {
+		_, _ = w.Write([]byte(entry))
+	}
+	for _, want := range []int{2, 2, 1} {
+		select {
+		case entries := <-requests:
+			if len(entries) != want {
+				t.Errorf("batch has %d entries, want %d", len(entries), want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for batch")
+		}
+	}
+}
+
+func TestHTTPWriterBufferReuse(t *testing.T) {
+	requests := make(chan []string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var entries []struct{ N string }
+		_ = json.NewDecoder(zr).Decode(&entries)
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.N)
+		}
+		requests <- got
+	}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, BatchSize: 2, Compress: "gzip", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 调用方复用同一个切片，已写入的日志不受影响；复用的缓冲区与 gzip 压缩器不会串批次
+	p := []byte(`{"n":"0"}`)
+	for i := range 6 {
+		p[6] = byte('0' + i)
+		_, _ = w.Write(p)
+	}
+	for _, want := range []string{"0 1", "2 3", "4 5"} {
+		select {
+		case got := <-requests:
+			if strings.Join(got, " ") != want {
+				t.Errorf("batch = %v, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for batch")
+		}
+	}
+}
+
+func TestHTTPWriterCloseDrain(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		received.Add(int32(len(entries)))
+	}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		_, _ = w.Write([]byte(`{"n":` + strconv.Itoa(i) + `}`))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := received.Load(); got != 10 {
+		t.Errorf("received %d entries after Close, want 10", got)
+	}
+
+	// 关闭后写入不会 panic
+	if _, err := w.Write([]byte(`{"n":10}`)); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Write after Close error = %v, want ErrWriterClosed", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+}
+
+func TestHTTPWriterCloseDrainTimeout(t *testing.T) {
+	srv, started, release := stalledServer(t)
+	defer close(release)
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, BatchSize: 1, DrainTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"n":1}`))
+	<-started
+	_, _ = w.Write([]byte(`{"n":2}`))
+
+	start := time.Now()
+	err = w.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %v, want about the drain timeout", elapsed)
+	}
+	if !errors.Is(err, ErrDrainTimeout) || !strings.Contains(err.Error(), "2 log entries abandoned") {
+		t.Errorf("Close() error = %v, want 2 entries abandoned", err)
+	}
+}
+
+func TestHTTPWriterCloseContext(t *testing.T) {
+	srv, started, release := stalledServer(t)
+	defer close(release)
+
+	// drain-timeout 较长时以 ctx 的期限为准
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, BatchSize: 1, DrainTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"n":1}`))
+	<-started
+	_, _ = w.Write([]byte(`{"n":2}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := w.CloseContext(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("CloseContext took %v, want about the ctx deadline", elapsed)
+	}
+	if !errors.Is(err, ErrDrainTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CloseContext() error = %v", err)
+	}
+	if result != (DrainResult{Abandoned: 2}) {
+		t.Errorf("result = %+v, want 2 abandoned", result)
+	}
+}
+
+func TestHTTPWriterCloseContextFlushed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		_, _ = w.Write([]byte(`{}`))
+	}
+	result, err := w.CloseContext(context.Background())
+	if err != nil || result != (DrainResult{Flushed: 5}) {
+		t.Errorf("CloseContext() = %+v, %v, want 5 flushed", result, err)
+	}
+}
+
+func TestHTTPWriterSpoolReplay(t *testing.T) {
+	var healthy atomic.Bool
+	received := make(chan string, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer srv.Close()
+
+	spoolDir := filepath.Join(t.TempDir(), "spool")
+	opts := HTTPOptions{URL: srv.URL, Spool: spoolDir, FlushInterval: 20 * time.Millisecond}
+
+	// 采集端不可用，批次写入磁盘队列
+	w, err := NewHTTPWriter(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("{\"msg\":\"while down\"}\n"))
+	_, _ = w.Write([]byte("{\"msg\":\"pending at shutdown\"}\n"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files := w.spool.list(); len(files) == 0 {
+		t.Fatal("expected failed batches to be spooled")
+	}
+
+	// 下次启动时重放
+	healthy.Store(true)
+	w, err = NewHTTPWriter(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var got strings.Builder
+	deadline := time.After(5 * time.Second)
+	for !strings.Contains(got.String(), "while down") || !strings.Contains(got.String(), "pending at shutdown") {
+		select {
+		case body := <-received:
+			got.WriteString(body)
+		case <-deadline:
+			t.Fatalf("expected spooled batches to be replayed, got %q", got.String())
+		}
+	}
+	for len(w.spool.list()) > 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected spool to be emptied, left %v", w.spool.list())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestHTTPWriterSpoolStartupReplay(t *testing.T) {
+	type request struct {
+		body string
+		at   time.Time
+	}
+	received := make(chan request, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{string(body), time.Now()}
+	}))
+	defer srv.Close()
+
+	// 上次运行留下的批次，其中最旧的一个已超过 spool-max-age
+	dir := t.TempDir()
+	s, err := newSpool(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"expired", "first", "second", "third"} {
+		if err := s.save([][]byte{[]byte(`{"msg":"` + msg + `"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldest := s.list()[0]
+	stale := fmt.Sprintf("%020d-000000%s", time.Now().Add(-2*time.Hour).UnixNano(), spoolExt)
+	if err := os.Rename(oldest, filepath.Join(dir, stale)); err != nil {
+		t.Fatal(err)
+	}
+
+	var dropped atomic.Int64
+	var reason atomic.Value
+	w, err := NewHTTPWriter(&HTTPOptions{
+		URL:         srv.URL,
+		Spool:       dir,
+		SpoolRate:   20,
+		SpoolMaxAge: time.Hour,
+		OnDrop: func(n int, err error) {
+			dropped.Add(int64(n))
+			reason.Store(err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var got []request
+	for len(got) < 3 {
+		select {
+		case r := <-received:
+			got = append(got, r)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out, received %v", got)
+		}
+	}
+	for i, want := range []string{"first", "second", "third"} {
+		if !strings.Contains(got[i].body, want) {
+			t.Errorf("request %d = %s, want %s", i, got[i].body, want)
+		}
+		// 每秒 20 个批次，间隔至少 50ms
+		if i > 0 && got[i].at.Sub(got[i-1].at) < 45*time.Millisecond {
+			t.Errorf("request %d sent %v after previous one", i, got[i].at.Sub(got[i-1].at))
+		}
+	}
+	if dropped.Load() != 1 || reason.Load() != ErrSpoolExpired {
+		t.Errorf("dropped = %d, reason = %v", dropped.Load(), reason.Load())
+	}
+	// 批次发送成功后才从磁盘删除
+	for deadline := time.Now().Add(time.Second); len(s.list()) > 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if files := s.list(); len(files) != 0 {
+		t.Errorf("spool not empty: %v", files)
+	}
+}
+
+func TestHTTPWriterSpoolTruncated(t *testing.T) {
+	received := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer srv.Close()
+
+	// 写入时被中断的批次（没有结尾换行），之后是一个完整的批次
+	dir := t.TempDir()
+	s, err := newSpool(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := filepath.Join(dir, fmt.Sprintf("%020d-000000%s", time.Now().Add(-time.Minute).UnixNano(), spoolExt))
+	if err := os.WriteFile(truncated, []byte(`{"msg":"a"}`+"\n"+`{"msg":"b`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.save([][]byte{[]byte(`{"msg":"ok"}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var dropped atomic.Int64
+	var reason atomic.Value
+	w, err := NewHTTPWriter(&HTTPOptions{
+		URL:   srv.URL,
+		Spool: dir,
+		OnDrop: func(n int, err error) {
+			dropped.Add(int64(n))
+			reason.Store(err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	select {
+	case body := <-received:
+		if !strings.Contains(body, `"ok"`) {
+			t.Errorf("replayed %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for replay")
+	}
+	// 被截断的批次删除并计入丢弃，不会反复重读
+	if _, err := os.Stat(truncated); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("truncated batch not removed: %v", err)
+	}
+	if err, _ := reason.Load().(error); dropped.Load() != 2 || err == nil || !strings.Contains(err.Error(), "truncated spool batch") {
+		t.Errorf("dropped = %d, reason = %v", dropped.Load(), reason.Load())
+	}
+}
+
+func TestSpoolMax(t *testing.T) {
+	s, err := newSpool(t.TempDir(), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		if err := s.save([][]byte{[]byte(strings.Repeat("x", 15) + strconv.Itoa(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := s.list()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 newest batches within budget, got %d", len(files))
+	}
+	batch, err := s.load(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 1 || !strings.HasSuffix(string(batch[0]), "4") {
+		t.Fatalf("expected newest batch to be kept, got %q", batch)
+	}
+}
+
+func TestHTTPWriterRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    []int
+		wantCalls int32
+	}{
+		{"bad request is not retried", []int{http.StatusBadRequest}, 1},
+		{"retry after 429", []int{http.StatusTooManyRequests, http.StatusOK}, 2},
+		{"retry after 502", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}, 3},
+		{"gives up after max retries", []int{500, 500, 500, 500, 500}, 3},
+		{"unexpected success status is not retried", []int{http.StatusNoContent}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				code := tt.status[min(int(n), len(tt.status))-1]
+				if code == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(code)
+			}))
+			defer srv.Close()
+
+			w, err := NewHTTPWriter(&HTTPOptions{
+				URL:          srv.URL,
+				MaxRetries:   2,
+				RetryMin:     time.Millisecond,
+				RetryMax:     5 * time.Millisecond,
+				ExpectStatus: []int{http.StatusOK, http.StatusAccepted},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			err = w.sendBatch([][]byte{[]byte(`{"msg":"retry"}`)})
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			wantErr := tt.status[tt.wantCalls-1] != http.StatusOK
+			if (err != nil) != wantErr {
+				t.Errorf("sendBatch() error = %v, wantErr %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPWriterOnDrop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var dropped atomic.Int64
+	var reason atomic.Value
+	w, err := NewHTTPWriter(&HTTPOptions{
+		URL:       srv.URL,
+		BatchSize: 2,
+		OnDrop: func(n int, err error) {
+			dropped.Add(int64(n))
+			reason.Store(err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte(`{"n":1}`))
+	_, _ = w.Write([]byte(`{"n":2}`))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 采集端拒绝的批次整体丢弃
+	if got := dropped.Load(); got != 2 {
+		t.Errorf("OnDrop count = %d, want 2", got)
+	}
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+	if err, _ := reason.Load().(error); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("OnDrop reason = %v, want status 400", err)
+	}
+}
+
+func TestHTTPWriterProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"head not allowed", http.StatusMethodNotAllowed, false},
+		{"unauthorized", http.StatusUnauthorized, true},
+		{"not found", http.StatusNotFound, true},
+		{"server error", http.StatusBadGateway, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("method = %s", r.Method)
+				}
+				auth = r.Header.Get("Authorization")
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			var reported error
+			w, err := NewHTTPWriter(&HTTPOptions{
+				URL:     srv.URL,
+				Headers: http.Header{"Authorization": {"Bearer token"}},
+				OnError: func(err error) { reported = err },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			err = w.Probe(context.Background())
+			if (err != nil) != tt.wantErr || (reported != nil) != tt.wantErr {
+				t.Errorf("Probe() = %v, reported %v, wantErr %v", err, reported, tt.wantErr)
+			}
+			if auth != "Bearer token" {
+				t.Errorf("Authorization = %q", auth)
+			}
+		})
+	}
+
+	t.Run("health url", func(t *testing.T) {
+		healthy := true
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != "/health" {
+				t.Errorf("%s %s", r.Method, r.URL.Path)
+			}
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+		w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL + "/ingest", HealthURL: srv.URL + "/health"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		if err := w.Probe(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		healthy = false
+		if err := w.Probe(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Probe() = %v", err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+		w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		if err := w.Probe(context.Background()); err == nil {
+			t.Fatal("expected error")
+		}
+		var st AdaptorStats
+		w.stats(&st)
+		if !strings.Contains(st.LastError, "probe failed") {
+			t.Errorf("LastError = %q", st.LastError)
+		}
+	})
+}
+
+func TestHTTPWriterQueueMetrics(t *testing.T) {
+	srv, started, release := stalledServer(t)
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, BatchSize: 5, BufferSize: 64, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一批发送被阻塞期间，后续日志堆积在缓冲区
+	for range 5 {
+		_, _ = w.Write([]byte(`{"msg":"queued"}`))
+	}
+	<-started
+	for range 10 {
+		_, _ = w.Write([]byte(`{"msg":"queued"}`))
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var st AdaptorStats
+	w.stats(&st)
+	if st.QueuePeak < 10 {
+		t.Errorf("QueuePeak = %d, want >= 10", st.QueuePeak)
+	}
+	h := st.QueueLatency
+	if h.Count != 15 || len(h.Buckets) != len(LatencyBuckets) || h.Buckets[len(h.Buckets)-1] != 15 {
+		t.Fatalf("QueueLatency = %+v", h)
+	}
+	// 阻塞的批次至少等待了 20ms
+	if h.Sum < 15*20*time.Millisecond/3 || h.Quantile(0.99) < 25*time.Millisecond {
+		t.Errorf("latency sum %v, p99 %v", h.Sum, h.Quantile(0.99))
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	var lh latencyHistogram
+	for _, d := range []time.Duration{time.Millisecond, 3 * time.Millisecond, 7 * time.Millisecond, 8 * time.Millisecond, time.Minute} {
+		lh.observe(d)
+	}
+	var h Histogram
+	lh.stats(&h)
+	if h.Count != 5 || h.Buckets[0] != 1 || h.Buckets[1] != 2 || h.Buckets[2] != 4 {
+		t.Fatalf("histogram = %+v", h)
+	}
+	for q, want := range map[float64]time.Duration{0.2: time.Millisecond, 0.5: 10 * time.Millisecond, 0.8: 10 * time.Millisecond, 1: 0} {
+		if got := h.Quantile(q); got != want {
+			t.Errorf("Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+	if (Histogram{}).Quantile(0.5) != 0 {
+		t.Error("empty histogram quantile should be 0")
+	}
+}
@@ -0,0 +1,231 @@
+package log
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// kvBuckets 滚动错误计数的窗口划分的桶数
+const kvBuckets = 60
+
+// kvValue 写入键的值
+type kvValue struct {
+	Count  uint64          `json:"count"`  // 窗口内的错误条数
+	Total  uint64          `json:"total"`  // 进程启动以来的错误条数
+	Window string          `json:"window"` // 窗口长度
+	Time   time.Time       `json:"time"`   // 最近一条错误的时间
+	Entry  json.RawMessage `json:"entry"`  // 最近一条错误，非 JSON 编码时为字符串
+}
+
+type kvBucket struct {
+	slot int64
+	n    uint64
+}
+
+// kvPublisher 记录最近一条日志与滚动窗口内的条数，定期写入 Consul 或 etcd 的键，
+// 状态没有变化时不写入。写入只更新内存，不会阻塞日志调用
+type kvPublisher struct {
+	opts   *KVOptions
+	client *http.Client
+	bucket time.Duration
+	sent   writerStats
+
+	mu        sync.Mutex
+	last      []byte
+	lastTime  time.Time
+	total     uint64
+	buckets   [kvBuckets]kvBucket
+	published [2]uint64 // 上次写入时的 total 与窗口计数
+
+	pushMu sync.Mutex // 保证写入按顺序进行
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newKVPublisher(opts *KVOptions) (*kvPublisher, error) {
+	tlsConfig, err := buildTLSConfig(&opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	window := cmp.Or(opts.Window, time.Hour)
+	p := &kvPublisher{
+		opts:   opts,
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}, Timeout: cmp.Or(opts.Timeout, 5*time.Second)},
+		bucket: max(window/kvBuckets, time.Millisecond),
+		sent:   writerStats{onError: opts.OnError},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run(cmp.Or(opts.Interval, 10*time.Second))
+	return p, nil
+}
+
+// Write 记录一条日志，末尾的换行不保存
+func (p *kvPublisher) Write(b []byte) (int, error) {
+	now := time.Now()
+	slot := now.UnixNano() / int64(p.bucket)
+	p.mu.Lock()
+	p.last = append(p.last[:0], bytes.TrimRight(b, "\n")...)
+	p.lastTime = now
+	p.total++
+	if bucket := &p.buckets[slot%kvBuckets]; bucket.slot != slot {
+		*bucket = kvBucket{slot: slot, n: 1}
+	} else {
+		bucket.n++
+	}
+	p.mu.Unlock()
+	return len(b), nil
+}
+
+// Sync 立即写入变化的状态，zap 在 panic、fatal 日志后调用，保证进程退出前发布
+func (p *kvPublisher) Sync() error {
+	return p.publish(context.Background())
+}
+
+func (p *kvPublisher) run(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = p.publish(context.Background())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// count 返回窗口内的条数，调用方需持有锁
+func (p *kvPublisher) count(now time.Time) uint64 {
+	slot := now.UnixNano() / int64(p.bucket)
+	var n uint64
+	for _, b := range p.buckets {
+		if b.slot <= slot && slot-b.slot < kvBuckets {
+			n += b.n
+		}
+	}
+	return n
+}
+
+// publish 写入当前状态，还没有日志或状态（包括窗口计数）没有变化时不写入
+func (p *kvPublisher) publish(ctx context.Context) error {
+	p.pushMu.Lock()
+	defer p.pushMu.Unlock()
+	p.mu.Lock()
+	state := [2]uint64{p.total, p.count(time.Now())}
+	if p.total == 0 || state == p.published {
+		p.mu.Unlock()
+		return nil
+	}
+	value := kvValue{Count: state[1], Total: state[0], Window: (p.bucket * kvBuckets).String(), Time: p.lastTime}
+	if json.Valid(p.last) {
+		value.Entry = bytes.Clone(p.last)
+	} else {
+		value.Entry, _ = json.Marshal(string(p.last))
+	}
+	p.mu.Unlock()
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := p.send(ctx, body); err != nil {
+		p.sent.failure(err)
+		return err
+	}
+	p.sent.success(len(body))
+	p.mu.Lock()
+	p.published = state
+	p.mu.Unlock()
+	return nil
+}
+
+// send 写入键：Consul 使用 KV API，etcd 使用 v3 的 HTTP/JSON 网关
+func (p *kvPublisher) send(ctx context.Context, value []byte) error {
+	var req *http.Request
+	var err error
+	switch p.opts.Backend {
+	case "etcd":
+		body, _ := json.Marshal(map[string]string{
+			"key":   base64.StdEncoding.EncodeToString([]byte(p.opts.Key)),
+			"value": base64.StdEncoding.EncodeToString(value),
+		})
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, p.opts.Endpoint+"/v3/kv/put", bytes.NewReader(body))
+	default:
+		target := p.opts.Endpoint + "/v1/kv/" + (&url.URL{Path: p.opts.Key}).EscapedPath()
+		req, err = http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(value))
+	}
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return p.do(req, "publish last error")
+}
+
+func (p *kvPublisher) do(req *http.Request, action string) error {
+	if p.opts.Token != "" {
+		req.Header.Set("X-Consul-Token", p.opts.Token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s failed: status %d", action, resp.StatusCode)
+	}
+	return nil
+}
+
+// Probe 有错误时立即写入，否则请求服务端的健康检查接口确认可达
+func (p *kvPublisher) Probe(ctx context.Context) error {
+	p.mu.Lock()
+	total := p.total
+	p.mu.Unlock()
+	var err error
+	if total > 0 {
+		err = p.publish(ctx)
+	} else {
+		path := "/v1/status/leader"
+		if p.opts.Backend == "etcd" {
+			path = "/health"
+		}
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.opts.Endpoint+path, nil); err == nil {
+			if err = p.do(req, "health check"); err != nil {
+				p.sent.failure(err)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("probe failed: %w", err)
+	}
+	return nil
+}
+
+// Close 停止定时写入并写入最终状态
+func (p *kvPublisher) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout)
+		defer cancel()
+		err = p.publish(ctx)
+	})
+	return err
+}
+
+func (p *kvPublisher) stats(st *AdaptorStats) { p.sent.stats(st) }
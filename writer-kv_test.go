@@ -0,0 +1,138 @@
+package log
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKVPublisherConsul(t *testing.T) {
+	values := make(chan kvValue, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v1/kv/app/web-1/last-error" || r.Header.Get("X-Consul-Token") != "secret" {
+			t.Errorf("%s %s token=%q", r.Method, r.URL.Path, r.Header.Get("X-Consul-Token"))
+		}
+		var v kvValue
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Error(err)
+		}
+		values <- v
+		_, _ = io.WriteString(w, "true")
+	}))
+	defer srv.Close()
+
+	p, err := newKVPublisher(&KVOptions{Backend: "consul", Endpoint: srv.URL, Key: "app/web-1/last-error", Token: "secret", Interval: time.Hour, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 还没有错误时不写入
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = p.Write([]byte(`{"msg":"first"}` + "\n"))
+	_, _ = p.Write([]byte(`{"msg":"second"}` + "\n"))
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	v := <-values
+	if v.Count != 2 || v.Total != 2 || v.Window != "1m0s" || string(v.Entry) != `{"msg":"second"}` || v.Time.IsZero() {
+		t.Errorf("value = %+v", v)
+	}
+	// 状态没有变化时不再写入
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// 非 JSON 编码的日志以字符串写入
+	_, _ = p.Write([]byte("level=error msg=third\n"))
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-values; v.Total != 3 || string(v.Entry) != `"level=error msg=third"` {
+		t.Errorf("value = %+v", v)
+	}
+	select {
+	case v := <-values:
+		t.Errorf("unexpected publish: %+v", v)
+	default:
+	}
+	var st AdaptorStats
+	p.stats(&st)
+	if st.Written == 0 || st.LastError != "" {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestKVPublisherEtcd(t *testing.T) {
+	puts := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			_, _ = io.WriteString(w, `{"health":"true"}`)
+		case "/v3/kv/put":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			puts <- body
+			_, _ = io.WriteString(w, "{}")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := newKVPublisher(&KVOptions{Backend: "etcd", Endpoint: srv.URL, Key: "/app/last-error", Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	// 没有错误时探测健康检查接口
+	if err := p.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = p.Write([]byte(`{"msg":"boom"}`))
+	if err := p.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	body := <-puts
+	key, _ := base64.StdEncoding.DecodeString(body["key"])
+	value, _ := base64.StdEncoding.DecodeString(body["value"])
+	if string(key) != "/app/last-error" || !strings.Contains(string(value), `"entry":{"msg":"boom"}`) || !strings.Contains(string(value), `"window":"1h0m0s"`) {
+		t.Errorf("put key = %s, value = %s", key, value)
+	}
+}
+
+func TestKVPublisherWindow(t *testing.T) {
+	p := &kvPublisher{bucket: time.Second}
+	now := time.Now()
+	_, _ = p.Write([]byte("a"))
+	_, _ = p.Write([]byte("b"))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := p.count(now); n != 2 {
+		t.Errorf("count = %d", n)
+	}
+	// 超出窗口 (60 个桶) 的计数不再计入，total 仍然保留
+	if n := p.count(now.Add(kvBuckets * time.Second)); n != 0 || p.total != 2 {
+		t.Errorf("count after window = %d, total = %d", n, p.total)
+	}
+}
+
+func TestKVPublisherFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	var reported error
+	p, err := newKVPublisher(&KVOptions{Backend: "consul", Endpoint: srv.URL, Key: "k", Interval: time.Hour, OnError: func(err error) { reported = err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = p.Write([]byte("x"))
+	if err := p.Close(); err == nil || !strings.Contains(err.Error(), "status 403") || reported == nil {
+		t.Errorf("Close() = %v, reported = %v", err, reported)
+	}
+}
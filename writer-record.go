@@ -0,0 +1,181 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRecordedEntry 录制文件中单条记录的最大长度，防止损坏文件导致超大内存分配
+const maxRecordedEntry = 256 << 20
+
+// recordWriter 将适配器每次写入的字节原样录制到文件，供 Replay 重新发送到其他适配器，
+// 用于复现采集端的解析问题。记录格式: [4 字节大端长度][8 字节大端 UnixNano 时间][写入的字节]
+type recordWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	sent writerStats
+}
+
+func newRecordWriter(opts *RecordOptions) (zapcore.WriteSyncer, io.Closer, error) {
+	if opts.Path == "" {
+		return nil, nil, fmt.Errorf("record path is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	f, err := os.OpenFile(opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open record file: %w", err)
+	}
+	w := &recordWriter{file: f, sent: writerStats{onError: opts.OnError}}
+	return w, w, nil
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	record := make([]byte, 12, 12+len(p))
+	binary.BigEndian.PutUint32(record[:4], uint32(len(p)))
+	binary.BigEndian.PutUint64(record[4:12], uint64(time.Now().UnixNano()))
+	record = append(record, p...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, ErrWriterClosed
+	}
+	// 整条记录一次写入，进程崩溃时最多留下末尾一条不完整的记录
+	if _, err := w.file.Write(record); err != nil {
+		err = fmt.Errorf("write record file: %w", err)
+		w.sent.failure(err)
+		return 0, err
+	}
+	w.sent.success(len(p))
+	return len(p), nil
+}
+
+func (w *recordWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *recordWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *recordWriter) stats(st *AdaptorStats) { w.sent.stats(st) }
+
+// ReadRecording 按顺序读取 record 适配器录制的记录，fn 收到写入时间与原始字节（调用返回后不可再使用）。
+// 末尾不完整的记录（进程在写入时退出）被忽略
+func ReadRecording(r io.Reader, fn func(at time.Time, data []byte) error) error {
+	br := bufio.NewReader(r)
+	var header [12]byte
+	var buf []byte
+	for record := 1; ; record++ {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("record %d: %w", record, err)
+		}
+		size := binary.BigEndian.Uint32(header[:4])
+		if size > maxRecordedEntry {
+			return fmt.Errorf("record %d: invalid length %d", record, size)
+		}
+		if cap(buf) < int(size) {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("record %d: %w", record, err)
+		}
+		at := time.Unix(0, int64(binary.BigEndian.Uint64(header[4:12])))
+		if err := fn(at, buf); err != nil {
+			return fmt.Errorf("record %d: %w", record, err)
+		}
+	}
+}
+
+// Replay 将 record 适配器录制的文件按原顺序重新写入 dsn 指定的适配器（file、http、syslog 等），
+// 写入的是录制时的原始字节，不经过编码器，DSN 中的 format 等编码参数不生效。
+// HTTP 适配器未设置 overflow 时使用 block 策略、block-timeout 默认 1m，避免重放过快导致丢弃；
+// 返回前关闭目标适配器，等待缓冲中的日志发送完成
+func Replay(path, dsn string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, closer, err := newReplayWriter(dsn)
+	if err != nil {
+		return err
+	}
+	err = ReadRecording(f, func(_ time.Time, data []byte) error {
+		_, err := w.Write(data)
+		return err
+	})
+	return errors.Join(err, closer.Close())
+}
+
+// newReplayWriter 根据 DSN 创建 Replay 的目标 writer
+func newReplayWriter(dsn string) (zapcore.WriteSyncer, io.Closer, error) {
+	u, err := parseDSN(dsn)
+	if err != nil || u.Scheme == "" {
+		return nil, nil, fmt.Errorf("invalid adaptor DSN: %s", redactDSN(dsn))
+	}
+	switch u.Scheme {
+	case "file":
+		opts, err := parseFileOptions(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newFileWriter(opts)
+	case "http", "https":
+		opts, err := parseHTTPOptions(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		query := u.Query()
+		if !query.Has("overflow") {
+			opts.Overflow = "block"
+			if !query.Has("block-timeout") {
+				opts.BlockTimeout = time.Minute
+			}
+		}
+		return newHTTPWriter(opts)
+	case "syslog+tls", "relp", "relp+tls":
+		opts, err := parseSyslogOptions(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newSyslogWriter(opts)
+	case "record":
+		opts, err := parseRecordOptions(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newRecordWriter(opts)
+	default:
+		return nil, nil, fmt.Errorf("unsupported scheme for replay: %s", u.Scheme)
+	}
+}
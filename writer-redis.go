@@ -0,0 +1,279 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedisWriter 将每条日志写入 Redis：list 模式使用 LPUSH（消费端 BRPOP 即按写入顺序读取），
+// stream 模式使用 XADD。设置 MaxLen 时 list 在同一批命令中用 LTRIM 裁剪，
+// stream 使用 XADD MAXLEN ~ 近似裁剪。写入是同步的，连接断开时下一次写入自动重连；
+// 连接失败后进入退避，退避期内写入立即失败
+type RedisWriter struct {
+	opts    *RedisOptions
+	timeout time.Duration
+	tls     *tls.Config // nil 表示不使用 TLS
+	sent    writerStats
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	redial dialBackoff
+	closed bool
+}
+
+func newRedisWriter(opts *RedisOptions) (zapcore.WriteSyncer, io.Closer, error) {
+	writer, err := NewRedisWriter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return writer, writer, nil
+}
+
+// NewRedisWriter 根据选项创建 Redis writer，创建时不连接，第一次写入或探测时建立连接
+func NewRedisWriter(opts *RedisOptions) (*RedisWriter, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("redis address is empty")
+	}
+	if opts.Key == "" {
+		return nil, fmt.Errorf("redis key is empty")
+	}
+	w := &RedisWriter{
+		opts:    opts,
+		timeout: cmp.Or(opts.Timeout, 5*time.Second),
+		sent:    writerStats{onError: opts.OnError},
+	}
+	if opts.UseTLS {
+		cfg, err := buildTLSConfig(&opts.TLS)
+		if err != nil {
+			return nil, err
+		}
+		w.tls = cfg
+	}
+	return w, nil
+}
+
+// Write 写入一条日志，末尾的换行不写入
+func (w *RedisWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	var err error
+	// 连接可能已被服务端关闭，失败时重连再试一次；连接失败不再重试
+	for range 2 {
+		if w.conn == nil {
+			if err = w.redial.check(time.Now()); err != nil {
+				break
+			}
+			if err = w.connect(context.Background()); err != nil {
+				w.redial.failed(time.Now(), err)
+				break
+			}
+			w.redial.reset()
+		}
+		if err = w.do(w.commands(msg)...); err == nil {
+			w.sent.success(len(msg))
+			return len(p), nil
+		}
+		// 服务端返回的错误（如键的类型不对）重试也不会成功，连接仍然可用
+		if errors.As(err, new(redisError)) {
+			break
+		}
+		w.disconnect()
+	}
+	err = fmt.Errorf("failed to write to redis: %w", err)
+	w.sent.failure(err)
+	return 0, err
+}
+
+// commands 返回写入一条日志的命令
+func (w *RedisWriter) commands(msg []byte) [][][]byte {
+	key := []byte(w.opts.Key)
+	if w.opts.Stream {
+		xadd := [][]byte{[]byte("XADD"), key}
+		if w.opts.MaxLen > 0 {
+			xadd = append(xadd, []byte("MAXLEN"), []byte("~"), strconv.AppendInt(nil, w.opts.MaxLen, 10))
+		}
+		return [][][]byte{append(xadd, []byte("*"), []byte(w.opts.Field), msg)}
+	}
+	cmds := [][][]byte{{[]byte("LPUSH"), key, msg}}
+	if w.opts.MaxLen > 0 {
+		cmds = append(cmds, [][]byte{[]byte("LTRIM"), key, []byte("0"), strconv.AppendInt(nil, w.opts.MaxLen-1, 10)})
+	}
+	return cmds
+}
+
+func (w *RedisWriter) Sync() error { return nil }
+
+// Close 关闭连接
+func (w *RedisWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	w.disconnect()
+	return nil
+}
+
+// Probe 建立连接并发送 PING 以确认服务端可达且认证通过，失败时记录到运行状态
+func (w *RedisWriter) Probe(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	err := func() error {
+		if w.conn == nil {
+			if err := w.connect(ctx); err != nil {
+				return err
+			}
+		}
+		return w.do([][]byte{[]byte("PING")})
+	}()
+	if err != nil {
+		w.disconnect()
+		err = fmt.Errorf("probe failed: %w", err)
+		w.sent.failure(err)
+		return err
+	}
+	// 探测不受退避限制，成功后写入立即恢复
+	w.redial.reset()
+	return nil
+}
+
+func (w *RedisWriter) stats(st *AdaptorStats) { w.sent.stats(st) }
+
+// connect 建立连接并完成认证与选择数据库，调用方需持有锁
+func (w *RedisWriter) connect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if w.tls != nil {
+		conn, err = (&tls.Dialer{Config: w.tls}).DialContext(ctx, "tcp", w.opts.Address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", w.opts.Address)
+	}
+	if err != nil {
+		return err
+	}
+	w.conn, w.reader = conn, bufio.NewReader(conn)
+	var setup [][][]byte
+	switch {
+	case w.opts.Username != "":
+		setup = append(setup, [][]byte{[]byte("AUTH"), []byte(w.opts.Username), []byte(w.opts.Password)})
+	case w.opts.Password != "":
+		setup = append(setup, [][]byte{[]byte("AUTH"), []byte(w.opts.Password)})
+	}
+	if w.opts.DB != 0 {
+		setup = append(setup, [][]byte{[]byte("SELECT"), strconv.AppendInt(nil, int64(w.opts.DB), 10)})
+	}
+	if len(setup) > 0 {
+		if err := w.do(setup...); err != nil {
+			w.disconnect()
+			return fmt.Errorf("redis setup: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *RedisWriter) disconnect() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn, w.reader = nil, nil
+	}
+}
+
+// do 以 pipeline 方式发送命令并读取全部响应，返回第一个错误，调用方需持有锁
+func (w *RedisWriter) do(cmds ...[][]byte) error {
+	var buf []byte
+	for _, args := range cmds {
+		buf = appendRESPCommand(buf, args)
+	}
+	_ = w.conn.SetDeadline(time.Now().Add(w.timeout))
+	if _, err := w.conn.Write(buf); err != nil {
+		return err
+	}
+	var first error
+	for range cmds {
+		if err := readRESPReply(w.reader); err != nil {
+			if !errors.As(err, new(redisError)) {
+				return err
+			}
+			first = cmp.Or(first, err)
+		}
+	}
+	return first
+}
+
+// redisError 服务端返回的错误响应
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// appendRESPCommand 以 RESP 数组编码一条命令
+func appendRESPCommand(buf []byte, args [][]byte) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readRESPReply 读取并丢弃一个响应，错误响应返回 redisError
+func readRESPReply(r *bufio.Reader) error {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return errors.New("malformed redis reply")
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisError(line[1:])
+	case '$', '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return fmt.Errorf("malformed redis reply: %q", line)
+		}
+		if n < 0 {
+			return nil // nil 值
+		}
+		if line[0] == '$' {
+			_, err = r.Discard(n + 2)
+			return err
+		}
+		for range n {
+			if err := readRESPReply(r); err != nil && !errors.As(err, new(redisError)) {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected redis reply: %q", line)
+	}
+}
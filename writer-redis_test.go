@@ -0,0 +1,204 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// redisServer 简单的 RESP 服务端，handle 返回命令的响应（含结尾的 \r\n），返回空字符串时断开连接
+func redisServer(t *testing.T, handle func(conn int, args []string) string) (addr string, received chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	received = make(chan string, 16)
+	go func() {
+		for n := 1; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(n int) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readRESPCommand(r)
+					if err != nil {
+						return
+					}
+					received <- strings.Join(args, " ")
+					reply := handle(n, args)
+					if reply == "" {
+						return
+					}
+					_, _ = io.WriteString(conn, reply)
+				}
+			}(n)
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisWriterList(t *testing.T) {
+	// 第一个连接在 LPUSH 时断开，writer 重连后重发
+	addr, received := redisServer(t, func(conn int, args []string) string {
+		switch args[0] {
+		case "LPUSH":
+			if conn == 1 {
+				return ""
+			}
+			return ":1\r\n"
+		case "AUTH", "SELECT", "LTRIM":
+			return "+OK\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+	w, err := NewRedisWriter(&RedisOptions{Address: addr, Password: "pw", DB: 2, Key: "app:logs", MaxLen: 100, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"msg":"a"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for range 6 {
+		got = append(got, <-received)
+	}
+	want := []string{
+		"AUTH pw", "SELECT 2", `LPUSH app:logs {"msg":"a"}`,
+		"AUTH pw", "SELECT 2", `LPUSH app:logs {"msg":"a"}`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("received:\n%s", strings.Join(got, "\n"))
+	}
+	if cmd := <-received; cmd != "LTRIM app:logs 0 99" {
+		t.Errorf("trim = %q", cmd)
+	}
+	var st AdaptorStats
+	w.stats(&st)
+	if st.Written != uint64(len(`{"msg":"a"}`)) || st.LastError != "" {
+		t.Errorf("stats = %+v", st)
+	}
+	if _, err := w.Write([]byte("closed")); err != ErrWriterClosed {
+		t.Errorf("write after close: %v", err)
+	}
+}
+
+func TestRedisWriterStream(t *testing.T) {
+	addr, received := redisServer(t, func(_ int, args []string) string {
+		if args[0] == "XADD" {
+			id := "1700000000000-0"
+			return "$" + strconv.Itoa(len(id)) + "\r\n" + id + "\r\n"
+		}
+		return "+PONG\r\n"
+	})
+	w, err := NewRedisWriter(&RedisOptions{Address: addr, Key: "logs", Stream: true, MaxLen: 1000, Field: "log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received + "," + <-received; got != "PING,XADD logs MAXLEN ~ 1000 * log line" {
+		t.Errorf("received %q", got)
+	}
+}
+
+func TestRedisWriterRejected(t *testing.T) {
+	addr, received := redisServer(t, func(int, []string) string {
+		return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+	})
+	var reported error
+	w, err := NewRedisWriter(&RedisOptions{Address: addr, Key: "logs", OnError: func(err error) { reported = err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("msg")); err == nil || !strings.Contains(err.Error(), "redis: WRONGTYPE") {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// 服务端的错误响应不重试
+	<-received
+	select {
+	case cmd := <-received:
+		t.Errorf("unexpected retry: %s", cmd)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if reported == nil {
+		t.Error("expected OnError to be called")
+	}
+}
+
+func TestRedisWriterReconnectBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w, err := NewRedisWriter(&RedisOptions{Address: addr, Key: "logs", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("first")); err == nil || errors.Is(err, ErrReconnectBackoff) {
+		t.Fatalf("first Write() error = %v", err)
+	}
+	// 退避期内不再连接，立即失败
+	start := time.Now()
+	for range 100 {
+		if _, err := w.Write([]byte("more")); !errors.Is(err, ErrReconnectBackoff) {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("100 writes blocked for %v during backoff", d)
+	}
+	// 退避结束后再次连接
+	w.mu.Lock()
+	w.redial.until = time.Now()
+	w.mu.Unlock()
+	if _, err := w.Write([]byte("retry")); err == nil || errors.Is(err, ErrReconnectBackoff) {
+		t.Fatalf("Write() after backoff error = %v", err)
+	}
+	if w.redial.failures != 2 {
+		t.Errorf("failures = %d", w.redial.failures)
+	}
+}
@@ -0,0 +1,107 @@
+package log
+
+import "sync/atomic"
+
+// ringQueue 有界无锁队列 (Vyukov MPMC)，作为 HTTPWriter 缓冲区通道的替代 (queue=ring)。
+// 写入方通过 CAS 预留槽位，不需要像通道那样争用同一把锁，每个写入协程都有独立的 CPU 时竞争更小。
+// 队列满或槽位被抢占时写入方自旋重试，GOMAXPROCS 超过实际核数时自旋反而拖慢写入：
+// 在 1 核机器上 BenchmarkQueue 的结果为 GOMAXPROCS=1 时 ring 约 36ns/条、通道约 46ns/条，
+// GOMAXPROCS=4 时 ring 升至 100~200ns/条而通道不变。通道还可以直接 select，所以默认仍使用通道，
+// 在目标机器上用 go test -bench Queue -cpu 1,4,16 确认 ring 占优后再开启
+//
+// 满时 push 返回 false，由调用方决定丢弃、等待 (space) 或覆盖最旧的日志 (overflow=drop-oldest)
+type ringQueue struct {
+	slots []ringSlot
+	mask  uint64
+	_     [40]byte
+	tail  atomic.Uint64 // 下一个写入位置
+	_     [56]byte
+	head  atomic.Uint64 // 下一个读取位置
+	_     [56]byte
+	ready chan struct{} // 有新日志时通知消费者
+	space chan struct{} // 有空位时通知等待的写入方，不需要等待时为 nil
+	done  chan struct{} // 关闭后不再写入，消费者取完剩余日志后退出
+}
+
+// ringSlot 槽位，seq 等于位置时可写，等于位置 +1 时可读
+type ringSlot struct {
+	seq atomic.Uint64
+	val *queuedEntry
+}
+
+// newRingQueue 创建容量不小于 size 的队列，容量向上取整为 2 的幂且至少为 2
+// （只有一个槽位时无法区分空与满）
+func newRingQueue(size int, blocking bool) *ringQueue {
+	n := 2
+	for n < size {
+		n <<= 1
+	}
+	q := &ringQueue{
+		slots: make([]ringSlot, n),
+		mask:  uint64(n - 1),
+		ready: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	if blocking {
+		q.space = make(chan struct{}, 1)
+	}
+	return q
+}
+
+// push 写入一条日志，队列已满时返回 false
+func (q *ringQueue) push(e *queuedEntry) bool {
+	for {
+		pos := q.tail.Load()
+		slot := &q.slots[pos&q.mask]
+		switch diff := int64(slot.seq.Load() - pos); {
+		case diff == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				slot.val = e
+				slot.seq.Store(pos + 1)
+				notify(q.ready)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+		// 槽位已被其他写入方预留，重新读取位置
+	}
+}
+
+// pop 取出最旧的一条日志，队列为空（或最旧的槽位尚未写完）时返回 false
+func (q *ringQueue) pop() (*queuedEntry, bool) {
+	for {
+		pos := q.head.Load()
+		slot := &q.slots[pos&q.mask]
+		switch diff := int64(slot.seq.Load() - (pos + 1)); {
+		case diff == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				e := slot.val
+				slot.val = nil
+				slot.seq.Store(pos + q.mask + 1)
+				notify(q.space)
+				return e, true
+			}
+		case diff < 0:
+			return nil, false
+		}
+	}
+}
+
+func (q *ringQueue) len() int {
+	n := int64(q.tail.Load() - q.head.Load())
+	return int(min(max(n, 0), int64(len(q.slots))))
+}
+
+func (q *ringQueue) cap() int { return len(q.slots) }
+
+// notify 非阻塞地发送通知，已有未处理的通知时忽略
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,218 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingQueue(t *testing.T) {
+	if q := newRingQueue(1, false); q.cap() != 2 {
+		t.Fatalf("cap = %d, want 2", q.cap())
+	}
+	q := newRingQueue(3, false)
+	if q.cap() != 4 {
+		t.Fatalf("cap = %d, want 4", q.cap())
+	}
+	// 多轮写满再取空，覆盖位置回绕
+	for round := range 3 {
+		for i := range 4 {
+			if !q.push(newEntry([]byte(strconv.Itoa(round*4 + i)))) {
+				t.Fatalf("round %d: push %d failed", round, i)
+			}
+		}
+		if q.push(new(queuedEntry)) {
+			t.Fatalf("round %d: push to full queue succeeded", round)
+		}
+		if q.len() != 4 {
+			t.Errorf("round %d: len = %d, want 4", round, q.len())
+		}
+		for i := range 4 {
+			e, ok := q.pop()
+			if !ok || e.String() != strconv.Itoa(round*4+i) {
+				t.Fatalf("round %d: pop = %v, %v, want %d", round, e, ok, round*4+i)
+			}
+		}
+		if _, ok := q.pop(); ok {
+			t.Fatalf("round %d: pop from empty queue succeeded", round)
+		}
+	}
+}
+
+func TestRingQueueConcurrent(t *testing.T) {
+	const producers, perProducer = 8, 1000
+	q := newRingQueue(64, false)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perProducer {
+				e := newEntry([]byte(fmt.Sprintf("%d-%d", p, i)))
+				for !q.push(e) {
+					time.Sleep(time.Microsecond)
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 每个生产者的日志按写入顺序取出，且不重复不丢失
+	next := make([]int, producers)
+	for n := 0; n < producers*perProducer; {
+		e, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.ready:
+			case <-time.After(time.Second):
+				t.Fatalf("timed out after %d entries", n)
+			}
+			continue
+		}
+		var p, i int
+		if _, err := fmt.Sscanf(e.String(), "%d-%d", &p, &i); err != nil {
+			t.Fatal(err)
+		}
+		if i != next[p] {
+			t.Fatalf("producer %d: got entry %d, want %d", p, i, next[p])
+		}
+		next[p]++
+		n++
+	}
+	<-done
+}
+
+func TestHTTPWriterRingQueue(t *testing.T) {
+	var mu sync.Mutex
+	var received int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entries []any
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		mu.Lock()
+		received += len(entries)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Queue: "ring", Overflow: "block", BufferSize: 16, BatchSize: 10, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				if _, err := w.Write([]byte(`{"n":` + strconv.Itoa(i) + `}`)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if received != 200 {
+		t.Errorf("received %d entries, want 200", received)
+	}
+}
+
+func TestHTTPWriterDropOldest(t *testing.T) {
+	srv, started, release := stalledServer(t)
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Queue: "ring", Overflow: "drop-oldest", BufferSize: 2, BatchSize: 1, Spool: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一条阻塞在请求中，之后的 4 条只保留最新的 2 条
+	_, _ = w.Write([]byte(`{"n":0}`))
+	<-started
+	for i := 1; i <= 4; i++ {
+		if _, err := w.Write([]byte(`{"n":` + strconv.Itoa(i) + `}`)); err != nil {
+			t.Errorf("Write() error = %v", err)
+		}
+	}
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("dropped = %d, want 2", got)
+	}
+	var kept []string
+	for e, ok := w.ring.pop(); ok; e, ok = w.ring.pop() {
+		kept = append(kept, e.String())
+	}
+	if fmt.Sprint(kept) != `[{"n":3} {"n":4}]` {
+		t.Errorf("kept %v, want the newest two entries", kept)
+	}
+	close(release)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Overflow: "drop-oldest"}); err == nil {
+		t.Error("expected error for drop-oldest without ring queue")
+	}
+}
+
+// BenchmarkQueue 比较通道与 ringQueue 在多个写入协程下的吞吐，单个消费者持续取出，
+// 写入协程数为 producers × GOMAXPROCS。结果与交叉点见 ringQueue 的说明
+func BenchmarkQueue(b *testing.B) {
+	entry := newEntry([]byte(`{"level":"info","msg":"benchmark"}`))
+	for _, producers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("channel/producers=%d", producers), func(b *testing.B) {
+			ch := make(chan *queuedEntry, 1024)
+			done := make(chan struct{})
+			go func() {
+				for range ch {
+				}
+				close(done)
+			}()
+			b.SetParallelism(producers)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ch <- entry
+				}
+			})
+			close(ch)
+			<-done
+		})
+		b.Run(fmt.Sprintf("ring/producers=%d", producers), func(b *testing.B) {
+			q := newRingQueue(1024, false)
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					if _, ok := q.pop(); ok {
+						continue
+					}
+					select {
+					case <-q.ready:
+					case <-stop:
+						return
+					}
+				}
+			}()
+			b.SetParallelism(producers)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					for !q.push(entry) {
+						runtime.Gosched()
+					}
+				}
+			})
+			close(stop)
+			<-done
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// rotateNotifier 包装 lumberjack，在滚动后回调被滚动的文件路径
+// lumberjack 本身没有滚动回调，这里按照其滚动规则跟踪当前文件大小来判断
+type rotateNotifier struct {
+	*lumberjack.Logger
+	hook func(path string)
+	size int64
+	max  int64
+	mu   sync.Mutex
+}
+
+func newRotateNotifier(logger *lumberjack.Logger, hook func(path string)) *rotateNotifier {
+	r := &rotateNotifier{
+		Logger: logger,
+		hook:   hook,
+		max:    int64(logger.MaxSize) * mb,
+	}
+	if info, err := os.Stat(logger.Filename); err == nil {
+		r.size = info.Size()
+	}
+	return r
+}
+
+func (r *rotateNotifier) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rotated := r.size > 0 && r.size+int64(len(p)) > r.max
+	n, err := r.Logger.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if rotated {
+		r.size = int64(n)
+		if path := latestBackup(r.Filename); path != "" {
+			go r.notify(path)
+		}
+	} else {
+		r.size += int64(n)
+	}
+	return n, nil
+}
+
+// Rotate 手动滚动并回调
+func (r *rotateNotifier) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.Logger.Rotate(); err != nil {
+		return err
+	}
+	r.size = 0
+	if path := latestBackup(r.Filename); path != "" {
+		go r.notify(path)
+	}
+	return nil
+}
+
+// notify 回调被滚动的文件路径。开启压缩时等待 lumberjack 压缩完成，回调压缩后的路径，
+// 避免回调读取的原文件被压缩协程删除
+func (r *rotateNotifier) notify(path string) {
+	if r.Compress && !strings.HasSuffix(path, ".gz") {
+		path = waitCompressed(path, compressWait)
+	}
+	r.hook(path)
+}
+
+// compressWait 等待滚动文件压缩完成的最长时间
+const compressWait = time.Minute
+
+// waitCompressed 等待 lumberjack 将 path 压缩为 path.gz 并删除原文件，返回压缩后的路径；
+// 超时仍未完成时返回原路径
+func waitCompressed(path string, timeout time.Duration) string {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if _, err := os.Stat(path + ".gz"); err == nil {
+				return path + ".gz"
+			}
+		}
+		if time.Now().After(deadline) {
+			return path
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// latestBackup 查找最近一次滚动产生的备份文件
+// 备份文件名格式为 <name>-<timestamp><ext>，开启压缩时可能已被压缩为 .gz
+func latestBackup(filename string) string {
+	backups := listBackups(filename)
+	if len(backups) == 0 {
+		return ""
+	}
+	return backups[len(backups)-1]
+}
+
+// listBackups 按时间从旧到新列出所有备份文件
+func listBackups(filename string) []string {
+	dir := filepath.Dir(filename)
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".gz") {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	// 时间戳格式可按字典序排序
+	sort.Strings(backups)
+	return backups
+}
+
+// pruneHook 返回按总大小清理备份的回调，超出预算时从最旧的备份开始删除
+func pruneHook(filename string, budget int64) func(path string) {
+	var mu sync.Mutex
+	return func(string) {
+		mu.Lock()
+		defer mu.Unlock()
+		pruneBackups(filename, budget)
+	}
+}
+
+// pruneBackups 删除最旧的备份文件，直到备份总大小不超过 budget
+func pruneBackups(filename string, budget int64) {
+	backups := listBackups(filename)
+	sizes := make([]int64, len(backups))
+	var total int64
+	for i, path := range backups {
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; total > budget && i < len(backups); i++ {
+		// 压缩协程可能同时删除了原文件，忽略错误
+		_ = os.Remove(backups[i])
+		total -= sizes[i]
+	}
+}
+
+// commandHook 将 post-rotate-cmd 转为回调，被滚动的文件路径作为最后一个参数传入，
+// 同时通过环境变量 LOG_ROTATED_FILE 提供。命令按空白拆分参数，不支持引号；
+// 执行失败时将错误与命令输出交给 onError
+func commandHook(command string, onError func(err error)) func(path string) {
+	args := strings.Fields(command)
+	return func(path string) {
+		cmd := exec.Command(args[0], append(args[1:], path)...)
+		cmd.Env = append(os.Environ(), "LOG_ROTATED_FILE="+path)
+		out, err := cmd.CombinedOutput()
+		if err == nil || onError == nil {
+			return
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		onError(fmt.Errorf("post-rotate-cmd %s: %w", args[0], err))
+	}
+}
+
+// chainHooks 依次调用多个滚动回调
+func chainHooks(hooks ...func(path string)) func(path string) {
+	var active []func(path string)
+	for _, hook := range hooks {
+		if hook != nil {
+			active = append(active, hook)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return func(path string) {
+		for _, hook := range active {
+			hook(path)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP 批次签名相关的请求头
+const (
+	SignatureHeader = "X-Log-Signature" // 值为 sha256=<hex>
+	TimestampHeader = "X-Log-Timestamp" // 签名时间，Unix 秒
+)
+
+// ErrInvalidSignature 批次签名缺失、格式错误或与请求体不匹配
+var ErrInvalidSignature = errors.New("invalid batch signature")
+
+// signBatch 计算 HMAC-SHA256(key, timestamp + "." + body)
+func signBatch(key []byte, ts string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// signRequest 为请求附加时间戳与签名头，body 为实际发送的（压缩后的）请求体
+func signRequest(req *http.Request, key, body []byte, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(TimestampHeader, ts)
+	req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(signBatch(key, ts, body)))
+}
+
+// VerifyBatchSignature 供采集端校验批次签名，body 为原始（未解压的）请求体，
+// 时间戳与当前时间相差超过 maxAge 时视为重放，maxAge <= 0 表示不校验时间
+func VerifyBatchSignature(header http.Header, body, key []byte, maxAge time.Duration) error {
+	ts := header.Get(TimestampHeader)
+	sig, ok := strings.CutPrefix(header.Get(SignatureHeader), "sha256=")
+	if ts == "" || !ok {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signBatch(key, ts, body)) {
+		return ErrInvalidSignature
+	}
+	if maxAge <= 0 {
+		return nil
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(secs, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("%w: timestamp %s outside %s window", ErrInvalidSignature, ts, maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHTTPWriterSign(t *testing.T) {
+	key := []byte("secret")
+	verified := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := VerifyBatchSignature(r.Header, body, key, time.Minute)
+		if err == nil {
+			// 篡改请求体后签名不再匹配
+			err = VerifyBatchSignature(r.Header, append(body, ' '), key, time.Minute)
+			if !errors.Is(err, ErrInvalidSignature) {
+				err = errors.New("tampered body passed verification")
+			} else {
+				err = nil
+			}
+		}
+		verified <- err
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_HMAC_KEY", string(key))
+	opts, err := parseHTTPOptions(srv.URL + "?sign=hmac-sha256&sign-key-env=TEST_HMAC_KEY&compress=gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewHTTPWriter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := w.sendBatch([][]byte{[]byte(`{"msg":"signed"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-verified; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL, Sign: "hmac-sha256", SignKeyEnv: "TEST_HMAC_MISSING"}); err == nil {
+		t.Error("expected error for empty sign key")
+	}
+}
+
+func TestVerifyBatchSignatureReplay(t *testing.T) {
+	key, body := []byte("secret"), []byte(`[{"msg":"old"}]`)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	signRequest(req, key, body, time.Now().Add(-time.Hour))
+
+	if err := VerifyBatchSignature(req.Header, body, key, 0); err != nil {
+		t.Errorf("without maxAge: %v", err)
+	}
+	if err := VerifyBatchSignature(req.Header, body, key, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("stale timestamp: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// 修改时间戳会使签名失效
+	req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	if err := VerifyBatchSignature(req.Header, body, key, 5*time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("forged timestamp: err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const spoolExt = ".ndjson"
+
+// spool 磁盘队列，保存发送失败或关闭时未发送的批次，供之后重新发送
+// 每个批次一个文件，文件名以纳秒时间戳开头，按字典序即为写入顺序
+type spool struct {
+	dir string
+	max int64
+	seq atomic.Uint64
+	mu  sync.Mutex
+}
+
+func newSpool(dir string, max int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &spool{dir: dir, max: max}, nil
+}
+
+// save 将批次写入磁盘，超出容量时删除最旧的批次
+func (s *spool) save(batch [][]byte) error {
+	var buf bytes.Buffer
+	for _, data := range batch {
+		buf.Write(bytes.TrimSpace(data))
+		buf.WriteByte('\n')
+	}
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq.Add(1)%1000000, spoolExt)
+	path := filepath.Join(s.dir, name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 先写临时文件再重命名，避免重放时读到不完整的批次
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if s.max > 0 {
+		s.prune()
+	}
+	return nil
+}
+
+// prune 删除最旧的批次直到总大小不超过上限，调用方需持有锁
+func (s *spool) prune() {
+	files := s.list()
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+	for i := 0; total > s.max && i < len(files); i++ {
+		_ = os.Remove(files[i])
+		total -= sizes[i]
+	}
+}
+
+// list 按写入顺序列出所有批次文件
+func (s *spool) list() []string {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), spoolExt) {
+			files = append(files, filepath.Join(s.dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// usage 返回磁盘队列中的批次数与总字节数
+func (s *spool) usage() (files int, size int64) {
+	for _, path := range s.list() {
+		if info, err := os.Stat(path); err == nil {
+			files++
+			size += info.Size()
+		}
+	}
+	return files, size
+}
+
+// load 读取批次文件。save 写入的文件总以换行结尾，不是时视为被截断，
+// 返回其中的日志与错误，由调用方丢弃
+func (s *spool) load(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var batch [][]byte
+	for line := range bytes.Lines(data) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			batch = append(batch, line)
+		}
+	}
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		return batch, fmt.Errorf("truncated spool batch %s", filepath.Base(path))
+	}
+	return batch, nil
+}
+
+// savedAt 从文件名解析批次的写入时间
+func (s *spool) savedAt(path string) (time.Time, bool) {
+	ts, _, ok := strings.Cut(filepath.Base(path), "-")
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if !ok || err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n), true
+}
+
+// remove 删除已发送的批次文件
+func (s *spool) remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = os.Remove(path)
+}
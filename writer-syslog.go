@@ -0,0 +1,269 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// relpOffer RELP open 命令携带的会话参数
+const relpOffer = "relp_version=0\nrelp_software=mulan-ext/log\ncommands=syslog"
+
+// SyslogWriter 通过 TCP 发送 syslog 消息：syslog+tls 按 RFC5425 以 octet-counting 分帧，
+// relp 每条消息等待接收端确认后才返回，确认失败时重连并重发一次（至少一次投递）。
+// 写入是同步的，连接断开时下一次写入自动重连；连接失败后进入退避，退避期内写入立即失败
+type SyslogWriter struct {
+	address string
+	relp    bool
+	timeout time.Duration
+	tls     *tls.Config // nil 表示不使用 TLS
+	sent    writerStats
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	txnr   int
+	redial dialBackoff
+	closed bool
+}
+
+func newSyslogWriter(opts *SyslogOptions) (zapcore.WriteSyncer, io.Closer, error) {
+	writer, err := NewSyslogWriter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return writer, writer, nil
+}
+
+// NewSyslogWriter 根据选项创建 syslog writer，创建时不连接，第一次写入或探测时建立连接
+func NewSyslogWriter(opts *SyslogOptions) (*SyslogWriter, error) {
+	if opts.Address == "" {
+		return nil, fmt.Errorf("syslog address is empty")
+	}
+	w := &SyslogWriter{
+		address: opts.Address,
+		relp:    opts.RELP,
+		timeout: cmp.Or(opts.Timeout, 5*time.Second),
+		sent:    writerStats{onError: opts.OnError},
+	}
+	if opts.UseTLS {
+		cfg, err := buildTLSConfig(&opts.TLS)
+		if err != nil {
+			return nil, err
+		}
+		w.tls = cfg
+	}
+	return w, nil
+}
+
+// Write 发送一条消息，末尾的换行不计入消息
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	var err error
+	// 连接可能已被接收端关闭，失败时重连再试一次；连接失败不再重试
+	for range 2 {
+		if w.conn == nil {
+			if err = w.redial.check(time.Now()); err != nil {
+				break
+			}
+			if err = w.connect(); err != nil {
+				w.redial.failed(time.Now(), err)
+				break
+			}
+			w.redial.reset()
+		}
+		if err = w.send(msg); err == nil {
+			w.sent.success(len(msg))
+			return len(p), nil
+		}
+		w.disconnect()
+	}
+	err = fmt.Errorf("failed to send syslog message: %w", err)
+	w.sent.failure(err)
+	return 0, err
+}
+
+func (w *SyslogWriter) Sync() error { return nil }
+
+// Close 关闭连接，RELP 会话先发送 close 命令
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.conn != nil && w.relp {
+		_ = w.command("close", nil)
+	}
+	w.disconnect()
+	return nil
+}
+
+// Probe 建立连接（RELP 完成 open 握手）以确认接收端可达，失败时记录到运行状态
+func (w *SyslogWriter) Probe(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.conn != nil {
+		return nil
+	}
+	// 探测不受退避限制，成功后写入立即恢复
+	if err := w.connectContext(ctx); err != nil {
+		err = fmt.Errorf("probe failed: %w", err)
+		w.sent.failure(err)
+		return err
+	}
+	w.redial.reset()
+	return nil
+}
+
+func (w *SyslogWriter) stats(st *AdaptorStats) { w.sent.stats(st) }
+
+func (w *SyslogWriter) connect() error {
+	return w.connectContext(context.Background())
+}
+
+// connectContext 建立连接，调用方需持有锁
+func (w *SyslogWriter) connectContext(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if w.tls != nil {
+		conn, err = (&tls.Dialer{Config: w.tls}).DialContext(ctx, "tcp", w.address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", w.address)
+	}
+	if err != nil {
+		return err
+	}
+	w.conn, w.reader, w.txnr = conn, bufio.NewReader(conn), 0
+	if w.relp {
+		if err := w.command("open", []byte(relpOffer)); err != nil {
+			w.disconnect()
+			return fmt.Errorf("relp open: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *SyslogWriter) disconnect() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn, w.reader = nil, nil
+	}
+}
+
+// send 发送一条消息，调用方需持有锁
+func (w *SyslogWriter) send(msg []byte) error {
+	if w.relp {
+		return w.command("syslog", msg)
+	}
+	// RFC5425: MSG-LEN SP SYSLOG-MSG
+	frame := make([]byte, 0, len(msg)+8)
+	frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+	frame = append(frame, ' ')
+	frame = append(frame, msg...)
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	_, err := w.conn.Write(frame)
+	return err
+}
+
+// command 发送 RELP 命令并等待对应的 rsp，响应码不是 200 时返回错误
+func (w *SyslogWriter) command(cmd string, data []byte) error {
+	w.txnr++
+	frame := fmt.Appendf(nil, "%d %s %d", w.txnr, cmd, len(data))
+	if len(data) > 0 {
+		frame = append(frame, ' ')
+		frame = append(frame, data...)
+	}
+	frame = append(frame, '\n')
+	_ = w.conn.SetDeadline(time.Now().Add(w.timeout))
+	if _, err := w.conn.Write(frame); err != nil {
+		return err
+	}
+	// close 的响应无需等待，随后直接关闭连接
+	if cmd == "close" {
+		return nil
+	}
+	txnr, rsp, body, err := readRELPFrame(w.reader)
+	if err != nil {
+		return err
+	}
+	if rsp == "serverclose" {
+		return errors.New("relp server closed the session")
+	}
+	if rsp != "rsp" || txnr != w.txnr {
+		return fmt.Errorf("unexpected relp response %d %s", txnr, rsp)
+	}
+	if !bytes.HasPrefix(body, []byte("200")) {
+		status, _, _ := bytes.Cut(body, []byte{'\n'})
+		return fmt.Errorf("relp %s rejected: %s", cmd, status)
+	}
+	return nil
+}
+
+// readRELPFrame 读取一个 RELP 帧: TXNR SP COMMAND SP DATALEN [SP DATA] LF
+func readRELPFrame(r *bufio.Reader) (txnr int, cmd string, data []byte, err error) {
+	token := func() (string, byte, error) {
+		var b []byte
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return "", 0, err
+			}
+			if c == ' ' || c == '\n' {
+				return string(b), c, nil
+			}
+			if len(b) > 32 {
+				return "", 0, errors.New("malformed relp frame")
+			}
+			b = append(b, c)
+		}
+	}
+	s, _, err := token()
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if txnr, err = strconv.Atoi(s); err != nil {
+		return 0, "", nil, fmt.Errorf("malformed relp txnr: %q", s)
+	}
+	if cmd, _, err = token(); err != nil {
+		return 0, "", nil, err
+	}
+	s, sep, err := token()
+	if err != nil {
+		return 0, "", nil, err
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, "", nil, fmt.Errorf("malformed relp datalen: %q", s)
+	}
+	if n == 0 {
+		if sep != '\n' {
+			_, err = r.ReadByte()
+		}
+		return txnr, cmd, nil, err
+	}
+	data = make([]byte, n+1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, "", nil, err
+	}
+	return txnr, cmd, data[:n], nil
+}
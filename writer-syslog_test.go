@@ -0,0 +1,233 @@
+package log
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// relpServer 简单的 RELP 接收端，handle 返回 syslog 命令的响应，返回空字符串时不响应并断开连接
+func relpServer(t *testing.T, handle func(conn int, msg string) string) (addr string, received chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	received = make(chan string, 16)
+	go func() {
+		for n := 1; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(n int) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					txnr, cmd, data, err := readRELPFrame(r)
+					if err != nil {
+						return
+					}
+					rsp := "200 OK"
+					switch cmd {
+					case "close":
+						return
+					case "syslog":
+						received <- string(data)
+						if rsp = handle(n, string(data)); rsp == "" {
+							return
+						}
+					}
+					fmt.Fprintf(conn, "%d rsp %d %s\n", txnr, len(rsp), rsp)
+				}
+			}(n)
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSyslogWriterRELP(t *testing.T) {
+	// 第一个连接收到消息后不确认就断开，writer 重连后重发
+	addr, received := relpServer(t, func(conn int, msg string) string {
+		if conn == 1 {
+			return ""
+		}
+		return "200 OK"
+	})
+	w, err := NewSyslogWriter(&SyslogOptions{Address: addr, RELP: true, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first\n", "second\n"} {
+		if _, err := w.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for range 3 {
+		got = append(got, <-received)
+	}
+	if strings.Join(got, ",") != "first,first,second" {
+		t.Errorf("received %q", got)
+	}
+	var st AdaptorStats
+	w.stats(&st)
+	if st.Written != uint64(len("first")+len("second")) || st.LastError != "" {
+		t.Errorf("stats = %+v", st)
+	}
+	if _, err := w.Write([]byte("closed")); err != ErrWriterClosed {
+		t.Errorf("write after close: %v", err)
+	}
+}
+
+func TestSyslogWriterRELPRejected(t *testing.T) {
+	addr, _ := relpServer(t, func(int, string) string { return "500 queue full" })
+	var reported error
+	w, err := NewSyslogWriter(&SyslogOptions{Address: addr, RELP: true, OnError: func(err error) { reported = err }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("msg")); err == nil || !strings.Contains(err.Error(), "relp syslog rejected: 500 queue full") {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if reported == nil {
+		t.Error("expected OnError to be called")
+	}
+}
+
+func TestSyslogWriterTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	frames := make(chan string, 4)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			// RFC5425 octet-counting: MSG-LEN SP SYSLOG-MSG
+			s, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(s))
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			frames <- string(buf)
+		}
+	}()
+
+	w, err := NewSyslogWriter(&SyslogOptions{
+		Address: ln.Addr().String(),
+		UseTLS:  true,
+		TLS:     TLSOptions{Insecure: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, msg := range []string{"<14>1 - - - - - - hello world\n", "<11>1 - - - - - - multi\nline"} {
+		if _, err := w.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"<14>1 - - - - - - hello world", "<11>1 - - - - - - multi\nline"} {
+		select {
+		case got := <-frames:
+			if got != want {
+				t.Errorf("frame = %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for frame")
+		}
+	}
+}
+
+func TestSyslogWriterProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w, err := NewSyslogWriter(&SyslogOptions{Address: addr, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Probe(t.Context()); err == nil || !strings.Contains(err.Error(), "probe failed") {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	var st AdaptorStats
+	w.stats(&st)
+	if !strings.Contains(st.LastError, "probe failed") {
+		t.Errorf("LastError = %q", st.LastError)
+	}
+}
+
+func TestSyslogWriterReconnectBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	w, err := NewSyslogWriter(&SyslogOptions{Address: addr, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("<14>1 - - - - - - first\n")); err == nil || errors.Is(err, ErrReconnectBackoff) {
+		t.Fatalf("first Write() error = %v", err)
+	}
+	// 退避期内不再连接，立即失败
+	start := time.Now()
+	if _, err := w.Write([]byte("<14>1 - - - - - - second\n")); !errors.Is(err, ErrReconnectBackoff) || !strings.Contains(err.Error(), "refused") {
+		t.Fatalf("second Write() error = %v", err)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("Write() blocked for %v during backoff", d)
+	}
+
+	// 接收端恢复后，探测成功立即结束退避
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			_, _ = io.Copy(io.Discard, conn)
+		}
+	}()
+	if err := w.Probe(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("<14>1 - - - - - - third\n")); err != nil {
+		t.Errorf("Write() after probe error = %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// 日志中承载链路信息的字段名
+const (
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
+)
+
+// traceFields 日志中的链路字段
+type traceFields struct {
+	TraceID    string `json:"trace_id"`
+	SpanID     string `json:"span_id"`
+	TraceFlags string `json:"trace_flags"`
+}
+
+// batchTraceparent 取批次中第一条带有效链路信息的日志，生成 W3C traceparent，
+// 没有时返回空字符串
+func batchTraceparent(batch [][]byte) string {
+	needle := []byte(`"` + TraceIDKey + `"`)
+	for _, data := range batch {
+		if !bytes.Contains(data, needle) {
+			continue
+		}
+		var f traceFields
+		if json.Unmarshal(data, &f) != nil {
+			continue
+		}
+		if !validTraceHex(f.TraceID, 32) || !validTraceHex(f.SpanID, 16) {
+			continue
+		}
+		flags := f.TraceFlags
+		if !validTraceHex(flags, 2) {
+			flags = "01"
+		}
+		return "00-" + f.TraceID + "-" + f.SpanID + "-" + flags
+	}
+	return ""
+}
+
+// validTraceHex 校验小写十六进制、长度固定且不全为 0（flags 允许为 00）
+func validTraceHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil || hex.EncodeToString(b) != s {
+		return false
+	}
+	return n == 2 || bytes.Count(b, []byte{0}) != len(b)
+}
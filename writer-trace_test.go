@@ -0,0 +1,60 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchTraceparent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name  string
+		batch []string
+		want  string
+	}{
+		{"no trace", []string{`{"msg":"a"}`}, ""},
+		{"first traced entry", []string{`{"msg":"a"}`, `{"trace_id":"` + traceID + `","span_id":"` + spanID + `"}`}, "00-" + traceID + "-" + spanID + "-01"},
+		{"trace flags", []string{`{"trace_id":"` + traceID + `","span_id":"` + spanID + `","trace_flags":"00"}`}, "00-" + traceID + "-" + spanID + "-00"},
+		{"zero trace id", []string{`{"trace_id":"00000000000000000000000000000000","span_id":"` + spanID + `"}`}, ""},
+		{"uppercase trace id", []string{`{"trace_id":"4BF92F3577B34DA6A3CE929D0E0E4736","span_id":"` + spanID + `"}`}, ""},
+		{"missing span", []string{`{"trace_id":"` + traceID + `"}`}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := make([][]byte, len(tt.batch))
+			for i, entry := range tt.batch {
+				batch[i] = []byte(entry)
+			}
+			if got := batchTraceparent(batch); got != tt.want {
+				t.Errorf("batchTraceparent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPWriterTraceparent(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	w, err := NewHTTPWriter(&HTTPOptions{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	entry := `{"msg":"traced","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"}`
+	if err := w.sendBatch([][]byte{[]byte(entry)}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-received, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+}